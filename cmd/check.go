@@ -0,0 +1,72 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/mcjcloud/taurine/pkg/checker"
+	"github.com/mcjcloud/taurine/pkg/parser"
+	"github.com/spf13/cobra"
+)
+
+var checkCmd = &cobra.Command{
+	Use:   "check <file.tc>",
+	Short: "check a taurine program and its imports for errors without running it",
+	Args: func(cmd *cobra.Command, args []string) error {
+		if len(args) == 0 {
+			return fmt.Errorf("missing source file")
+		}
+		return nil
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		absPath, err := filepath.Abs(args[0])
+		if err != nil {
+			fmt.Printf("Could not get absolute path to source file: %s\n", err.Error())
+			os.Exit(1)
+		}
+
+		// create parse context
+		ctx, err := parser.NewParseContext(absPath)
+		if err != nil {
+			fmt.Printf("Could not create parse context: %s\n", err.Error())
+			os.Exit(1)
+		}
+
+		// parse using context
+		tree := parser.Parse(ctx)
+		ctx.PopImportWithTree(tree)
+
+		// check for import cycles
+		if cycles := ctx.ImportGraph.FindCycles(); len(cycles) > 0 {
+			fmt.Println("import cycle found.")
+			for _, n := range cycles {
+				fmt.Println(n)
+			}
+			os.Exit(1)
+		}
+
+		// print any errors during parsing
+		if ctx.HasErrors() {
+			ctx.PrintErrors()
+			os.Exit(1)
+		}
+
+		// type check every file, printing the errors like parse errors and the warnings along with the parser's
+		for path, handler := range checker.Check(ctx.ImportGraph, absPath) {
+			if parsed, ok := ctx.ErrorHandlers[path]; ok {
+				handler.Warnings = append(parsed.Warnings, handler.Warnings...)
+			}
+			ctx.ErrorHandlers[path] = handler
+		}
+		ctx.PrintWarnings()
+		if ctx.HasErrors() {
+			ctx.PrintErrors()
+			os.Exit(1)
+		}
+	},
+}
+
+func buildCheckCommand() *cobra.Command {
+	return checkCmd
+}
@@ -0,0 +1,170 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/mcjcloud/taurine/pkg/ast"
+	"github.com/mcjcloud/taurine/pkg/evaluator"
+	"github.com/mcjcloud/taurine/pkg/lexer"
+	"github.com/mcjcloud/taurine/pkg/parser"
+	"github.com/mcjcloud/taurine/pkg/token"
+	"github.com/mcjcloud/taurine/pkg/value"
+	"github.com/spf13/cobra"
+)
+
+const (
+	replPrompt         = "> "
+	replContinuePrompt = "... "
+)
+
+var replCmd = &cobra.Command{
+	Use:   "repl",
+	Short: "start an interactive taurine session",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		wd, err := os.Getwd()
+		if err != nil {
+			fmt.Printf("Could not get working directory: %s\n", err.Error())
+			os.Exit(1)
+		}
+
+		// the session acts like a source file in the working directory so imports are relative to it
+		replPath := filepath.Join(wd, "<repl>")
+		ctx, err := parser.NewParseContextFromSource(replPath, "")
+		if err != nil {
+			fmt.Printf("Could not create parse context: %s\n", err.Error())
+			os.Exit(1)
+		}
+		ctx.Interactive = true
+
+		// a single scope is used for the whole session so declarations survive between inputs
+		scope := evaluator.NewScope(evaluator.Options{})
+
+		var src string
+		scanner := bufio.NewScanner(os.Stdin)
+		fmt.Print(replPrompt)
+		for scanner.Scan() {
+			src += scanner.Text() + "\n"
+
+			// keep reading lines until every bracket is closed
+			tkns, err := lexer.Analyze(src)
+			if err != nil {
+				fmt.Printf("lex error: %s\n", err.Error())
+				src = ""
+				fmt.Print(replPrompt)
+				continue
+			}
+			last := lastToken(tkns)
+			if last == nil {
+				src = ""
+				fmt.Print(replPrompt)
+				continue
+			}
+			if bracketDepth(tkns) > 0 {
+				fmt.Print(replContinuePrompt)
+				continue
+			}
+
+			// allow the trailing semicolon to be left off of a single expression
+			if last.Type != ";" && last.Type != "}" {
+				src += ";"
+			}
+
+			evaluateReplSource(ctx, scope, src)
+			src = ""
+			fmt.Print(replPrompt)
+		}
+		fmt.Println()
+	},
+}
+
+// evaluateReplSource parses and evaluates one complete input, printing the value of any bare expressions
+func evaluateReplSource(ctx *parser.ParseContext, scope *evaluator.Scope, src string) {
+	if err := ctx.SetSource(src); err != nil {
+		fmt.Printf("lex error: %s\n", err.Error())
+		return
+	}
+
+	// parse using context
+	tree := parser.Parse(ctx)
+	ctx.PopImportWithTree(tree)
+
+	// check for import cycles
+	if cycles := ctx.ImportGraph.FindCycles(); len(cycles) > 0 {
+		fmt.Println("import cycle found.")
+		for _, n := range cycles {
+			fmt.Println(n)
+		}
+		ctx.Reset()
+		return
+	}
+
+	// print any errors during parsing
+	if ctx.HasErrors() {
+		ctx.PrintErrors()
+		ctx.Reset()
+		return
+	}
+	ctx.PrintWarnings()
+
+	// evaluate each statement in the persistent scope
+	block, ok := tree.Statement.(*ast.BlockStatement)
+	if !ok {
+		return
+	}
+	for _, stmt := range block.Statements {
+		val, err := evaluator.EvaluateStatement(stmt, scope, tree, ctx.ImportGraph)
+		if err != nil {
+			printEvalError(ctx, err)
+			return
+		}
+		if _, isNil := val.(*value.Nil); val != nil && !isNil && isBareExpression(stmt) {
+			fmt.Println(val)
+		}
+	}
+}
+
+// isBareExpression returns true if the statement is an expression whose value should be printed
+func isBareExpression(stmt ast.Statement) bool {
+	expStmt, ok := stmt.(*ast.ExpressionStatement)
+	if !ok {
+		return false
+	}
+	switch expStmt.Expression.(type) {
+	case *ast.VariableDecleration, *ast.DestructuringDeclaration, *ast.AssignmentExpression, *ast.FunctionLiteral, *ast.TypeDeclaration:
+		return false
+	default:
+		return true
+	}
+}
+
+// bracketDepth returns the number of unclosed brackets, parenthesis, and braces
+func bracketDepth(tkns []*token.Token) int {
+	var depth int
+	for _, t := range tkns {
+		switch t.Type {
+		case "{", "(", "[":
+			depth += 1
+		case "}", ")", "]":
+			depth -= 1
+		}
+	}
+	return depth
+}
+
+// lastToken returns the last token which isn't a newline, or nil if there isn't one
+func lastToken(tkns []*token.Token) *token.Token {
+	for i := len(tkns) - 1; i >= 0; i-- {
+		if tkns[i].Type != "newline" {
+			return tkns[i]
+		}
+	}
+	return nil
+}
+
+func buildReplCommand() *cobra.Command {
+	return replCmd
+}
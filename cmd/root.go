@@ -1,15 +1,33 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"path/filepath"
+	"time"
 
+	"github.com/mcjcloud/taurine/pkg/ast"
+	"github.com/mcjcloud/taurine/pkg/compiler"
 	"github.com/mcjcloud/taurine/pkg/evaluator"
 	"github.com/mcjcloud/taurine/pkg/parser"
+	"github.com/mcjcloud/taurine/pkg/util"
+	"github.com/mcjcloud/taurine/pkg/vm"
 	"github.com/spf13/cobra"
 )
 
+// useVM is set by the --vm flag to run programs with the bytecode virtual machine instead of the evaluator
+var useVM bool
+
+// the limits set by flags, which are 0 when there's no limit
+var (
+	maxDepth  int           // --max-depth limits the number of calls that can be in progress at once
+	maxSteps  int           // --max-steps limits the number of steps the program can take
+	timeout   time.Duration // --timeout limits how long the program can run for
+	maxSize   int           // --max-size limits the number of elements in an arr or bytes in a str
+	maxOutput int           // --max-output limits the number of bytes etch and read prompts can write
+)
+
 var rootCmd = &cobra.Command{
 	Use:   "taurine <file.tc>",
 	Short: "taurine is a simple language, fueled by caffiene",
@@ -17,6 +35,9 @@ var rootCmd = &cobra.Command{
 		if len(args) == 0 {
 			return fmt.Errorf("missing source file")
 		}
+		if maxDepth > evaluator.MaxDepthLimit {
+			return fmt.Errorf("--max-depth can be at most %d", evaluator.MaxDepthLimit)
+		}
 		return nil
 	},
 	Run: func(cmd *cobra.Command, args []string) {
@@ -51,18 +72,90 @@ var rootCmd = &cobra.Command{
 			ctx.PrintErrors()
 			os.Exit(1)
 		}
+		ctx.PrintWarnings()
 
 		// evaluate
-		err = evaluator.Evaluate(tree, ctx.ImportGraph)
+		err = run(tree, ctx.ImportGraph)
 		if err != nil {
-			fmt.Printf("eval error: %s", err)
+			printEvalError(ctx, err)
+			os.Exit(1)
 		}
 	},
 }
 
+// run executes a program with the backend chosen by the --vm flag
+func run(tree *ast.Ast, importGraph *util.ImportGraph) error {
+	opts := evaluator.Options{MaxDepth: maxDepth, MaxSteps: maxSteps, MaxSize: maxSize, MaxOutput: maxOutput}
+	if timeout > 0 {
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+		opts.Context = ctx
+	}
+	if !useVM {
+		return evaluator.Evaluate(tree, importGraph, opts)
+	}
+	program, err := compiler.Compile(tree, importGraph)
+	if err != nil {
+		return err
+	}
+	return vm.Run(program, opts)
+}
+
+// printEvalError prints an error from evaluation
+// runtime errors are printed with the source code where they occurred and the calls that led to them
+func printEvalError(ctx *parser.ParseContext, err error) {
+	rtErr, ok := err.(*evaluator.RuntimeError)
+	if !ok {
+		fmt.Printf("eval error: %s\n", err)
+		return
+	}
+
+	// errors thrown by the program are reported differently from errors raised by the evaluator
+	if rtErr.Value != nil {
+		fmt.Printf("uncaught error in %s\n", rtErr.Ref.FilePath)
+	} else {
+		fmt.Printf("runtime error in %s\n", rtErr.Ref.FilePath)
+	}
+	fmt.Printf("%d:%d: %s\n", rtErr.Ref.Position.Row, rtErr.Ref.Position.Col, rtErr.Message)
+	ctx.PrintSourceLine(rtErr.Ref.FilePath, rtErr.Ref.Position)
+	for i := 0; i < len(rtErr.Stack); i++ {
+		frame := rtErr.Stack[i]
+		ref := frame.Ref
+		if frame.Import {
+			fmt.Printf("%s imported from %s:%d:%d\n", frame.Name, ref.FilePath, ref.Position.Row, ref.Position.Col)
+		} else {
+			fmt.Printf("%s called from %s:%d:%d\n", frame.Name, ref.FilePath, ref.Position.Row, ref.Position.Col)
+		}
+		ctx.PrintSourceLine(ref.FilePath, ref.Position)
+
+		// a recursive call repeats the same frame, which is only printed once
+		repeats := 0
+		for i+1 < len(rtErr.Stack) && sameFrame(rtErr.Stack[i+1], frame) {
+			i++
+			repeats++
+		}
+		if repeats > 0 {
+			fmt.Printf("(%d more calls of %s from the same place)\n", repeats, frame.Name)
+		}
+	}
+}
+
+// sameFrame returns true if a and b are calls of the same function from the same place
+func sameFrame(a, b *evaluator.CallFrame) bool {
+	return a.Name == b.Name && a.Import == b.Import && *a.Ref == *b.Ref
+}
+
 func Execute() {
 	rootCmd.AddCommand(buildAstCommand())
 	rootCmd.AddCommand(buildTokenCommand())
+	rootCmd.AddCommand(buildReplCommand())
+	rootCmd.AddCommand(buildCheckCommand())
+	rootCmd.Flags().BoolVar(&useVM, "vm", false, "compile the program to bytecode and run it on the virtual machine")
+	rootCmd.Flags().IntVar(&maxDepth, "max-depth", evaluator.DefaultMaxDepth, fmt.Sprintf("the number of function calls that can be in progress at once before a stack overflow error, up to %d", evaluator.MaxDepthLimit))
+	rootCmd.Flags().IntVar(&maxSteps, "max-steps", 0, "the number of steps the program can take before it's stopped, or 0 for no limit")
+	rootCmd.Flags().DurationVar(&timeout, "timeout", 0, "how long the program can run before it's stopped, e.g. 5s, or 0 for no limit")
+	rootCmd.Flags().IntVar(&maxSize, "max-size", 0, "the number of elements an arr, or bytes a str, can have before the program is stopped, or 0 for no limit")
+	rootCmd.Flags().IntVar(&maxOutput, "max-output", 0, "the number of bytes etch and read prompts can write before the program is stopped, or 0 for no limit")
 
 	if err := rootCmd.Execute(); err != nil {
 		fmt.Println(err)
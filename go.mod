@@ -3,7 +3,6 @@ module github.com/mcjcloud/taurine
 go 1.18
 
 require (
-	github.com/jinzhu/copier v0.3.5
 	github.com/kylelemons/godebug v1.1.0
 	github.com/spf13/cobra v1.5.0
 )
@@ -0,0 +1,62 @@
+package ast
+
+import "fmt"
+
+// BindArguments returns the indexes of the arguments of a call to name which are passed as each of params
+// arguments are passed in order until the first named argument, and a variadic parameter takes the rest of them
+// a parameter without an argument uses its default value, so it's an error if it doesn't have one
+func BindArguments(name string, params []*VariableDecleration, args []Expression) ([][]int, error) {
+	bound := make([][]int, len(params))
+	given := make([]bool, len(params))
+	next := 0
+	for i, arg := range args {
+		if named, ok := arg.(*NamedArgument); ok {
+			p := parameterIndex(params, named.Name)
+			switch {
+			case p < 0:
+				return nil, fmt.Errorf("unknown argument name '%s' in call to '%s'", named.Name, name)
+			case params[p].Variadic:
+				return nil, fmt.Errorf("cannot pass variadic parameter '%s' by name in call to '%s'", named.Name, name)
+			case given[p]:
+				return nil, fmt.Errorf("'%s' was passed more than once in call to '%s'", named.Name, name)
+			}
+			bound[p], given[p] = []int{i}, true
+			continue
+		}
+
+		if next == len(params) {
+			return nil, tooManyArguments(name, params, len(args))
+		}
+		bound[next], given[next] = append(bound[next], i), true
+		if !params[next].Variadic {
+			next++
+		}
+	}
+
+	for p, param := range params {
+		if !given[p] && param.Value == nil && !param.Variadic {
+			return nil, fmt.Errorf("missing argument for '%s' in call to '%s'", param.Symbol, name)
+		}
+	}
+	return bound, nil
+}
+
+// parameterIndex returns the index of the parameter with the name, or -1 if there isn't one
+func parameterIndex(params []*VariableDecleration, name string) int {
+	for i, param := range params {
+		if param.Symbol == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// tooManyArguments returns the error for a call to name with more arguments than params
+func tooManyArguments(name string, params []*VariableDecleration, count int) error {
+	for _, param := range params {
+		if param.Value != nil {
+			return fmt.Errorf("expected at most '%d' arguments but got '%d' for call to '%s'", len(params), count, name)
+		}
+	}
+	return fmt.Errorf("expected '%d' arguments but got '%d' for call to '%s'", len(params), count, name)
+}
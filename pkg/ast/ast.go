@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"math/big"
+	"strings"
 
 	"github.com/mcjcloud/taurine/pkg/token"
 )
@@ -25,12 +26,35 @@ type Expression interface {
 	Evaluate()
 }
 
+// SourceRef is the location in the source code that a node was parsed from
+type SourceRef struct {
+	FilePath string    `json:"-"` // the absolute path of the file containing the node
+	Position token.Pos `json:"-"` // the position of the token the node was parsed from
+}
+
+// Ref returns the location of the node
+func (r *SourceRef) Ref() *SourceRef {
+	return r
+}
+
+// Binding is the variable a node declares or refers to, which the resolver finds before the program runs
+// Depth is the number of scopes between the node and the scope the variable is in, and Slot is the variable's index in that scope
+// an identifier which doesn't refer to a declared variable has a Depth of -1
+type Binding struct {
+	Depth int `json:"-"`
+	Slot  int `json:"-"`
+}
+
+// Referable is a node which knows where it was parsed from
+type Referable interface {
+	Node
+	Ref() *SourceRef
+}
+
 // Ast represents the Abstract Syntax Tree for a file
 type Ast struct {
-	FilePath  string                `json:"file_path"` // the absolute path to the source code; will be used for referencing
-	Exports   map[string]Expression `json:"exports"`   // used during execution to map variable names to resolved values
-	Statement Statement             `json:"statement"` // the parsed AST root
-	Evaluated bool                  `json:"evaluated"` // true if the AST as already been evaluated (used during execution)
+	FilePath  string    `json:"file_path"` // the absolute path to the source code; will be used for referencing
+	Statement Statement `json:"statement"` // the parsed AST root
 }
 
 func (a *Ast) String() string {
@@ -43,6 +67,7 @@ func (a *Ast) String() string {
 
 // BlockStatement is a Statement which consists of multiple statements
 type BlockStatement struct {
+	SourceRef
 	Statements []Statement `json:"statements"`
 }
 
@@ -57,6 +82,7 @@ func (b *BlockStatement) String() string {
 
 // ExpressionStatement represents a statement which is just an expression
 type ExpressionStatement struct {
+	SourceRef
 	Expression Expression `json:"expression"`
 }
 
@@ -67,7 +93,9 @@ func (e *ExpressionStatement) String() string {
 
 // ReturnStatement represents a statement to return a value
 type ReturnStatement struct {
+	SourceRef
 	Value Expression `json:"value"`
+	Tail  bool       `json:"-"` // true if the value is a call whose frame can replace the frame of the function returning it
 }
 
 func (r *ReturnStatement) do() {}
@@ -75,8 +103,41 @@ func (r *ReturnStatement) String() string {
 	return fmt.Sprintf("return %s", r.Value)
 }
 
+// ThrowStatement represents a statement which throws an error
+type ThrowStatement struct {
+	SourceRef
+	Value Expression `json:"value"`
+}
+
+func (t *ThrowStatement) do() {}
+func (t *ThrowStatement) String() string {
+	return fmt.Sprintf("throw %s", t.Value)
+}
+
+// TryStatement represents a try block followed by a catch block, a finally block, or both
+type TryStatement struct {
+	SourceRef
+	Statement      Statement            `json:"statement"`
+	CatchParameter *VariableDecleration `json:"catch_parameter"` // the variable the caught error is stored in
+	Catch          Statement            `json:"catch"`
+	Finally        Statement            `json:"finally"`
+}
+
+func (t *TryStatement) do() {}
+func (t *TryStatement) String() string {
+	str := fmt.Sprintf("try %s", t.Statement)
+	if t.Catch != nil {
+		str += fmt.Sprintf(" catch (%s %s) %s", t.CatchParameter.SymbolType, t.CatchParameter.Symbol, t.Catch)
+	}
+	if t.Finally != nil {
+		str += fmt.Sprintf(" finally %s", t.Finally)
+	}
+	return str
+}
+
 // EtchStatement represents an etch call
 type EtchStatement struct {
+	SourceRef
 	Expressions []Expression `json:"expressions"`
 }
 
@@ -91,6 +152,7 @@ func (e *EtchStatement) String() string {
 
 // ReadStatement represents a statement to read from stdin
 type ReadStatement struct {
+	SourceRef
 	Identifier *Identifier    `json:"expressions"`
 	Prompt     *StringLiteral `json:"prompt"`
 }
@@ -102,6 +164,7 @@ func (r *ReadStatement) String() string {
 
 // IfStatement represents an if statement
 type IfStatement struct {
+	SourceRef
 	Condition Expression `json:"condition"`
 	Statement Statement  `json:"statement"`
 	ElseIf    Statement  `json:"else_if"` // this may just be a statement in the case of else or another IfStatement in case of else if
@@ -114,7 +177,9 @@ func (i *IfStatement) String() string {
 
 // ForLoopStatement represents for loop
 type ForLoopStatement struct {
+	SourceRef
 	Control   *Identifier `json:"control"`
+	Pattern   Pattern     `json:"pattern,omitempty"` // if each element is destructured e.g. for [k, v] in pairs, this is used instead of Control
 	Iterator  Expression  `json:"iterator"`
 	Step      int         `json:"step"`
 	Statement Statement   `json:"statement"`
@@ -122,11 +187,15 @@ type ForLoopStatement struct {
 
 func (f *ForLoopStatement) do() {}
 func (f *ForLoopStatement) String() string {
+	if f.Pattern != nil {
+		return fmt.Sprintf("for %s in %s %s", f.Pattern, f.Iterator, f.Statement)
+	}
 	return fmt.Sprintf("for %s in %s %s", f.Control, f.Iterator, f.Statement)
 }
 
 // WhileLoopStatement represents a while loop
 type WhileLoopStatement struct {
+	SourceRef
 	Condition Expression `json:"condition"`
 	Statement Statement  `json:"statement"`
 }
@@ -138,6 +207,7 @@ func (w *WhileLoopStatement) String() string {
 
 // ImportStatement represents an import statement
 type ImportStatement struct {
+	SourceRef
 	Source  string        `json:"source"`
 	Imports []*Identifier `json:"imports"`
 }
@@ -149,6 +219,7 @@ func (i *ImportStatement) String() string {
 
 // ExportStatement represents an export statement
 type ExportStatement struct {
+	SourceRef
 	Identifier *Identifier `json:"identifier"`
 	Value      Expression  `json:"value"`
 }
@@ -158,6 +229,26 @@ func (e *ExportStatement) String() string {
 	return fmt.Sprintf("export %s as %s", e.Value, e.Identifier)
 }
 
+// BreakStatement represents a statement which exits the nearest loop
+type BreakStatement struct {
+	SourceRef
+}
+
+func (b *BreakStatement) do() {}
+func (b *BreakStatement) String() string {
+	return "break"
+}
+
+// ContinueStatement represents a statement which skips to the next iteration of the nearest loop
+type ContinueStatement struct {
+	SourceRef
+}
+
+func (c *ContinueStatement) do() {}
+func (c *ContinueStatement) String() string {
+	return "continue"
+}
+
 // TODO: add for loops
 
 // Symbol is a type which represents the possible beginning symbols of a statement
@@ -180,6 +271,10 @@ const (
 	READ = "read"
 	// RETURN represents the return keyword
 	RETURN = "return"
+	// BREAK represents the break keyword
+	BREAK = "break"
+	// CONTINUE represents the continue keyword
+	CONTINUE = "continue"
 	// NUM represents a number type
 	NUM = "num"
 	// INT represents an integer type
@@ -206,6 +301,24 @@ const (
 	FROM = "from"
 	// IN represents in keyword
 	IN = "in"
+	// NIL represents the nil keyword, and the type of the nil value
+	NIL = "nil"
+	// ERR represents the error type
+	ERR = "err"
+	// THROW represents the throw keyword
+	THROW = "throw"
+	// TRY represents the try keyword
+	TRY = "try"
+	// CATCH represents the catch keyword
+	CATCH = "catch"
+	// FINALLY represents the finally keyword
+	FINALLY = "finally"
+	// TYPE represents the type keyword, and the type of a value declared with it
+	TYPE = "type"
+	// MATCH represents the match keyword
+	MATCH = "match"
+	// WILDCARD represents _, which matches any value without storing it
+	WILDCARD = "_"
 )
 
 // Operator represents an operator
@@ -250,26 +363,118 @@ const (
 	DOT = "."
 	// RANGE represents ..
 	RANGE = ".."
+	// AND represents &&
+	AND = "&&"
+	// OR represents ||
+	OR = "||"
+	// COALESCE represents ??
+	COALESCE = "??"
+	// NOT represents !
+	NOT = "!"
+	// ASSIGN represents =
+	ASSIGN = "="
 )
 
+// PRECEDENCE maps each binary operator to how tightly it binds its operands; higher binds tighter
 var PRECEDENCE = map[Operator]int{
-	PLUS:     1,
-	MINUS:    1,
-	MULTIPLY: 2,
-	DIVIDE:   2,
-	AT:       3,
-	RANGE:    4,
-	DOT:      5,
+	ASSIGN:         1,
+	PLUS_EQUAL:     1,
+	MINUS_EQUAL:    1,
+	MULTIPLY_EQUAL: 1,
+	DIVIDE_EQUAL:   1,
+	MODULO_EQUAL:   1,
+	OR:             2,
+	AND:            3,
+	EQUAL_EQUAL:    4,
+	NOT_EQUAL:      4,
+	LESS_THAN:      5,
+	LESS_EQUAL:     5,
+	GREATER_THAN:   5,
+	GREATER_EQUAL:  5,
+	COALESCE:       6,
+	RANGE:          7,
+	PLUS:           8,
+	MINUS:          8,
+	MULTIPLY:       9,
+	DIVIDE:         9,
+	MODULO:         9,
+	AT:             11,
+	DOT:            11,
+}
+
+// PREFIX_PRECEDENCE maps each unary operator to how tightly it binds its operand
+var PREFIX_PRECEDENCE = map[Operator]int{
+	NOT:   10,
+	MINUS: 10,
+}
+
+// CALL_PRECEDENCE is how tightly a call binds to the expression being called
+const CALL_PRECEDENCE = 11
+
+// Associativity describes how a chain of operators with the same precedence is grouped
+type Associativity int
+
+const (
+	// LeftAssociative operators group from the left, so a - b - c is (a - b) - c
+	LeftAssociative Associativity = iota
+	// RightAssociative operators group from the right, so a = b = c is a = (b = c)
+	RightAssociative
+)
+
+// ASSOCIATIVITY maps each binary operator to its associativity
+var ASSOCIATIVITY = map[Operator]Associativity{
+	ASSIGN:         RightAssociative,
+	PLUS_EQUAL:     RightAssociative,
+	MINUS_EQUAL:    RightAssociative,
+	MULTIPLY_EQUAL: RightAssociative,
+	DIVIDE_EQUAL:   RightAssociative,
+	MODULO_EQUAL:   RightAssociative,
+	OR:             LeftAssociative,
+	AND:            LeftAssociative,
+	EQUAL_EQUAL:    LeftAssociative,
+	NOT_EQUAL:      LeftAssociative,
+	LESS_THAN:      LeftAssociative,
+	LESS_EQUAL:     LeftAssociative,
+	GREATER_THAN:   LeftAssociative,
+	GREATER_EQUAL:  LeftAssociative,
+	COALESCE:       RightAssociative,
+	RANGE:          LeftAssociative,
+	PLUS:           LeftAssociative,
+	MINUS:          LeftAssociative,
+	MULTIPLY:       LeftAssociative,
+	DIVIDE:         LeftAssociative,
+	MODULO:         LeftAssociative,
+	AT:             LeftAssociative,
+	DOT:            LeftAssociative,
+}
+
+// COMPOUND_OPERATORS maps each compound assignment operator to the binary operator it applies before assigning
+var COMPOUND_OPERATORS = map[Operator]Operator{
+	PLUS_EQUAL:     PLUS,
+	MINUS_EQUAL:    MINUS,
+	MULTIPLY_EQUAL: MULTIPLY,
+	DIVIDE_EQUAL:   DIVIDE,
+	MODULO_EQUAL:   MODULO,
 }
 
 // IsStatementPrefix returns true if the symbol is a statement prefix
 func (str Symbol) IsStatementPrefix() bool {
-	return str == IF || str == FOR || str == WHILE || str == ETCH || str == READ || str == RETURN || str == IMPORT || str == EXPORT
+	return str == IF || str == FOR || str == WHILE || str == ETCH || str == READ || str == RETURN || str == BREAK || str == CONTINUE || str == IMPORT || str == EXPORT || str == THROW || str == TRY
 }
 
 // IsDataType returns true if the symbol represents a data type
 func (str Symbol) IsDataType() bool {
-	return str == NUM || str == INT || str == STR || str == BOOL || str == ARR || str == OBJ || str == FUNC || str == VOID
+	return str == NUM || str == INT || str == STR || str == BOOL || str == ARR || str == OBJ || str == FUNC || str == VOID || str == ERR
+}
+
+// IsOptional returns true if the symbol is an optional data type e.g. str?, which can also hold nil
+func (str Symbol) IsOptional() bool {
+	return strings.HasSuffix(string(str), "?")
+}
+
+// BaseType returns the data type without the '?' of an optional type
+func (str Symbol) BaseType() Symbol {
+	return Symbol(strings.TrimSuffix(string(str), "?"))
 }
 
 // ErrorNode represents an exoression that couldn't be parsed
@@ -279,12 +484,14 @@ type ErrorNode struct {
 
 func (e *ErrorNode) do()       {}
 func (e *ErrorNode) Evaluate() {}
+func (e *ErrorNode) pattern()  {}
 func (e *ErrorNode) String() string {
 	return fmt.Sprintf("error node: %s", e.Token.Value)
 }
 
 // NumberLiteral represents the num data type
 type NumberLiteral struct {
+	SourceRef
 	Value float64
 }
 
@@ -295,6 +502,7 @@ func (n *NumberLiteral) String() string {
 
 // IntegerLiteral represents the int data type
 type IntegerLiteral struct {
+	SourceRef
 	Value *big.Int
 }
 
@@ -305,16 +513,37 @@ func (i *IntegerLiteral) String() string {
 
 // StringLiteral represents the str data type
 type StringLiteral struct {
+	SourceRef
 	Value string
 }
 
 func (s *StringLiteral) Evaluate() {}
 func (s *StringLiteral) String() string {
-	return s.Value
+	return fmt.Sprintf("\"%s\"", token.Escape(s.Value))
+}
+
+// InterpolatedString represents a string with embedded expressions e.g. "hello \(name)"
+type InterpolatedString struct {
+	SourceRef
+	Parts []Expression `json:"parts"`
+}
+
+func (i *InterpolatedString) Evaluate() {}
+func (i *InterpolatedString) String() string {
+	str := "\""
+	for _, p := range i.Parts {
+		if s, ok := p.(*StringLiteral); ok {
+			str += token.Escape(s.Value)
+		} else {
+			str += fmt.Sprintf("\\(%s)", p)
+		}
+	}
+	return str + "\""
 }
 
 // BooleanLiteral represents a bool
 type BooleanLiteral struct {
+	SourceRef
 	Value bool
 }
 
@@ -323,8 +552,19 @@ func (b *BooleanLiteral) String() string {
 	return fmt.Sprintf("%v", b.Value)
 }
 
+// NilLiteral represents the nil value
+type NilLiteral struct {
+	SourceRef
+}
+
+func (n *NilLiteral) Evaluate() {}
+func (n *NilLiteral) String() string {
+	return NIL
+}
+
 // ObjectLiteral represents the obj data type
 type ObjectLiteral struct {
+	SourceRef
 	Value map[string]Expression
 }
 
@@ -335,20 +575,46 @@ func (o *ObjectLiteral) String() string {
 
 // FunctionLiteral represents a function
 type FunctionLiteral struct {
-	Symbol     string                 `json:"symbol"`
-	ReturnType string                 `json:"returnType"`
-	Parameters []*VariableDecleration `json:"parameters"`
-	Body       Statement              `json:"body"`
+	SourceRef
+	Binding
+	Symbol       string                 `json:"symbol"`
+	Receiver     string                 `json:"receiver,omitempty"` // if the function is a method e.g. func (num) Point.len(Point p), this is the type it belongs to
+	ReceiverType Binding                `json:"-"`                  // if the function is a method, the variable holding the type it belongs to
+	ReturnType   string                 `json:"returnType"`
+	Parameters   []*VariableDecleration `json:"parameters"`
+	Body         Statement              `json:"body"`
 }
 
 func (f *FunctionLiteral) Evaluate() {}
 func (f *FunctionLiteral) String() string {
+	if f.Receiver != "" {
+		return fmt.Sprintf("func (%s) %s.%s(%s) %s", f.ReturnType, f.Receiver, f.Symbol, f.Parameters, f.Body)
+	}
 	return fmt.Sprintf("func (%s) %s(%s) %s", f.ReturnType, f.Symbol, f.Parameters, f.Body)
 }
 
+// TypeDeclaration represents the declaration of a record type with named, typed fields
+// e.g. type Point { num x, num y }
+type TypeDeclaration struct {
+	SourceRef
+	Binding
+	Symbol string                 `json:"symbol"`
+	Fields []*VariableDecleration `json:"fields"`
+}
+
+func (t *TypeDeclaration) Evaluate() {}
+func (t *TypeDeclaration) String() string {
+	fields := make([]string, len(t.Fields))
+	for i, f := range t.Fields {
+		fields[i] = fmt.Sprintf("%s %s", f.SymbolType, f.Symbol)
+	}
+	return fmt.Sprintf("type %s { %s }", t.Symbol, strings.Join(fields, ", "))
+}
+
 // FunctionCall represents an expression which needs to call a function
 // The "Expression" will be whatever in AST, but Evaluate to a evaluator.ScopedFunction during runtime
 type FunctionCall struct {
+	SourceRef
 	Function  Expression   `json:"function"`
 	Arguments []Expression `json:"arguments"`
 }
@@ -358,11 +624,27 @@ func (f *FunctionCall) String() string {
 	return fmt.Sprintf("%s(%s)", f.Function, f.Arguments)
 }
 
+// NamedArgument is an argument passed to the parameter with its name e.g. the end in range(0, end: 10)
+type NamedArgument struct {
+	SourceRef
+	Name  string     `json:"name"`
+	Value Expression `json:"value"`
+}
+
+func (n *NamedArgument) Evaluate() {}
+func (n *NamedArgument) String() string {
+	return fmt.Sprintf("%s: %s", n.Name, n.Value)
+}
+
 // VariableDecleration represents a node that is a variable decleration
+// as a parameter of a function, its value is the default used when no argument is passed for it
 type VariableDecleration struct {
+	SourceRef
+	Binding
 	Symbol     string     `json:"symbol"`
 	SymbolType string     `json:"symbolType"`
 	Value      Expression `json:"value"`
+	Variadic   bool       `json:"variadic,omitempty"` // if the parameter takes the rest of the arguments as an arr of its type e.g. num nums...
 }
 
 func (v *VariableDecleration) Evaluate() {}
@@ -370,8 +652,23 @@ func (v *VariableDecleration) String() string {
 	return fmt.Sprintf("var (%s) %s = %s", v.SymbolType, v.Symbol, v.Value)
 }
 
+// DestructuringDeclaration declares the variables in a pattern from the elements of an array or the properties of an object
+// e.g. var [num a, num b, arr rest...] = exp or var {str name, num age} = exp
+type DestructuringDeclaration struct {
+	SourceRef
+	Pattern Pattern    `json:"pattern"`
+	Value   Expression `json:"value"`
+}
+
+func (d *DestructuringDeclaration) Evaluate() {}
+func (d *DestructuringDeclaration) String() string {
+	return fmt.Sprintf("var %s = %s", d.Pattern, d.Value)
+}
+
 // Identifier represents a variable or some kind of reference
 type Identifier struct {
+	SourceRef
+	Binding
 	Name string
 }
 
@@ -382,6 +679,7 @@ func (i *Identifier) String() string {
 
 // OperationExpression represents an expression consisting of an operation
 type OperationExpression struct {
+	SourceRef
 	Operator        Operator   `json:"operator"`
 	LeftExpression  Expression `json:"leftExpression"`
 	RightExpression Expression `json:"rightExpression"`
@@ -400,19 +698,35 @@ func (o *OperationExpression) String() string {
 	return fmt.Sprintf("%s(%s, %s)", o.Operator, l, r)
 }
 
-// AssignmentExpression represents an expression which assigns a new value to a variable
+// UnaryExpression represents an operator applied to a single expression e.g. !exp
+type UnaryExpression struct {
+	SourceRef
+	Operator   Operator   `json:"operator"`
+	Expression Expression `json:"expression"`
+}
+
+func (u *UnaryExpression) Evaluate() {}
+func (u *UnaryExpression) String() string {
+	return fmt.Sprintf("%s(%s)", u.Operator, u.Expression)
+}
+
+// AssignmentExpression represents an expression which assigns a new value to a variable, array element, or object property
+// e.g. x = exp, arr@i += exp, obj.prop = exp
 type AssignmentExpression struct {
-	Identifier *Identifier `json:"identifier"`
-	Value      Expression  `json:"value"`
+	SourceRef
+	Target   Expression `json:"target"`
+	Operator Operator   `json:"operator"`
+	Value    Expression `json:"value"`
 }
 
 func (a *AssignmentExpression) Evaluate() {}
 func (a *AssignmentExpression) String() string {
-	return fmt.Sprintf("%s = %s", a.Identifier, a.Value)
+	return fmt.Sprintf("%s %s %s", a.Target, a.Operator, a.Value)
 }
 
 // GroupExpression represents an expression inside of []
 type GroupExpression struct {
+	SourceRef
 	Expression Expression `json:"expression"`
 }
 
@@ -423,6 +737,7 @@ func (g *GroupExpression) String() string {
 
 // ArrayExpression represents an array of expressions e.g. [exp1, exp2]
 type ArrayExpression struct {
+	SourceRef
 	Expressions []Expression `json:"expressions"`
 }
 
@@ -439,3 +754,135 @@ func (a *ArrayExpression) String() string {
 	str += "]"
 	return str
 }
+
+// MatchExpression compares a value with the pattern of each arm in order, and evaluates the first arm that matches
+// e.g. match c { "0" => 0, 1..9 => 1, (str) s => 2, _ => 3 }
+type MatchExpression struct {
+	SourceRef
+	Value Expression  `json:"value"`
+	Arms  []*MatchArm `json:"arms"`
+}
+
+func (m *MatchExpression) Evaluate() {}
+func (m *MatchExpression) String() string {
+	arms := make([]string, len(m.Arms))
+	for i, arm := range m.Arms {
+		arms[i] = arm.String()
+	}
+	return fmt.Sprintf("match %s { %s }", m.Value, strings.Join(arms, ", "))
+}
+
+// MatchArm is a pattern, and either the expression or the block which is evaluated if the pattern matches
+type MatchArm struct {
+	SourceRef
+	Pattern Pattern    `json:"pattern"`
+	Value   Expression `json:"value"` // the value of the match if the arm is an expression
+	Body    Statement  `json:"body"`  // the block executed if the arm is a block
+}
+
+func (m *MatchArm) String() string {
+	if m.Body != nil {
+		return fmt.Sprintf("%s => %s", m.Pattern, m.Body)
+	}
+	return fmt.Sprintf("%s => %s", m.Pattern, m.Value)
+}
+
+// Pattern represents something a value is matched against, which may store parts of the value in variables
+type Pattern interface {
+	Node
+	pattern()
+}
+
+// LiteralPattern matches values equal to a literal e.g. "0"
+type LiteralPattern struct {
+	SourceRef
+	Value Expression `json:"value"`
+}
+
+func (l *LiteralPattern) pattern() {}
+func (l *LiteralPattern) String() string {
+	return l.Value.String()
+}
+
+// RangePattern matches the numbers in a range e.g. 1..9, which includes the start but not the end like the '..' operator
+type RangePattern struct {
+	SourceRef
+	Start Expression `json:"start"`
+	End   Expression `json:"end"`
+}
+
+func (r *RangePattern) pattern() {}
+func (r *RangePattern) String() string {
+	return fmt.Sprintf("%s..%s", r.Start, r.End)
+}
+
+// BindingPattern matches any value and stores it in a variable, unless the symbol is _
+type BindingPattern struct {
+	SourceRef
+	Binding
+	Symbol string `json:"symbol"`
+}
+
+func (b *BindingPattern) pattern() {}
+func (b *BindingPattern) String() string {
+	return b.Symbol
+}
+
+// TypePattern matches values of a data type and stores them in a variable of that type e.g. (str) s
+type TypePattern struct {
+	SourceRef
+	Binding
+	Symbol     string `json:"symbol"`
+	SymbolType string `json:"symbolType"`
+}
+
+func (t *TypePattern) pattern() {}
+func (t *TypePattern) String() string {
+	return fmt.Sprintf("(%s) %s", t.SymbolType, t.Symbol)
+}
+
+// ArrayPattern matches arrays with as many elements as it has patterns, where each element matches the pattern in its position
+// if it has a rest pattern e.g. [first, rest...], the array can have more elements, which are matched by it as an array
+type ArrayPattern struct {
+	SourceRef
+	Elements []Pattern `json:"elements"`
+	Rest     Pattern   `json:"rest,omitempty"`
+}
+
+func (a *ArrayPattern) pattern() {}
+func (a *ArrayPattern) String() string {
+	elements := make([]string, len(a.Elements))
+	for i, el := range a.Elements {
+		elements[i] = el.String()
+	}
+	if a.Rest != nil {
+		elements = append(elements, a.Rest.String()+"...")
+	}
+	return "[" + strings.Join(elements, ", ") + "]"
+}
+
+// ObjectPattern matches objects and records which have each of its properties, where each property matches its pattern
+type ObjectPattern struct {
+	SourceRef
+	Properties []*PropertyPattern `json:"properties"`
+}
+
+func (o *ObjectPattern) pattern() {}
+func (o *ObjectPattern) String() string {
+	props := make([]string, len(o.Properties))
+	for i, p := range o.Properties {
+		props[i] = p.String()
+	}
+	return "{" + strings.Join(props, ", ") + "}"
+}
+
+// PropertyPattern is a property of an ObjectPattern and the pattern its value must match
+type PropertyPattern struct {
+	SourceRef
+	Key     string  `json:"key"`
+	Pattern Pattern `json:"pattern"`
+}
+
+func (p *PropertyPattern) String() string {
+	return fmt.Sprintf("%s: %s", p.Key, p.Pattern)
+}
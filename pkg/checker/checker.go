@@ -0,0 +1,368 @@
+package checker
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+
+	"github.com/mcjcloud/taurine/pkg/ast"
+	"github.com/mcjcloud/taurine/pkg/token"
+	"github.com/mcjcloud/taurine/pkg/util"
+)
+
+// Checker infers the types of expressions in an import graph and reports errors without evaluating anything
+type Checker struct {
+	graph    *util.ImportGraph
+	handlers map[string]*util.ErrorHandler // the errors and warnings found in each file
+	exports  map[string]map[string]*value  // the exported values of each file which has been checked
+	path     string                        // the file being checked
+}
+
+// Check checks the entry file and every file in the import graph
+// the errors and warnings found are returned for each file, ordered by position
+func Check(g *util.ImportGraph, entry string) map[string]*util.ErrorHandler {
+	c := &Checker{
+		graph:    g,
+		handlers: make(map[string]*util.ErrorHandler),
+		exports:  make(map[string]map[string]*value),
+	}
+	c.checkFile(entry)
+
+	// files which aren't imported by the entry file are checked in a stable order
+	paths := make([]string, 0, len(g.Nodes))
+	for path := range g.Nodes {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		c.checkFile(path)
+	}
+
+	for _, handler := range c.handlers {
+		sortByPosition(handler.Errors)
+		sortByPosition(handler.Warnings)
+	}
+	return c.handlers
+}
+
+// sortByPosition sorts errors by where they were found in their file
+func sortByPosition(errors []util.ParseError) {
+	sort.SliceStable(errors, func(i, j int) bool {
+		a, b := errors[i].Token.Position, errors[j].Token.Position
+		return a.Row < b.Row || (a.Row == b.Row && a.Col < b.Col)
+	})
+}
+
+// checkFile checks the statements of a file, unless it has already been checked
+func (c *Checker) checkFile(path string) {
+	if _, ok := c.exports[path]; ok {
+		return
+	}
+	c.exports[path] = make(map[string]*value)
+	node, ok := c.graph.Nodes[path]
+	if !ok || node.Ast == nil {
+		return
+	}
+	block, ok := node.Ast.Statement.(*ast.BlockStatement)
+	if !ok {
+		return
+	}
+
+	prevPath := c.path
+	c.path = path
+	s := newScope(nil)
+	c.checkStatements(block.Statements, s)
+	c.flush(s)
+	c.path = prevPath
+}
+
+// errorf records an error at the position node was parsed from
+func (c *Checker) errorf(node ast.Node, format string, args ...interface{}) {
+	handler, tkn := c.handler(node)
+	handler.Add(tkn, fmt.Sprintf(format, args...))
+}
+
+// warnf records a warning at the position node was parsed from, for code which runs but probably isn't what was meant
+func (c *Checker) warnf(node ast.Node, format string, args ...interface{}) {
+	handler, tkn := c.handler(node)
+	handler.Warn(tkn, fmt.Sprintf(format, args...))
+}
+
+// handler returns the error handler of the file node was parsed from, and a token at its position
+func (c *Checker) handler(node ast.Node) (*util.ErrorHandler, *token.Token) {
+	path := c.path
+	tkn := &token.Token{}
+	if referable, ok := node.(ast.Referable); ok && referable.Ref().FilePath != "" {
+		path = referable.Ref().FilePath
+		tkn.Position = referable.Ref().Position
+	}
+	handler, ok := c.handlers[path]
+	if !ok {
+		handler = util.NewErrorHandler()
+		c.handlers[path] = handler
+	}
+	return handler, tkn
+}
+
+// flush checks the bodies of the functions declared in s
+// they are checked after the rest of the block so they can use variables declared after them
+func (c *Checker) flush(s *scope) {
+	for len(s.pending) > 0 {
+		p := s.pending[0]
+		s.pending = s.pending[1:]
+		c.checkFunctionBody(p.fn, p.scope)
+	}
+}
+
+func (c *Checker) checkFunctionBody(fn *ast.FunctionLiteral, parent *scope) {
+	s := newScope(parent)
+	s.fn = fn
+	for _, param := range fn.Parameters {
+		// a default value can use the parameters before it
+		dType := ast.Symbol(param.SymbolType)
+		if param.Value != nil {
+			if v := c.checkExpression(param.Value, s); !assignable(dType, v.dType) {
+				c.errorf(param.Value, "cannot assign %s to '%s' of type %s", v.dType, param.Symbol, dType)
+			}
+		}
+		if param.Variadic {
+			dType = ast.ARR
+		}
+		s.variables[param.Symbol] = &value{dType: dType}
+	}
+	c.checkStatement(fn.Body, s)
+	c.flush(s)
+
+	if fn.ReturnType != ast.VOID && !terminates(fn.Body) {
+		c.errorf(fn, "function of type %s may end without returning a value", fn.ReturnType)
+	}
+}
+
+// checkStatements checks a list of statements, reporting the first one which can never be reached
+func (c *Checker) checkStatements(stmts []ast.Statement, s *scope) {
+	var reachable = true
+	for _, stmt := range stmts {
+		if !reachable {
+			c.errorf(stmt, "unreachable code")
+			reachable = true
+		}
+		c.checkStatement(stmt, s)
+		if interrupts(stmt) {
+			reachable = false
+		}
+	}
+}
+
+func (c *Checker) checkStatement(stmt ast.Statement, s *scope) {
+	switch t := stmt.(type) {
+	case *ast.BlockStatement:
+		block := newScope(s)
+		c.checkStatements(t.Statements, block)
+		c.flush(block)
+	case *ast.ExpressionStatement:
+		c.checkExpression(t.Expression, s)
+	case *ast.EtchStatement:
+		for _, exp := range t.Expressions {
+			c.checkExpression(exp, s)
+		}
+	case *ast.ReadStatement:
+		c.checkReadStatement(t, s)
+	case *ast.IfStatement:
+		c.checkCondition("if", t.Condition, s)
+		c.checkStatement(t.Statement, s)
+		if t.ElseIf != nil {
+			c.checkStatement(t.ElseIf, s)
+		}
+	case *ast.ForLoopStatement:
+		c.checkForStatement(t, s)
+	case *ast.WhileLoopStatement:
+		c.checkCondition("while", t.Condition, s)
+		body := newScope(s)
+		c.checkStatement(t.Statement, body)
+		c.flush(body)
+	case *ast.ReturnStatement:
+		c.checkReturnStatement(t, s)
+	case *ast.ImportStatement:
+		c.checkImportStatement(t, s)
+	case *ast.ExportStatement:
+		c.checkExportStatement(t, s)
+	case *ast.ThrowStatement:
+		if v := c.checkExpression(t.Value, s); !oneOf(v.dType, ast.STR, ast.ERR) {
+			c.errorf(t, "cannot throw %s", v.dType)
+		}
+	case *ast.TryStatement:
+		c.checkStatement(t.Statement, s)
+		if t.Catch != nil {
+			catch := newScope(s)
+			catch.variables[t.CatchParameter.Symbol] = &value{dType: ast.ERR}
+			c.checkStatement(t.Catch, catch)
+			c.flush(catch)
+		}
+		if t.Finally != nil {
+			c.checkStatement(t.Finally, s)
+		}
+	}
+}
+
+func (c *Checker) checkReadStatement(stmt *ast.ReadStatement, s *scope) {
+	// read declares the variable if it doesn't exist yet
+	v := s.get(stmt.Identifier.Name)
+	if v == nil {
+		// nothing is read at the end of the input, so the variable may be nil
+		s.variables[stmt.Identifier.Name] = &value{dType: ast.STR + "?"}
+	} else if !assignable(v.dType, ast.STR) {
+		c.errorf(stmt.Identifier, "cannot read str into '%s' of type %s", stmt.Identifier.Name, v.dType)
+	}
+}
+
+// checkCondition checks that the condition of an if or while statement is a boolean
+func (c *Checker) checkCondition(keyword string, cond ast.Expression, s *scope) {
+	if v := c.checkExpression(cond, s); !oneOf(v.dType, ast.BOOL) {
+		c.errorf(cond, "%s expression must be of type bool but found %s", keyword, v.dType)
+	}
+}
+
+func (c *Checker) checkForStatement(stmt *ast.ForLoopStatement, s *scope) {
+	iter := c.checkExpression(stmt.Iterator, s)
+
+	// the control variable is a character when iterating over a string, or an integer when iterating over a range
+	control := &value{dType: unknown}
+	switch iter.dType.BaseType() {
+	case ast.STR:
+		control.dType = ast.STR
+	case ast.ARR:
+		if op, ok := stmt.Iterator.(*ast.OperationExpression); ok && op.Operator == ast.RANGE {
+			control.dType = ast.INT
+		}
+	case unknown:
+	default:
+		c.errorf(stmt.Iterator, "expected array or string iterator but found %s", iter.dType)
+	}
+
+	body := newScope(s)
+	if stmt.Pattern != nil {
+		c.checkDestructuring(stmt.Pattern, control.dType, body)
+	} else {
+		body.variables[stmt.Control.Name] = control
+	}
+	c.checkStatement(stmt.Statement, body)
+	c.flush(body)
+}
+
+func (c *Checker) checkReturnStatement(stmt *ast.ReturnStatement, s *scope) {
+	v := &value{dType: ast.VOID}
+	if stmt.Value != nil {
+		v = c.checkExpression(stmt.Value, s)
+	}
+	fn := s.function()
+	if fn == nil || fn.ReturnType == ast.VOID {
+		return
+	}
+	if !assignable(ast.Symbol(fn.ReturnType), v.dType) {
+		c.errorf(stmt, "cannot return %s from function of type %s", v.dType, fn.ReturnType)
+	}
+}
+
+func (c *Checker) checkImportStatement(stmt *ast.ImportStatement, s *scope) {
+	path := util.ResolveImport(filepath.Dir(c.path), stmt.Source)
+	node, ok := c.graph.Nodes[path]
+	if !ok || node.Ast == nil {
+		c.errorf(stmt, "could not find imported file %s", stmt.Source)
+		for _, id := range stmt.Imports {
+			s.variables[id.Name] = &value{dType: unknown}
+		}
+		return
+	}
+
+	c.checkFile(path)
+	exports := c.exports[path]
+	for _, id := range stmt.Imports {
+		v, ok := exports[id.Name]
+		if !ok {
+			c.errorf(id, "symbol '%s' is not exported from %s", id.Name, stmt.Source)
+			v = &value{dType: unknown}
+		}
+		s.variables[id.Name] = v
+	}
+}
+
+func (c *Checker) checkExportStatement(stmt *ast.ExportStatement, s *scope) {
+	v := c.checkExpression(stmt.Value, s)
+	c.exports[c.path][stmt.Identifier.Name] = v
+}
+
+// interrupts returns true if the statements following stmt in the same block can never be reached
+func interrupts(stmt ast.Statement) bool {
+	switch stmt.(type) {
+	case *ast.BreakStatement, *ast.ContinueStatement:
+		return true
+	default:
+		return terminates(stmt)
+	}
+}
+
+// terminates returns true if stmt always returns from, or throws out of, the function it is in
+func terminates(stmt ast.Statement) bool {
+	switch t := stmt.(type) {
+	case *ast.ReturnStatement, *ast.ThrowStatement:
+		return true
+	case *ast.BlockStatement:
+		for _, s := range t.Statements {
+			if terminates(s) {
+				return true
+			}
+		}
+	case *ast.IfStatement:
+		return t.ElseIf != nil && terminates(t.Statement) && terminates(t.ElseIf)
+	case *ast.WhileLoopStatement:
+		// a loop that never ends can only be left by returning
+		cond, ok := t.Condition.(*ast.BooleanLiteral)
+		return ok && cond.Value && !breaks(t.Statement)
+	case *ast.ExpressionStatement:
+		// a match terminates if one of its arms always matches, and each arm is a block which terminates
+		match, ok := t.Expression.(*ast.MatchExpression)
+		if !ok || !exhaustive(match, unknown) {
+			return false
+		}
+		for _, arm := range match.Arms {
+			if arm.Body == nil || !terminates(arm.Body) {
+				return false
+			}
+		}
+		return true
+	case *ast.TryStatement:
+		// an error thrown by the try block is caught, so the catch block has to terminate as well
+		if t.Finally != nil && terminates(t.Finally) {
+			return true
+		}
+		return terminates(t.Statement) && (t.Catch == nil || terminates(t.Catch))
+	}
+	return false
+}
+
+// breaks returns true if stmt contains a break statement for the loop it is in
+func breaks(stmt ast.Statement) bool {
+	switch t := stmt.(type) {
+	case *ast.BreakStatement:
+		return true
+	case *ast.BlockStatement:
+		for _, s := range t.Statements {
+			if breaks(s) {
+				return true
+			}
+		}
+	case *ast.IfStatement:
+		return breaks(t.Statement) || (t.ElseIf != nil && breaks(t.ElseIf))
+	case *ast.TryStatement:
+		return breaks(t.Statement) || (t.Catch != nil && breaks(t.Catch)) || (t.Finally != nil && breaks(t.Finally))
+	case *ast.ExpressionStatement:
+		if match, ok := t.Expression.(*ast.MatchExpression); ok {
+			for _, arm := range match.Arms {
+				if arm.Body != nil && breaks(arm.Body) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
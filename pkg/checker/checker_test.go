@@ -0,0 +1,167 @@
+package checker
+
+import (
+	"testing"
+
+	"github.com/mcjcloud/taurine/pkg/parser"
+)
+
+// check parses src and returns the messages of the errors found by the checker
+func check(t *testing.T, src string) []string {
+	t.Helper()
+	errors, _ := checkWithWarnings(t, src)
+	return errors
+}
+
+// checkWithWarnings parses src and returns the messages of the errors and warnings found by the checker
+func checkWithWarnings(t *testing.T, src string) ([]string, []string) {
+	t.Helper()
+	ctx, err := parser.NewParseContextFromSource("/src/main.tc", src)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	tree := parser.Parse(ctx)
+	ctx.PopImportWithTree(tree)
+	if ctx.HasErrors() {
+		t.Fatal("unexpected parse errors")
+	}
+
+	var errors, warnings []string
+	for _, handler := range Check(ctx.ImportGraph, "/src/main.tc") {
+		for _, e := range handler.Errors {
+			errors = append(errors, e.Message)
+		}
+		for _, w := range handler.Warnings {
+			warnings = append(warnings, w.Message)
+		}
+	}
+	return errors, warnings
+}
+
+func TestCheckErrors(t *testing.T) {
+	tests := []struct {
+		src string
+		msg string
+	}{
+		{`var (num) x = "a" * 2;`, "'*' cannot be applied to str and int"},
+		{"var (int) x = 1;\nx = 1.5;", "cannot assign num to 'x' of type int"},
+		{"var (int) x = 1;\nx += 0.5;", "cannot assign num to 'x' of type int"},
+		{"func (int) f(int a) { return a; }\nf(\"a\");", "cannot pass str as 'a' of type int"},
+		{"func (int) f(int a) { return a; }\nf(1, 2);", "expected '1' arguments but got '2' for call to 'f'"},
+		{"func (str) f() { return 1; }", "cannot return int from function of type str"},
+		{"func (int) f(bool b) { if b { return 1; } }", "function of type int may end without returning a value"},
+		{"func (void) f() {\n  return;\n  etch 1;\n}", "unreachable code"},
+		{"if 1 { etch 1; }", "if expression must be of type bool but found int"},
+		{"for c in true { etch c; }", "expected array or string iterator but found bool"},
+		{"etch !\"a\";", "'!' cannot be applied to str"},
+		{"etch len(1);", "len can only be called on type str or arr but found int"},
+		{"var (int) x = 1;\nx();", "cannot call int as a function"},
+		{"var (str) s = \"abc\";\ns@0 = \"x\";", "cannot assign to an element of str"},
+		{"var (arr) a = [1];\na@\"0\" = 2;", "index must be of type int but found str"},
+		{"var (int) n = 1;\nn.x += 2;", "cannot assign to a property of int"},
+		{"etch [1] == \"a\";", "'==' cannot be applied to arr and str"},
+		{"var (str) s = \"a\";\ns = nil;", "cannot assign nil to 's' of type str"},
+		{"func (int) f(int? a) { return a ?? 0; }\nf(nil);\nvar (str?) s = f(1);", "cannot assign int to 's' of type str?"},
+		{"var (int?) n;\netch n ?? \"none\";", "'??' cannot be applied to int? and str"},
+		{"throw 1;", "cannot throw int"},
+		{"try {\n  throw \"a\";\n  etch 1;\n} catch (err e) {}", "unreachable code"},
+		{"func (int) f() {\n  try {\n    return 1;\n  } catch (err e) {\n    etch e;\n  }\n}", "function of type int may end without returning a value"},
+		{"try {} catch (err e) {\n  var (str) s = e;\n}", "cannot assign err to 's' of type str"},
+		{"type Point { num x, num y }\nvar (Point) p = Point(1, \"a\");", "cannot pass str as field 'y' of type num"},
+		{"type Point { num x, num y }\nvar (Point) p = Point(1);", "missing argument for 'y' in call to 'Point'"},
+		{"type Point { num x, num y }\nvar (Point) p = Point(1, 2);\np.x = true;", "cannot assign bool to field 'x' of type num"},
+		{"type Point { num x, num y }\nvar (Point) p = Point(1, 2);\netch p.z;", "Point has no field 'z'"},
+		{"type Point { num x, num y }\nvar (str) s = Point(1, 2).x;", "cannot assign num to 's' of type str"},
+		{"type Point { num x, num y }\nfunc (num) Point.sum(Point p) { return p.x + p.y; }\nvar (Point) p = Point(1, 2);\nvar (int) n = p.sum();", "cannot assign num to 'n' of type int"},
+		{"type Point { num x, num y }\nfunc (num) Point.scale(Point p, num k) { return p.x * k; }\nPoint(1, 2).scale();", "missing argument for 'k' in call to 'scale'"},
+		{"type Point { num x, num y }\nPoint(1, 2).norm();", "Point has no method 'norm'"},
+		{"type Point { num x, num y }\nfunc (num) Point.x(Point p) { return 1; }", "Point already has a field named 'x'"},
+		{"var (str) s = \"a\";\netch match s { 1 => 1, _ => 0 };", "pattern of type int can never match str"},
+		{"var (str) s = \"a\";\netch match s { 0..9 => 1, _ => 0 };", "range pattern can never match str"},
+		{"var (int) n = 1;\netch match n { (str) s => s, _ => \"\" };", "pattern of type str can never match int"},
+		{"var (int) n = 1;\nvar (str) m = match n { 1 => 10, 2 => 20 };", "cannot assign int? to 'm' of type str"},
+		{"var (int) n = 1;\nvar (str) s = match n { (int) i => i, _ => 0 };", "cannot assign int to 's' of type str"},
+		{"type Point { num x, num y }\nmatch Point(1, 2) { {x, z} => { etch x; } _ => {} }", "Point has no field 'z'"},
+		{"func (int) f(int n) {\n  match n {\n    0 => { return 0; }\n    1 => { return 1; }\n  }\n}", "function of type int may end without returning a value"},
+		{"func (int) f(int a, int b = 1) { return a + b; }\nf(b: 2);", "missing argument for 'a' in call to 'f'"},
+		{"func (int) f(int a) { return a; }\nf(1, b: 2);", "unknown argument name 'b' in call to 'f'"},
+		{"func (num) f(num nums...) { return 0; }\nf(1, \"a\");", "cannot pass str as 'nums' of type num"},
+		{"func (int) f(int a = \"a\") { return a; }", "cannot assign str to 'a' of type int"},
+		{"func (int) f(int a, int b = a) { return b; }\nvar (str) s = f(1);", "cannot assign int to 's' of type str"},
+		{"var (str) s = \"ab\";\nvar [str a, str b] = s;", "cannot destructure str as an array"},
+		{"var (arr) a = [];\nvar {num x} = a;", "cannot destructure arr as an object"},
+		{"type Point { num x, num y }\nvar {num x, num z} = Point(1, 2);", "Point has no field 'z'"},
+		{"type Point { num x, num y }\nvar {str x} = Point(1, 2);", "cannot assign num to 'x' of type str"},
+		{"for [i, j] in 0..3 {}", "cannot destructure int as an array"},
+	}
+	for _, test := range tests {
+		messages := check(t, test.src)
+		if len(messages) != 1 || messages[0] != test.msg {
+			t.Errorf("expected %q for %q but found %q", test.msg, test.src, messages)
+		}
+	}
+}
+
+func TestCheckWarnings(t *testing.T) {
+	tests := []struct {
+		src string
+		msg string
+	}{
+		// a match which doesn't match any arm is nil, so it still runs
+		{"var (bool) b = true;\nmatch b { true => { etch 1; } }", "match on bool is not exhaustive, missing false"},
+		{"var (bool) b = false;\netch match b { true => 1 };", "match on bool is not exhaustive, missing false"},
+	}
+	for _, test := range tests {
+		errors, warnings := checkWithWarnings(t, test.src)
+		if len(errors) != 0 {
+			t.Errorf("expected no errors for %q but found %q", test.src, errors)
+		}
+		if len(warnings) != 1 || warnings[0] != test.msg {
+			t.Errorf("expected %q for %q but found %q", test.msg, test.src, warnings)
+		}
+	}
+}
+
+func TestCheckValid(t *testing.T) {
+	tests := []string{
+		// functions can use variables declared after them, since they are called later
+		"func (int) f() { return limit; }\nvar (int) limit = 3;\netch f();",
+		// recursion and int to num conversion
+		"func (num) fact(int n) {\n  if n < 2 {\n    return 1;\n  }\n  return n * fact(n - 1);\n}\netch fact(5);",
+		// loops that can only be left by returning
+		"func (int) first(arr a) {\n  while true {\n    return a@0;\n  }\n}",
+		// values of unknown type are allowed
+		"var (obj) o = {a: 1};\nvar (int) x = o.a + 1;\netch x, o.a@0;",
+		"for i in 0..3 { etch i % 2; }\nfor c in \"abc\" { etch c + \"!\"; }",
+		"var (str) s;\nread s, \"> \";\nread t, \"> \";\netch s + t;",
+		// elements and properties can be assigned any value
+		"var (arr) a = [[1], 2];\na@0@0 += 1;\nvar (obj) o = {p: {x: 1}};\no.p.x = \"a\";\na@1 = o;",
+		// numbers of either type can be compared, and references are compared with their own type
+		"etch 1 == 1.0, 2 < 2.5;\nvar (arr) a = [];\netch a == a;",
+		// functions can end by throwing, and errors have a message
+		"func (int) f(int n) {\n  if n > 0 {\n    return n;\n  }\n  throw err(\"bad\");\n}\ntry {\n  f(0);\n} catch (err e) {\n  etch e.message;\n}",
+		"func (int) g() {\n  try {\n    return int(\"1\");\n  } catch (err e) {\n    return 0;\n  } finally {\n    etch 1;\n  }\n}",
+		// a match has the type of its arms, and can end a function if every arm returns
+		"var (bool) b = false;\nvar (str) s = match b { true => \"yes\", false => \"no\" };\nvar (num) n = match s { \"yes\" => 1, _ => 0.5 };",
+		"func (int) f(arr a) {\n  match a {\n    [(int) x, _] => { return x; }\n    [] => { return 0; }\n    _ => { throw \"bad\"; }\n  }\n}",
+		"while true {\n  match 1 { 1 => { break; } _ => {} }\n}\netch 1;",
+		// optional values can be nil, and are used as if they aren't
+		"var (int?) n;\nvar (int) m = n ?? 1;\nif n != nil { etch n + m; }\nn = nil;",
+		// records have typed fields, and methods which are called with the record as their first argument
+		"type Point { num x, num y }\nfunc (Point) Point.add(Point p, Point q) { return Point(p.x + q.x, p.y + q.y); }\nvar (Point) p = Point(1, 2).add(Point(3, 4));\np.x += 1;\nvar (num) y = p.y;",
+		"type Node { int value, Node? next }\nfunc (int) Node.sum(Node n) {\n  if n.next == nil {\n    return n.value;\n  }\n  return n.value + n.next.sum();\n}\nvar (int) s = Node(1, Node(2, nil)).sum();",
+		// parameters can have default values, take the rest of the arguments, and be passed by name
+		"func (arr) range(int start = 0, int end = start + 10, int step = 1) { return []; }\nrange(end: 5);\nrange(1, step: 2);",
+		"func (num) sum(num nums...) {\n  var (num) total = 0;\n  for n in nums { total += n; }\n  return total + len(nums);\n}\nsum();\nsum(1, 2.5);",
+		"type Point { num x, num y }\nvar (Point) p = Point(y: 1, x: 2);",
+		// destructured variables have the type they're declared with, or of the field they're taken from
+		"type Point { num x, num y }\nvar {num x, num y} = Point(1, 2);\nvar [num a, arr rest...] = [x, y, 3];\nvar (num) sum = a + rest@0;",
+		"for [k, {str name}] in [[1, {name: \"a\"}]] { etch k, name + \"!\"; }",
+		"func (str?) find(obj o) { return o.name; }\nvar (str) x = find({id: 1}) ?? \"none\";\nvar (int?) i;\nvar (num) y = i ?? 1.5;\nread line, \"> \";\nline = nil;",
+	}
+	for _, src := range tests {
+		if messages := check(t, src); len(messages) != 0 {
+			t.Errorf("expected no errors for %q but found %q", src, messages)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package checker
+
+import (
+	"github.com/mcjcloud/taurine/pkg/ast"
+)
+
+// checkDestructuringDeclaration checks that the value of decl can be destructured into its pattern
+func (c *Checker) checkDestructuringDeclaration(decl *ast.DestructuringDeclaration, s *scope) *value {
+	v := c.checkExpression(decl.Value, s)
+	c.checkDestructuring(decl.Pattern, v.dType, s)
+	return &value{dType: v.dType}
+}
+
+// checkDestructuring checks that a value of type dType has the shape of pattern, and declares the variables it stores in s
+func (c *Checker) checkDestructuring(pattern ast.Pattern, dType ast.Symbol, s *scope) {
+	switch p := pattern.(type) {
+	case *ast.BindingPattern:
+		if p.Symbol != ast.WILDCARD {
+			s.variables[p.Symbol] = &value{dType: dType}
+		}
+	case *ast.TypePattern:
+		if p.Symbol == ast.WILDCARD {
+			return
+		}
+		pType := ast.Symbol(p.SymbolType)
+		if !assignable(pType, dType) {
+			c.errorf(p, "cannot assign %s to '%s' of type %s", dType, p.Symbol, pType)
+		}
+		if _, ok := s.variables[p.Symbol]; ok {
+			c.errorf(p, "variable '%s' already exists", p.Symbol)
+		}
+		s.variables[p.Symbol] = &value{dType: pType}
+	case *ast.ArrayPattern:
+		if !oneOf(dType, ast.ARR) {
+			c.errorf(p, "cannot destructure %s as an array", dType)
+		}
+		// the types of elements aren't tracked
+		for _, el := range p.Elements {
+			c.checkDestructuring(el, unknown, s)
+		}
+		if p.Rest != nil {
+			c.checkDestructuring(p.Rest, ast.ARR, s)
+		}
+	case *ast.ObjectPattern:
+		rec := s.record(dType)
+		if rec == nil && !oneOf(dType, ast.OBJ) {
+			c.errorf(p, "cannot destructure %s as an object", dType)
+		}
+		for _, prop := range p.Properties {
+			propType := unknown
+			if rec != nil {
+				if field := rec.field(prop.Key); field == nil {
+					c.errorf(prop, "%s has no field '%s'", dType.BaseType(), prop.Key)
+				} else {
+					propType = ast.Symbol(field.SymbolType)
+				}
+			}
+			c.checkDestructuring(prop.Pattern, propType, s)
+		}
+	}
+}
@@ -0,0 +1,460 @@
+package checker
+
+import (
+	"fmt"
+
+	"github.com/mcjcloud/taurine/pkg/ast"
+)
+
+// checkExpression checks an expression and returns what is known about its value
+func (c *Checker) checkExpression(exp ast.Expression, s *scope) *value {
+	switch t := exp.(type) {
+	case *ast.NumberLiteral:
+		return &value{dType: ast.NUM}
+	case *ast.IntegerLiteral:
+		return &value{dType: ast.INT}
+	case *ast.StringLiteral:
+		return &value{dType: ast.STR}
+	case *ast.InterpolatedString:
+		for _, part := range t.Parts {
+			c.checkExpression(part, s)
+		}
+		return &value{dType: ast.STR}
+	case *ast.BooleanLiteral:
+		return &value{dType: ast.BOOL}
+	case *ast.NilLiteral:
+		return &value{dType: ast.NIL}
+	case *ast.ArrayExpression:
+		for _, el := range t.Expressions {
+			c.checkExpression(el, s)
+		}
+		return &value{dType: ast.ARR}
+	case *ast.ObjectLiteral:
+		for _, v := range t.Value {
+			c.checkExpression(v, s)
+		}
+		return &value{dType: ast.OBJ}
+	case *ast.GroupExpression:
+		return c.checkExpression(t.Expression, s)
+	case *ast.Identifier:
+		if v := s.get(t.Name); v != nil {
+			return v
+		}
+		c.errorf(t, "'%s' was not declared", t.Name)
+	case *ast.UnaryExpression:
+		return c.checkUnary(t, s)
+	case *ast.OperationExpression:
+		return c.checkOperation(t, s)
+	case *ast.VariableDecleration:
+		return c.checkVariableDecleration(t, s)
+	case *ast.DestructuringDeclaration:
+		return c.checkDestructuringDeclaration(t, s)
+	case *ast.AssignmentExpression:
+		return c.checkAssignment(t, s)
+	case *ast.FunctionLiteral:
+		return c.checkFunctionLiteral(t, s)
+	case *ast.TypeDeclaration:
+		return c.checkTypeDeclaration(t, s)
+	case *ast.MatchExpression:
+		return c.checkMatch(t, s)
+	case *ast.FunctionCall:
+		return c.checkFunctionCall(t, s)
+	case *ast.NamedArgument:
+		c.errorf(t, "cannot pass '%s' by name, since only functions and types have named parameters", t.Name)
+		return c.checkExpression(t.Value, s)
+	}
+	return &value{dType: unknown}
+}
+
+func (c *Checker) checkVariableDecleration(decl *ast.VariableDecleration, s *scope) *value {
+	dType := ast.Symbol(decl.SymbolType)
+	v := &value{dType: dType}
+	if decl.Value != nil {
+		init := c.checkExpression(decl.Value, s)
+		if !assignable(dType, init.dType) {
+			c.errorf(decl, "cannot assign %s to '%s' of type %s", init.dType, decl.Symbol, dType)
+		}
+		v.fn = init.fn
+	}
+	if _, ok := s.variables[decl.Symbol]; ok {
+		c.errorf(decl, "variable '%s' already exists", decl.Symbol)
+	}
+	s.variables[decl.Symbol] = v
+	return v
+}
+
+func (c *Checker) checkAssignment(asn *ast.AssignmentExpression, s *scope) *value {
+	var target *value
+	id, isId := asn.Target.(*ast.Identifier)
+	if isId {
+		if target = s.get(id.Name); target == nil {
+			c.errorf(id, "'%s' was not declared", id.Name)
+		}
+	} else if op, ok := asn.Target.(*ast.OperationExpression); ok {
+		target = c.checkLocation(op, s)
+	}
+	val := c.checkExpression(asn.Value, s)
+	if target == nil {
+		return val
+	}
+
+	// compound assignments store the result of applying their operator to the current value
+	if binary, ok := ast.COMPOUND_OPERATORS[asn.Operator]; ok {
+		result, ok := operationType(binary, target.dType, val.dType)
+		if !ok {
+			c.errorf(asn, "'%s' cannot be applied to %s and %s", asn.Operator, target.dType, val.dType)
+			return &value{dType: result}
+		}
+		val = &value{dType: result}
+	}
+	if isId {
+		c.assign(id, target, val)
+	} else if !assignable(target.dType, val.dType) {
+		// only the types of record fields are known
+		field := asn.Target.(*ast.OperationExpression).RightExpression
+		c.errorf(asn, "cannot assign %s to field '%s' of type %s", val.dType, field, target.dType)
+	}
+	return val
+}
+
+// checkLocation checks the array element or object property being assigned to, and returns what is known about its value
+func (c *Checker) checkLocation(op *ast.OperationExpression, s *scope) *value {
+	left := c.checkExpression(op.LeftExpression, s)
+	if op.Operator == ast.AT {
+		if index := c.checkExpression(op.RightExpression, s); !oneOf(index.dType, ast.INT) {
+			c.errorf(op.RightExpression, "index must be of type int but found %s", index.dType)
+		}
+		if !oneOf(left.dType, ast.ARR) {
+			c.errorf(op, "cannot assign to an element of %s", left.dType)
+		}
+	} else if rec := s.record(left.dType); rec != nil {
+		if field := c.checkField(op, left.dType, rec); field != nil {
+			return &value{dType: ast.Symbol(field.SymbolType)}
+		}
+	} else if !oneOf(left.dType, ast.OBJ) {
+		c.errorf(op, "cannot assign to a property of %s", left.dType)
+	}
+	// the types of elements and properties aren't tracked
+	return &value{dType: unknown}
+}
+
+// assign checks that val can be stored in the variable id, which holds v
+func (c *Checker) assign(id *ast.Identifier, v, val *value) {
+	if !assignable(v.dType, val.dType) {
+		c.errorf(id, "cannot assign %s to '%s' of type %s", val.dType, id.Name, v.dType)
+	}
+	// the variable may hold either function now, so calls to it can't be checked
+	if v.fn != val.fn {
+		v.fn = nil
+	}
+}
+
+func (c *Checker) checkFunctionLiteral(fn *ast.FunctionLiteral, s *scope) *value {
+	v := &value{dType: ast.FUNC, fn: fn}
+	if fn.Receiver != "" {
+		c.checkMethod(fn, s)
+	} else if fn.Symbol != "" {
+		s.variables[fn.Symbol] = v
+	}
+	s.pending = append(s.pending, pendingFunction{fn: fn, scope: s})
+	return v
+}
+
+// checkMethod adds a method to the type it is declared on
+func (c *Checker) checkMethod(fn *ast.FunctionLiteral, s *scope) {
+	rec := s.record(ast.Symbol(fn.Receiver))
+	if rec == nil {
+		c.errorf(fn, "'%s' is not a type", fn.Receiver)
+		return
+	}
+	if _, ok := rec.methods[fn.Symbol]; ok {
+		c.errorf(fn, "method '%s' is already declared on %s", fn.Symbol, fn.Receiver)
+	} else if rec.field(fn.Symbol) != nil {
+		c.errorf(fn, "%s already has a field named '%s'", fn.Receiver, fn.Symbol)
+	}
+	rec.methods[fn.Symbol] = fn
+}
+
+func (c *Checker) checkTypeDeclaration(decl *ast.TypeDeclaration, s *scope) *value {
+	v := &value{dType: ast.TYPE, record: &record{decl: decl, methods: make(map[string]*ast.FunctionLiteral)}}
+	if _, ok := s.variables[decl.Symbol]; ok {
+		c.errorf(decl, "type '%s' already exists", decl.Symbol)
+	}
+	s.variables[decl.Symbol] = v
+	return v
+}
+
+func (c *Checker) checkFunctionCall(call *ast.FunctionCall, s *scope) *value {
+	// built-in functions are called even if a variable has the same name
+	if id, ok := call.Function.(*ast.Identifier); ok && (id.Name == "len" || id.Name == "int" || id.Name == "err") {
+		args := make([]*value, len(call.Arguments))
+		for i, arg := range call.Arguments {
+			args[i] = c.checkExpression(arg, s)
+		}
+		return c.checkBuiltInCall(id, call, args)
+	}
+	args := c.checkArgumentValues(call, s)
+
+	callee := c.checkExpression(call.Function, s)
+	if callee.record != nil {
+		// calling a type creates a record of that type from the values of its fields
+		c.checkArguments(call, callee.record.decl.Fields, args, "field '%s'")
+		return &value{dType: ast.Symbol(callee.record.decl.Symbol)}
+	}
+	if !oneOf(callee.dType, ast.FUNC) {
+		c.errorf(call, "cannot call %s as a function", callee.dType)
+		return &value{dType: unknown}
+	}
+	fn := callee.fn
+	if fn == nil {
+		return &value{dType: unknown}
+	}
+
+	c.checkArguments(call, fn.Parameters, args, "'%s'")
+	return &value{dType: ast.Symbol(fn.ReturnType)}
+}
+
+// checkArgumentValues checks the arguments of call, including the values of those passed by name
+func (c *Checker) checkArgumentValues(call *ast.FunctionCall, s *scope) []*value {
+	args := make([]*value, len(call.Arguments))
+	for i, arg := range call.Arguments {
+		if named, ok := arg.(*ast.NamedArgument); ok {
+			arg = named.Value
+		}
+		args[i] = c.checkExpression(arg, s)
+	}
+	return args
+}
+
+// checkArguments checks that each argument of call can be passed as the parameter it's bound to
+// name formats the name of a parameter in errors
+func (c *Checker) checkArguments(call *ast.FunctionCall, params []*ast.VariableDecleration, args []*value, name string) {
+	bound, err := ast.BindArguments(call.Function.String(), params, call.Arguments)
+	if err != nil {
+		c.errorf(call, "%s", err)
+		return
+	}
+	for i, param := range params {
+		for _, index := range bound[i] {
+			if !assignable(ast.Symbol(param.SymbolType), args[index].dType) {
+				c.errorf(call.Arguments[index], "cannot pass %s as %s of type %s", args[index].dType, fmt.Sprintf(name, param.Symbol), param.SymbolType)
+			}
+		}
+	}
+}
+
+func (c *Checker) checkBuiltInCall(id *ast.Identifier, call *ast.FunctionCall, args []*value) *value {
+	result := &value{dType: ast.INT}
+	if id.Name == "err" {
+		result.dType = ast.ERR
+	}
+	if len(args) != 1 {
+		c.errorf(call, "%s takes only one argument", id.Name)
+		return result
+	}
+	arg := args[0].dType.BaseType()
+	if id.Name == "len" && arg != unknown && arg != ast.STR && arg != ast.ARR {
+		c.errorf(call.Arguments[0], "len can only be called on type str or arr but found %s", arg)
+	} else if id.Name == "int" && arg != unknown && arg != ast.NUM && arg != ast.STR {
+		c.errorf(call.Arguments[0], "int() can only be called on type num or str but found %s", arg)
+	} else if id.Name == "err" && arg != unknown && arg != ast.STR {
+		c.errorf(call.Arguments[0], "err() can only be called on type str but found %s", arg)
+	}
+	return result
+}
+
+func (c *Checker) checkUnary(op *ast.UnaryExpression, s *scope) *value {
+	operand := c.checkExpression(op.Expression, s)
+	if op.Operator == ast.MINUS {
+		if !oneOf(operand.dType, ast.NUM, ast.INT) {
+			c.errorf(op, "'%s' cannot be applied to %s", op.Operator, operand.dType)
+		}
+		return &value{dType: operand.dType}
+	}
+	if !oneOf(operand.dType, ast.BOOL) {
+		c.errorf(op, "'%s' cannot be applied to %s", op.Operator, operand.dType)
+	}
+	return &value{dType: ast.BOOL}
+}
+
+func (c *Checker) checkOperation(op *ast.OperationExpression, s *scope) *value {
+	// the right side of '.' is a property of the left side, so only function arguments are checked
+	if op.Operator == ast.DOT {
+		left := c.checkExpression(op.LeftExpression, s)
+		if rec := s.record(left.dType); rec != nil {
+			return c.checkMember(op, left.dType, rec, s)
+		}
+		if !oneOf(left.dType, ast.OBJ, ast.STR, ast.ARR, ast.ERR) {
+			c.errorf(op, "'.' cannot be applied to %s", left.dType)
+		}
+		if call, ok := op.RightExpression.(*ast.FunctionCall); ok {
+			for _, arg := range call.Arguments {
+				c.checkExpression(arg, s)
+			}
+		}
+		return &value{dType: unknown}
+	}
+
+	left := c.checkExpression(op.LeftExpression, s)
+	right := c.checkExpression(op.RightExpression, s)
+	result, ok := operationType(op.Operator, left.dType, right.dType)
+	if !ok {
+		c.errorf(op, "'%s' cannot be applied to %s and %s", op.Operator, left.dType, right.dType)
+	}
+	return &value{dType: result}
+}
+
+// checkMember checks the field or method call on the right side of '.' when the left side is a record
+func (c *Checker) checkMember(op *ast.OperationExpression, dType ast.Symbol, rec *record, s *scope) *value {
+	call, ok := op.RightExpression.(*ast.FunctionCall)
+	if !ok {
+		if field := c.checkField(op, dType, rec); field != nil {
+			return &value{dType: ast.Symbol(field.SymbolType)}
+		}
+		return &value{dType: unknown}
+	}
+
+	args := c.checkArgumentValues(call, s)
+	id, ok := call.Function.(*ast.Identifier)
+	if !ok {
+		return &value{dType: unknown}
+	}
+	// the record is passed as the first parameter of a method
+	if fn, ok := rec.methods[id.Name]; ok {
+		c.checkArguments(call, fn.Parameters[1:], args, "'%s'")
+		return &value{dType: ast.Symbol(fn.ReturnType)}
+	}
+	if field := rec.field(id.Name); field == nil {
+		c.errorf(op, "%s has no method '%s'", dType.BaseType(), id.Name)
+	} else if !oneOf(ast.Symbol(field.SymbolType), ast.FUNC) {
+		c.errorf(call, "cannot call %s as a function", field.SymbolType)
+	}
+	return &value{dType: unknown}
+}
+
+// checkField returns the field of a record on the right side of '.', or nil if the type doesn't have it
+func (c *Checker) checkField(op *ast.OperationExpression, dType ast.Symbol, rec *record) *ast.VariableDecleration {
+	id, ok := op.RightExpression.(*ast.Identifier)
+	if !ok {
+		return nil
+	}
+	field := rec.field(id.Name)
+	if field == nil {
+		c.errorf(op, "%s has no field '%s'", dType.BaseType(), id.Name)
+	}
+	return field
+}
+
+// operationType returns the type of the result of a binary operation, and false if the operation isn't allowed
+// the rules follow the evaluator, and an operand of unknown type is assumed to be allowed
+// an operand of optional type is assumed not to be nil, since that can only be known once it's evaluated
+func operationType(op ast.Operator, left, right ast.Symbol) (ast.Symbol, bool) {
+	if op == ast.COALESCE {
+		return coalesceType(left, right)
+	}
+	if op == ast.EQUAL_EQUAL || op == ast.NOT_EQUAL {
+		// anything can be compared with nil
+		if left == ast.NIL || right == ast.NIL {
+			return ast.BOOL, true
+		}
+	}
+	left, right = left.BaseType(), right.BaseType()
+	switch op {
+	case ast.PLUS:
+		// strings can be added to anything, and numbers can be added to strings
+		if left == ast.STR || (right == ast.STR && oneOf(left, ast.NUM, ast.INT)) {
+			return ast.STR, true
+		}
+		if left == unknown {
+			return unknown, true
+		}
+		if oneOf(left, ast.NUM, ast.INT) && oneOf(right, ast.NUM, ast.INT) {
+			return numericType(left, right), true
+		}
+	case ast.MINUS, ast.MULTIPLY, ast.DIVIDE:
+		if oneOf(left, ast.NUM, ast.INT) && oneOf(right, ast.NUM, ast.INT) {
+			return numericType(left, right), true
+		}
+	case ast.MODULO:
+		return ast.INT, oneOf(left, ast.INT) && oneOf(right, ast.INT)
+	case ast.EQUAL_EQUAL, ast.NOT_EQUAL:
+		// values of the same type can be compared, and so can any two numbers
+		switch left {
+		case ast.NUM, ast.INT:
+			return ast.BOOL, oneOf(right, ast.NUM, ast.INT)
+		case unknown:
+			return ast.BOOL, true
+		}
+		return ast.BOOL, oneOf(right, left)
+	case ast.LESS_THAN, ast.LESS_EQUAL, ast.GREATER_THAN, ast.GREATER_EQUAL:
+		return ast.BOOL, oneOf(left, ast.NUM, ast.INT) && oneOf(right, ast.NUM, ast.INT)
+	case ast.AND, ast.OR:
+		return ast.BOOL, oneOf(left, ast.BOOL) && oneOf(right, ast.BOOL)
+	case ast.AT:
+		if left == ast.STR {
+			return ast.STR, oneOf(right, ast.INT)
+		}
+		return unknown, oneOf(left, ast.ARR) && oneOf(right, ast.INT)
+	case ast.RANGE:
+		return ast.ARR, oneOf(left, ast.INT) && oneOf(right, ast.INT)
+	default:
+		return unknown, true
+	}
+	return unknown, false
+}
+
+// coalesceType returns the type of left ?? right, which is the type of left without its '?' if right can be stored in it
+func coalesceType(left, right ast.Symbol) (ast.Symbol, bool) {
+	if left == ast.NIL {
+		return right, true
+	}
+	if left == unknown {
+		return unknown, true
+	}
+	if right == ast.NIL {
+		return left, true
+	}
+	base := left.BaseType()
+	if assignable(base, right) {
+		return base, true
+	}
+	// e.g. an int? with a num default is a num
+	if assignable(right.BaseType(), base) {
+		return right, true
+	}
+	return unknown, false
+}
+
+// numericType returns the type of an arithmetic operation on two numbers, which is only int if both are
+func numericType(left, right ast.Symbol) ast.Symbol {
+	if left == ast.INT && right == ast.INT {
+		return ast.INT
+	} else if left == unknown || right == unknown {
+		return unknown
+	}
+	return ast.NUM
+}
+
+// oneOf returns true if t is unknown or one of the given types, ignoring whether it is optional
+func oneOf(t ast.Symbol, types ...ast.Symbol) bool {
+	t = t.BaseType()
+	if t == unknown {
+		return true
+	}
+	for _, typ := range types {
+		if t == typ {
+			return true
+		}
+	}
+	return false
+}
+
+// assignable returns true if a value of type from can be stored as type to, converting an int to a num
+// nil can only be stored as an optional type, but a value of optional type is assumed not to be nil
+func assignable(to, from ast.Symbol) bool {
+	if from == ast.NIL {
+		return to == unknown || to.IsOptional()
+	}
+	to, from = to.BaseType(), from.BaseType()
+	return to == unknown || from == unknown || to == from || (to == ast.NUM && from == ast.INT)
+}
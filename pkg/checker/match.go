@@ -0,0 +1,158 @@
+package checker
+
+import (
+	"github.com/mcjcloud/taurine/pkg/ast"
+)
+
+// checkMatch checks the patterns and arms of a match
+// its type is the type of its arms if each arm is an expression, which is optional unless some arm always matches
+func (c *Checker) checkMatch(match *ast.MatchExpression, s *scope) *value {
+	v := c.checkExpression(match.Value, s)
+	var result ast.Symbol
+	for i, arm := range match.Arms {
+		armScope := newScope(s)
+		c.checkPattern(arm.Pattern, v.dType, armScope)
+		if arm.Body != nil {
+			c.checkStatement(arm.Body, armScope)
+			result = unknown
+		} else if t := c.checkExpression(arm.Value, armScope).dType; i == 0 {
+			result = t
+		} else {
+			result = commonType(result, t)
+		}
+		c.flush(armScope)
+	}
+
+	exhaustive := exhaustive(match, v.dType)
+	if !exhaustive && v.dType == ast.BOOL {
+		c.warnf(match, "match on bool is not exhaustive, missing %s", missingBool(match))
+	}
+	if len(match.Arms) == 0 {
+		return &value{dType: ast.NIL}
+	}
+	if !exhaustive && result != unknown && result != ast.NIL && !result.IsOptional() {
+		result += "?"
+	}
+	return &value{dType: result}
+}
+
+// checkPattern checks that pattern could match a value of type dType, and declares the variables it stores in s
+func (c *Checker) checkPattern(pattern ast.Pattern, dType ast.Symbol, s *scope) {
+	switch p := pattern.(type) {
+	case *ast.LiteralPattern:
+		lit := c.checkExpression(p.Value, s)
+		if _, ok := operationType(ast.EQUAL_EQUAL, dType, lit.dType); !ok {
+			c.errorf(p, "pattern of type %s can never match %s", lit.dType, dType)
+		}
+	case *ast.RangePattern:
+		if !oneOf(dType, ast.NUM, ast.INT) {
+			c.errorf(p, "range pattern can never match %s", dType)
+		}
+	case *ast.BindingPattern:
+		if p.Symbol != ast.WILDCARD {
+			s.variables[p.Symbol] = &value{dType: dType}
+		}
+	case *ast.TypePattern:
+		pType := ast.Symbol(p.SymbolType)
+		if base := dType.BaseType(); base != unknown && base != pType.BaseType() && !(dType.IsOptional() && pType.IsOptional()) {
+			c.errorf(p, "pattern of type %s can never match %s", pType, dType)
+		}
+		if p.Symbol != ast.WILDCARD {
+			s.variables[p.Symbol] = &value{dType: pType}
+		}
+	case *ast.ArrayPattern:
+		if !oneOf(dType, ast.ARR) {
+			c.errorf(p, "array pattern can never match %s", dType)
+		}
+		// the types of elements aren't tracked
+		for _, el := range p.Elements {
+			c.checkPattern(el, unknown, s)
+		}
+		if p.Rest != nil {
+			c.checkPattern(p.Rest, ast.ARR, s)
+		}
+	case *ast.ObjectPattern:
+		rec := s.record(dType)
+		if rec == nil && !oneOf(dType, ast.OBJ) {
+			c.errorf(p, "object pattern can never match %s", dType)
+		}
+		for _, prop := range p.Properties {
+			propType := unknown
+			if rec != nil {
+				if field := rec.field(prop.Key); field == nil {
+					c.errorf(prop, "%s has no field '%s'", dType.BaseType(), prop.Key)
+				} else {
+					propType = ast.Symbol(field.SymbolType)
+				}
+			}
+			c.checkPattern(prop.Pattern, propType, s)
+		}
+	}
+}
+
+// commonType returns the type of a value which may be of type a or b
+func commonType(a, b ast.Symbol) ast.Symbol {
+	switch {
+	case a == b:
+		return a
+	case a == ast.NIL && b != unknown:
+		return b.BaseType() + "?"
+	case b == ast.NIL && a != unknown:
+		return a.BaseType() + "?"
+	case assignable(a, b) && a != unknown && b != unknown:
+		// e.g. num and int, or str and str?
+		return widerType(a, b)
+	case assignable(b, a) && a != unknown && b != unknown:
+		return widerType(b, a)
+	}
+	return unknown
+}
+
+// widerType returns to, made optional if from is optional, where a value of type from can be stored as type to
+func widerType(to, from ast.Symbol) ast.Symbol {
+	if from.IsOptional() && !to.IsOptional() {
+		return to + "?"
+	}
+	return to
+}
+
+// exhaustive returns true if a value of type dType is always matched by one of the arms of match
+// that's the case if an arm matches anything of that type, or if there are arms for both true and false
+func exhaustive(match *ast.MatchExpression, dType ast.Symbol) bool {
+	if missingBool(match) == "" {
+		return true
+	}
+	for _, arm := range match.Arms {
+		switch p := arm.Pattern.(type) {
+		case *ast.BindingPattern:
+			return true
+		case *ast.TypePattern:
+			pType := ast.Symbol(p.SymbolType)
+			if dType != unknown && pType.BaseType() == dType.BaseType() && (pType.IsOptional() || !dType.IsOptional()) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// missingBool returns the boolean literal which no arm of match matches, or "" if there are arms for both
+func missingBool(match *ast.MatchExpression) string {
+	var matchesTrue, matchesFalse bool
+	for _, arm := range match.Arms {
+		if lit, ok := arm.Pattern.(*ast.LiteralPattern); ok {
+			if b, ok := lit.Value.(*ast.BooleanLiteral); ok {
+				matchesTrue = matchesTrue || b.Value
+				matchesFalse = matchesFalse || !b.Value
+			}
+		}
+	}
+	if !matchesTrue && !matchesFalse {
+		return "true and false"
+	} else if !matchesTrue {
+		return "true"
+	} else if !matchesFalse {
+		return "false"
+	}
+	return ""
+}
@@ -0,0 +1,78 @@
+package checker
+
+import "github.com/mcjcloud/taurine/pkg/ast"
+
+// unknown is the type of an expression which can't be inferred without evaluating it
+const unknown ast.Symbol = "unknown"
+
+// value is what is known about the value of an expression without evaluating it
+type value struct {
+	dType  ast.Symbol
+	fn     *ast.FunctionLiteral // the function the value holds, if it is known
+	record *record              // the type the value holds, if it is a type declared with the type keyword
+}
+
+// record is what is known about a type declared with the type keyword
+type record struct {
+	decl    *ast.TypeDeclaration
+	methods map[string]*ast.FunctionLiteral
+}
+
+// field returns the declaration of the field named name, or nil if the type doesn't have one
+func (r *record) field(name string) *ast.VariableDecleration {
+	for _, f := range r.decl.Fields {
+		if f.Symbol == name {
+			return f
+		}
+	}
+	return nil
+}
+
+// pendingFunction is a function body which will be checked once the block it was declared in has been checked
+type pendingFunction struct {
+	fn    *ast.FunctionLiteral
+	scope *scope
+}
+
+// scope keeps track of the variables declared in a block
+type scope struct {
+	parent    *scope
+	variables map[string]*value
+	fn        *ast.FunctionLiteral // if the scope is the body of a function, this is the function
+	pending   []pendingFunction    // function bodies declared in the scope which haven't been checked yet
+}
+
+func newScope(parent *scope) *scope {
+	return &scope{
+		parent:    parent,
+		variables: make(map[string]*value),
+	}
+}
+
+// get returns the value of a variable, or nil if it hasn't been declared
+func (s *scope) get(name string) *value {
+	for sc := s; sc != nil; sc = sc.parent {
+		if v, ok := sc.variables[name]; ok {
+			return v
+		}
+	}
+	return nil
+}
+
+// record returns the type declared with the type keyword which dType refers to, or nil if it isn't one
+func (s *scope) record(dType ast.Symbol) *record {
+	if v := s.get(string(dType.BaseType())); v != nil {
+		return v.record
+	}
+	return nil
+}
+
+// function returns the function whose body the scope is in, or nil if the scope is outside of any function
+func (s *scope) function() *ast.FunctionLiteral {
+	for sc := s; sc != nil; sc = sc.parent {
+		if sc.fn != nil {
+			return sc.fn
+		}
+	}
+	return nil
+}
@@ -0,0 +1,315 @@
+package compiler
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/mcjcloud/taurine/pkg/ast"
+	"github.com/mcjcloud/taurine/pkg/util"
+)
+
+// compiler holds the state shared by every function of a program while it's compiled
+type compiler struct {
+	importGraph *util.ImportGraph
+	program     *Program
+}
+
+// funcState is the state of a function while its code is generated
+type funcState struct {
+	*compiler
+	fn      *Function
+	parent  *funcState // the function the function was declared in, or nil for the top level of a file
+	scopes  []*scope
+	loops   []*loop
+	refs    []*ast.SourceRef // the locations of the nodes being compiled, starting with the outermost
+	strings map[string]int
+}
+
+// scope is a block of code whose variables are only visible inside of it
+type scope struct {
+	index   int
+	names   map[string]int // the slot of each variable declared in the scope
+	pending []pending
+}
+
+// pending is a function whose body is compiled when the scope it was declared in ends,
+// so it can use every variable the scope declares, including the ones declared after it
+type pending struct {
+	fn      *Function
+	literal *ast.FunctionLiteral
+}
+
+// loop is a loop whose break and continue statements are waiting to be given the instruction they jump to
+type loop struct {
+	mark      int // the slot holding the size of the stack when the loop started
+	breaks    []int
+	continues []int
+}
+
+// variable is where a variable is stored
+type variable struct {
+	captured bool // true if the variable is in Captures rather than Slots
+	index    int
+}
+
+// Compile compiles the code of tree, and every file it imports, to a Program
+func Compile(tree *ast.Ast, importGraph *util.ImportGraph) (*Program, error) {
+	c := &compiler{importGraph: importGraph, program: &Program{Modules: make(map[string]*Function)}}
+	main, err := c.compileFile(tree)
+	if err != nil {
+		return nil, err
+	}
+	c.program.Main = main
+	return c.program, nil
+}
+
+// compileFile compiles the top level statements of tree as a function
+func (c *compiler) compileFile(tree *ast.Ast) (*Function, error) {
+	block, ok := tree.Statement.(*ast.BlockStatement)
+	if !ok {
+		return nil, errors.New("ast must contain block statement")
+	}
+	s := &funcState{
+		compiler: c,
+		fn:       &Function{Name: tree.FilePath, FilePath: tree.FilePath},
+		strings:  make(map[string]int),
+	}
+	s.enterScope()
+	for _, stmt := range block.Statements {
+		s.statement(stmt)
+	}
+	s.emit(OpEnd, 0, 0)
+	s.exitScope()
+	return s.fn, nil
+}
+
+// module returns the compiled top level of the file at absPath, or nil if it isn't in the import graph
+func (c *compiler) module(absPath string) *Function {
+	if fn, ok := c.program.Modules[absPath]; ok {
+		return fn
+	}
+	node, ok := c.importGraph.Nodes[absPath]
+	if !ok || node.Ast == nil {
+		return nil
+	}
+	fn, err := c.compileFile(node.Ast)
+	if err != nil {
+		return nil
+	}
+	c.program.Modules[absPath] = fn
+	return fn
+}
+
+// compileFunction compiles the body of a function literal in the scope of the function it was declared in
+// the parameters are the first slots, in order, and a parameter which has a default value starts by checking if it was passed
+func (s *funcState) compileFunction(fn *Function, lit *ast.FunctionLiteral) {
+	fs := &funcState{compiler: s.compiler, fn: fn, parent: s, strings: make(map[string]int)}
+	fs.refs = append(fs.refs, s.refs...)
+	defer fs.at(lit)()
+
+	params := fs.pushScope()
+	for _, param := range lit.Parameters {
+		dType := ast.Symbol(param.SymbolType)
+		if param.Variadic {
+			dType = ast.ARR
+		}
+		fs.fn.Slots = append(fs.fn.Slots, Slot{Name: param.Symbol, Type: dType})
+		fs.fn.Scopes[params.index] = append(fs.fn.Scopes[params.index], len(fs.fn.Slots)-1)
+	}
+	for i, param := range lit.Parameters {
+		if param.Value != nil {
+			skip := fs.emit(OpSkipIfBound, 0, i)
+			fs.expression(param.Value)
+			// a default value of the wrong type is reported at the call rather than in the function
+			fs.refs = append(fs.refs, nil)
+			fs.emit(OpDeclareLocal, i, 0)
+			fs.refs = fs.refs[:len(fs.refs)-1]
+			fs.emit(OpPop, 0, 0)
+			fs.patch(skip)
+		}
+		params.names[param.Symbol] = i
+	}
+
+	fs.statement(lit.Body)
+	fs.emit(OpEnd, 0, 0)
+	fs.exitScope()
+}
+
+// emit adds an instruction located at the node being compiled, returning its index
+func (s *funcState) emit(op Opcode, a, b int) int {
+	var ref *ast.SourceRef
+	if len(s.refs) > 0 {
+		ref = s.refs[len(s.refs)-1]
+	}
+	s.fn.Code = append(s.fn.Code, Instruction{Op: op, A: a, B: b})
+	s.fn.Refs = append(s.fn.Refs, ref)
+	return len(s.fn.Code) - 1
+}
+
+// patch makes the jump at index go to the next instruction to be emitted
+func (s *funcState) patch(index int) {
+	s.fn.Code[index].A = len(s.fn.Code)
+}
+
+// at makes node the location of the instructions emitted until the returned function is called
+// nodes which weren't parsed from source code keep the location of the node they're in
+func (s *funcState) at(node ast.Node) func() {
+	ref := (*ast.SourceRef)(nil)
+	if len(s.refs) > 0 {
+		ref = s.refs[len(s.refs)-1]
+	}
+	if referable, ok := node.(ast.Referable); ok && referable.Ref().FilePath != "" {
+		ref = referable.Ref()
+	}
+	s.refs = append(s.refs, ref)
+	return func() { s.refs = s.refs[:len(s.refs)-1] }
+}
+
+// str returns the index of a string in the function's table of strings
+func (s *funcState) str(value string) int {
+	if index, ok := s.strings[value]; ok {
+		return index
+	}
+	s.fn.Strings = append(s.fn.Strings, value)
+	s.strings[value] = len(s.fn.Strings) - 1
+	return len(s.fn.Strings) - 1
+}
+
+// fail emits an error which is raised when the instruction is reached
+func (s *funcState) fail(format string, a ...interface{}) {
+	s.emit(OpError, s.str(fmt.Sprintf(format, a...)), 0)
+}
+
+// pushScope starts a scope without emitting any code for it
+func (s *funcState) pushScope() *scope {
+	sc := &scope{index: len(s.fn.Scopes), names: make(map[string]int)}
+	s.fn.Scopes = append(s.fn.Scopes, []int{})
+	s.scopes = append(s.scopes, sc)
+	return sc
+}
+
+// enterScope starts a scope whose variables are cleared each time it's entered
+func (s *funcState) enterScope() *scope {
+	sc := s.pushScope()
+	s.emit(OpEnterScope, sc.index, 0)
+	return sc
+}
+
+// exitScope ends the current scope, compiling the functions declared in it
+func (s *funcState) exitScope() {
+	sc := s.scopes[len(s.scopes)-1]
+	for _, p := range sc.pending {
+		s.compileFunction(p.fn, p.literal)
+	}
+	s.scopes = s.scopes[:len(s.scopes)-1]
+}
+
+// current returns the innermost scope
+func (s *funcState) current() *scope {
+	return s.scopes[len(s.scopes)-1]
+}
+
+// declare returns the slot of a variable in the current scope, which is added if the scope doesn't have one yet
+func (s *funcState) declare(name string, dType ast.Symbol) int {
+	sc := s.current()
+	if slot, ok := sc.names[name]; ok {
+		s.fn.Slots[slot].Type = dType
+		return slot
+	}
+	slot := s.hidden()
+	s.fn.Slots[slot].Name = name
+	s.fn.Slots[slot].Type = dType
+	sc.names[name] = slot
+	return slot
+}
+
+// bind returns the slot of a variable in the current scope without changing its type, adding an untyped one if there isn't one
+func (s *funcState) bind(name string) int {
+	if slot, ok := s.current().names[name]; ok {
+		return slot
+	}
+	return s.declare(name, "")
+}
+
+// hidden adds a slot to the current scope which can't be referred to by name
+func (s *funcState) hidden() int {
+	sc := s.current()
+	s.fn.Slots = append(s.fn.Slots, Slot{})
+	slot := len(s.fn.Slots) - 1
+	s.fn.Scopes[sc.index] = append(s.fn.Scopes[sc.index], slot)
+	return slot
+}
+
+// declared returns true if the current scope has a variable named name
+func (s *funcState) declared(name string) bool {
+	_, ok := s.current().names[name]
+	return ok
+}
+
+// resolve finds the variable a name refers to, looking through the scopes of the function and then the functions it's in
+func (s *funcState) resolve(name string) (variable, bool) {
+	for i := len(s.scopes) - 1; i >= 0; i-- {
+		if slot, ok := s.scopes[i].names[name]; ok {
+			return variable{index: slot}, true
+		}
+	}
+	if s.parent == nil {
+		return variable{}, false
+	}
+	v, ok := s.parent.resolve(name)
+	if !ok {
+		return variable{}, false
+	}
+
+	// the enclosing function keeps the variable in a cell, so both functions see the same value
+	capture := Capture{Local: !v.captured, Index: v.index, Name: name}
+	if v.captured {
+		capture.Type = s.parent.fn.Captures[v.index].Type
+	} else {
+		s.parent.fn.Slots[v.index].Captured = true
+		capture.Type = s.parent.fn.Slots[v.index].Type
+	}
+	for i, c := range s.fn.Captures {
+		if c.Local == capture.Local && c.Index == capture.Index {
+			return variable{captured: true, index: i}, true
+		}
+	}
+	s.fn.Captures = append(s.fn.Captures, capture)
+	return variable{captured: true, index: len(s.fn.Captures) - 1}, true
+}
+
+// get pushes the value of a variable, which is an error if it wasn't declared
+func (s *funcState) get(name string) {
+	v, ok := s.resolve(name)
+	if !ok {
+		s.fail("'%s' was not declared", name)
+	} else if v.captured {
+		s.emit(OpGetCapture, v.index, 0)
+	} else {
+		s.emit(OpGetLocal, v.index, 0)
+	}
+}
+
+// load pushes the value of a variable without checking it, pushing undefined if there isn't a variable named name
+func (s *funcState) load(name string, undefined Opcode) {
+	v, ok := s.resolve(name)
+	if !ok {
+		s.emit(undefined, 0, 0)
+	} else if v.captured {
+		s.emit(OpLoadCapture, v.index, 0)
+	} else {
+		s.emit(OpLoadLocal, v.index, 0)
+	}
+}
+
+// store stores the top of the stack in the variable named name, adding one to the current scope if there isn't one
+func (s *funcState) store(name string) {
+	v, ok := s.resolve(name)
+	if !ok {
+		s.emit(OpStoreLocal, s.declare(name, ""), 0)
+	} else if v.captured {
+		s.emit(OpStoreCapture, v.index, 0)
+	} else {
+		s.emit(OpStoreLocal, v.index, 0)
+	}
+}
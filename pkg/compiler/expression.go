@@ -0,0 +1,295 @@
+package compiler
+
+import (
+	"sort"
+
+	"github.com/mcjcloud/taurine/pkg/ast"
+	"github.com/mcjcloud/taurine/pkg/value"
+)
+
+func (s *funcState) expression(exp ast.Expression) {
+	defer s.at(exp)()
+
+	switch t := exp.(type) {
+	case *ast.OperationExpression:
+		s.operation(t)
+	case *ast.UnaryExpression:
+		s.unary(t)
+	case *ast.Identifier:
+		s.get(t.Name)
+	case *ast.VariableDecleration:
+		s.variableDeclaration(t)
+	case *ast.DestructuringDeclaration:
+		s.expression(t.Value)
+		tmp := s.hidden()
+		s.emit(OpStoreLocal, tmp, 0)
+		s.destructure(t.Pattern, func() { s.emit(OpLoadLocal, tmp, 0) })
+	case *ast.AssignmentExpression:
+		s.assignment(t)
+	case *ast.FunctionCall:
+		s.call(t)
+	case *ast.GroupExpression:
+		s.expression(t.Expression)
+	case *ast.ArrayExpression:
+		for _, el := range t.Expressions {
+			s.expression(el)
+		}
+		s.emit(OpArray, len(t.Expressions), 0)
+	case *ast.FunctionLiteral:
+		s.functionLiteral(t)
+	case *ast.ObjectLiteral:
+		s.objectLiteral(t)
+	case *ast.TypeDeclaration:
+		if s.declared(t.Symbol) {
+			s.fail("type '%s' already exists", t.Symbol)
+			return
+		}
+		s.fn.Types = append(s.fn.Types, t)
+		s.emit(OpType, len(s.fn.Types)-1, 0)
+		s.emit(OpDeclareLocal, s.declare(t.Symbol, ast.TYPE), 0)
+	case *ast.MatchExpression:
+		s.match(t)
+	case *ast.InterpolatedString:
+		for _, part := range t.Parts {
+			s.stringify(part)
+		}
+		s.emit(OpInterpolate, len(t.Parts), 0)
+	case *ast.NamedArgument:
+		s.fail("cannot pass '%s' by name, since only functions and types have named parameters", t.Name)
+	case *ast.NumberLiteral:
+		s.constant(&value.Num{Value: t.Value})
+	case *ast.IntegerLiteral:
+		s.constant(&value.Int{Value: t.Value})
+	case *ast.StringLiteral:
+		s.constant(&value.Str{Value: t.Value})
+	case *ast.BooleanLiteral:
+		s.constant(&value.Bool{Value: t.Value})
+	case *ast.NilLiteral:
+		s.emit(OpNil, 0, 0)
+	default:
+		s.fail("cannot evaluate %s", exp)
+	}
+}
+
+// constant pushes a literal value, which is shared by every evaluation of the literal since it can't be changed
+func (s *funcState) constant(val value.Value) {
+	s.fn.Constants = append(s.fn.Constants, val)
+	s.emit(OpConstant, len(s.fn.Constants)-1, 0)
+}
+
+// stringify pushes the value of exp converted to a str, so it's printed as it was when it was evaluated even if a later expression changes it
+func (s *funcState) stringify(exp ast.Expression) {
+	s.expression(exp)
+	if _, ok := exp.(*ast.StringLiteral); !ok {
+		s.emit(OpStringify, 0, 0)
+	}
+}
+
+func (s *funcState) operation(op *ast.OperationExpression) {
+	// these operators don't always evaluate their right side
+	switch op.Operator {
+	case ast.DOT:
+		s.dot(op)
+		return
+	case ast.AND, ast.OR:
+		s.expression(op.LeftExpression)
+		jump := OpAnd
+		if op.Operator == ast.OR {
+			jump = OpOr
+		}
+		end := s.emit(jump, 0, 0)
+		s.expression(op.RightExpression)
+		s.emit(OpBool, s.str(string(op.Operator)), 0)
+		s.patch(end)
+		return
+	case ast.COALESCE:
+		s.expression(op.LeftExpression)
+		end := s.emit(OpCoalesce, 0, 0)
+		s.expression(op.RightExpression)
+		s.patch(end)
+		return
+	}
+
+	s.expression(op.LeftExpression)
+	s.expression(op.RightExpression)
+	s.emit(OpBinary, s.str(string(op.Operator)), 0)
+}
+
+// dot compiles a property access or a method call
+// a method call on an obj which doesn't have the method calls the variable of the same name instead, so it is pushed too
+func (s *funcState) dot(op *ast.OperationExpression) {
+	s.expression(op.LeftExpression)
+	switch right := op.RightExpression.(type) {
+	case *ast.Identifier:
+		s.emit(OpProperty, s.str(right.Name), 0)
+		return
+	case *ast.FunctionCall:
+		if id, ok := right.Function.(*ast.Identifier); ok {
+			s.load(id.Name, OpUndefined)
+			s.arguments(right)
+			s.fn.Calls = append(s.fn.Calls, right)
+			s.emit(OpInvoke, len(s.fn.Calls)-1, 0)
+			return
+		}
+	}
+	s.fail("right side of '.' must be identifier or function call")
+}
+
+func (s *funcState) unary(op *ast.UnaryExpression) {
+	s.expression(op.Expression)
+	switch op.Operator {
+	case ast.NOT:
+		s.emit(OpNot, 0, 0)
+	case ast.MINUS:
+		s.emit(OpNegate, 0, 0)
+	default:
+		s.fail("unrecognized unary operator '%s'", op.Operator)
+	}
+}
+
+func (s *funcState) variableDeclaration(decl *ast.VariableDecleration) {
+	// the value can't refer to the variable being declared
+	if decl.Value != nil {
+		s.expression(decl.Value)
+	}
+	if s.declared(decl.Symbol) {
+		s.fail("variable '%s' already exists", decl.Symbol)
+		return
+	}
+	slot := s.declare(decl.Symbol, ast.Symbol(decl.SymbolType))
+	if decl.Value != nil {
+		s.emit(OpDeclareLocal, slot, 0)
+	} else {
+		s.emit(OpDeclareLocal, slot, 1)
+	}
+}
+
+func (s *funcState) assignment(asn *ast.AssignmentExpression) {
+	binary, compound := ast.COMPOUND_OPERATORS[asn.Operator]
+
+	// a variable is assigned directly, and an element or property through a reference to it
+	if id, ok := asn.Target.(*ast.Identifier); ok {
+		v, ok := s.resolve(id.Name)
+		if !ok {
+			s.fail("'%s' was not declared", id.Name)
+			return
+		}
+		load, assign := OpLoadLocal, OpAssignLocal
+		if v.captured {
+			load, assign = OpLoadCapture, OpAssignCapture
+		}
+		if compound {
+			s.emit(load, v.index, 0)
+		}
+		s.expression(asn.Value)
+		if compound {
+			s.emit(OpBinary, s.str(string(binary)), 0)
+		}
+		s.emit(assign, v.index, 0)
+		return
+	}
+
+	op, ok := asn.Target.(*ast.OperationExpression)
+	if ok && op.Operator == ast.AT {
+		s.expression(op.LeftExpression)
+		s.expression(op.RightExpression)
+		s.emit(OpElementRef, 0, 0)
+	} else if ok && op.Operator == ast.DOT {
+		id, ok := op.RightExpression.(*ast.Identifier)
+		if !ok {
+			s.fail("expected property name on right side of '.'")
+			return
+		}
+		s.expression(op.LeftExpression)
+		s.emit(OpPropertyRef, s.str(id.Name), 0)
+	} else {
+		s.fail("expected left side of assignment to be an identifier, index, or property")
+		return
+	}
+	if compound {
+		s.emit(OpRefGet, 0, 0)
+	}
+	s.expression(asn.Value)
+	if compound {
+		s.emit(OpBinary, s.str(string(binary)), 0)
+	}
+	s.emit(OpRefSet, 0, 0)
+}
+
+// call compiles a function call, or a call to one of the built in functions
+func (s *funcState) call(call *ast.FunctionCall) {
+	if id, ok := call.Function.(*ast.Identifier); ok && (id.Name == "len" || id.Name == "int" || id.Name == "err") {
+		if len(call.Arguments) != 1 {
+			s.fail("%s takes only one argument", id.Name)
+			return
+		}
+		s.expression(call.Arguments[0])
+		s.fn.Calls = append(s.fn.Calls, call)
+		s.emit(OpBuiltin, len(s.fn.Calls)-1, 0)
+		return
+	}
+
+	s.expression(call.Function)
+	s.arguments(call)
+	s.fn.Calls = append(s.fn.Calls, call)
+	s.emit(OpCall, len(s.fn.Calls)-1, 0)
+}
+
+// tailCall compiles a call whose value is returned by the function making it
+func (s *funcState) tailCall(call *ast.FunctionCall) {
+	defer s.at(call)()
+	s.expression(call.Function)
+	s.arguments(call)
+	s.fn.Calls = append(s.fn.Calls, call)
+	s.emit(OpTailCall, len(s.fn.Calls)-1, 0)
+}
+
+// arguments pushes the values of the arguments of call in the order they were passed
+func (s *funcState) arguments(call *ast.FunctionCall) {
+	for _, arg := range call.Arguments {
+		if named, ok := arg.(*ast.NamedArgument); ok {
+			arg = named.Value
+		}
+		s.expression(arg)
+	}
+}
+
+// functionLiteral creates a function, whose body is compiled once the scope it's declared in has ended
+func (s *funcState) functionLiteral(lit *ast.FunctionLiteral) {
+	name := lit.Symbol
+	if lit.Receiver != "" {
+		name = lit.Receiver + "." + lit.Symbol
+	} else if name == "" {
+		name = "anonymous function"
+	}
+	fn := &Function{Name: name, Declaration: lit, FilePath: s.fn.FilePath}
+	s.fn.Functions = append(s.fn.Functions, fn)
+	sc := s.current()
+	sc.pending = append(sc.pending, pending{fn: fn, literal: lit})
+
+	// a method is stored in the type it belongs to rather than in a variable
+	if lit.Receiver != "" {
+		s.load(lit.Receiver, OpNil)
+		s.emit(OpClosure, len(s.fn.Functions)-1, 0)
+		s.emit(OpMethod, 0, 0)
+		return
+	}
+	s.emit(OpClosure, len(s.fn.Functions)-1, 0)
+	if lit.Symbol != "" {
+		s.emit(OpDeclareLocal, s.declare(lit.Symbol, ast.FUNC), 0)
+	}
+}
+
+// objectLiteral pushes the values of an object in the order of their keys
+func (s *funcState) objectLiteral(obj *ast.ObjectLiteral) {
+	keys := make([]string, 0, len(obj.Value))
+	for k := range obj.Value {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		s.expression(obj.Value[k])
+	}
+	s.fn.Objects = append(s.fn.Objects, keys)
+	s.emit(OpObject, len(s.fn.Objects)-1, 0)
+}
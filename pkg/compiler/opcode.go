@@ -0,0 +1,218 @@
+package compiler
+
+// Opcode is the operation an instruction performs
+// the comment on each opcode describes its operands, and what it pops from and pushes to the stack
+type Opcode byte
+
+const (
+	// OpConstant pushes Constants[A]
+	OpConstant Opcode = iota
+	// OpNil pushes nil
+	OpNil
+	// OpUndefined pushes nothing in place of a variable which isn't declared, e.g. the function of a method call which may not exist
+	OpUndefined
+	// OpPop pops a value
+	OpPop
+	// OpError raises an error with the message Strings[A]
+	OpError
+
+	// OpEnterScope clears the slots of Scopes[A]
+	OpEnterScope
+	// OpGetLocal pushes slot A, raising an error if it isn't declared or is an unexpected nil
+	OpGetLocal
+	// OpLoadLocal pushes slot A, or nil if it isn't declared
+	OpLoadLocal
+	// OpDeclareLocal converts the top of the stack to the type of slot A and stores it there, replacing the top of the stack with the result
+	// if B is 1 the slot is declared without a value, and nil is pushed
+	OpDeclareLocal
+	// OpAssignLocal is like OpDeclareLocal, but raises an error if slot A isn't declared
+	OpAssignLocal
+	// OpStoreLocal stores the top of the stack in slot A without converting it
+	OpStoreLocal
+	// OpGetCapture, OpLoadCapture, OpAssignCapture, and OpStoreCapture are the same as the local ones, for Captures[A]
+	OpGetCapture
+	OpLoadCapture
+	OpAssignCapture
+	OpStoreCapture
+	// OpMark stores the size of the stack in slot A, so a break or continue can unwind to it with OpUnwind
+	OpMark
+	// OpUnwind pops values until the stack is the size stored in slot A
+	OpUnwind
+	// OpSkipIfBound jumps to A if slot B has a value, which is used to skip the default value of a parameter which was passed
+	OpSkipIfBound
+
+	// OpJump jumps to A
+	OpJump
+	// OpJumpIfFalse pops a bool and jumps to A if it's false, raising the error Strings[B] if it isn't a bool
+	OpJumpIfFalse
+	// OpJumpIfTrue pops a bool and jumps to A if it's true, raising the error Strings[B] if it isn't a bool
+	OpJumpIfTrue
+	// OpAnd and OpOr check that the top of the stack is a bool, and jump to A if it decides the operation, otherwise popping it
+	OpAnd
+	OpOr
+	// OpBool checks that the top of the stack is a bool operand of the operator Strings[A]
+	OpBool
+	// OpCoalesce jumps to A if the top of the stack isn't nil, otherwise popping it
+	OpCoalesce
+
+	// OpBinary pops the right and left operands and pushes the result of the operator Strings[A]
+	OpBinary
+	// OpNegate pops a number and pushes its negative
+	OpNegate
+	// OpNot pops a bool and pushes its opposite
+	OpNot
+	// OpArray pops A values and pushes an arr of them
+	OpArray
+	// OpObject pops a value for each key of Objects[A] and pushes an obj of them
+	OpObject
+	// OpStringify pops a value and pushes it converted to a str
+	OpStringify
+	// OpInterpolate pops A strs and pushes a str of them joined together
+	OpInterpolate
+	// OpClosure pushes a function of Functions[A] which captures the variables it uses
+	OpClosure
+	// OpMethod pops a function and the type it belongs to, adds the function as a method, and pushes the function
+	OpMethod
+	// OpType pushes a new type declared by Types[A]
+	OpType
+
+	// OpProperty pops a value and pushes its property or field Strings[A]
+	OpProperty
+	// OpCall pops the arguments of Calls[A] and the function being called, and pushes the value it returns
+	OpCall
+	// OpInvoke pops the arguments of Calls[A], the variable of the same name as the method, and the value it's called on, and pushes the value it returns
+	OpInvoke
+	// OpTailCall is an OpCall whose value is returned, which ends the function and calls the function in its place if it can
+	OpTailCall
+	// OpBuiltin pops the argument of the built in function Calls[A] and pushes its result
+	OpBuiltin
+	// OpReturn pops the value a function returns
+	OpReturn
+	// OpEnd ends a function which didn't return a value
+	OpEnd
+
+	// OpElementRef pops an index and an arr, and pushes a reference to the element
+	OpElementRef
+	// OpPropertyRef pops a value and pushes a reference to its property or field Strings[A]
+	OpPropertyRef
+	// OpRefGet pushes the current value of the reference on top of the stack
+	OpRefGet
+	// OpRefSet pops a value and a reference, stores the value, and pushes the value that was stored
+	OpRefSet
+
+	// OpIter pops an arr or str and pushes an iterator over it, which steps by A
+	OpIter
+	// OpNext pushes the next element of the iterator in slot B, or jumps to A if there aren't any left
+	OpNext
+
+	// OpMatchLiteral pops a literal and a value, and pushes whether they're equal
+	OpMatchLiteral
+	// OpMatchRange pops the end and start of a range and a value, and pushes whether the range contains the value
+	OpMatchRange
+	// OpMatchType pops a value and pushes whether it has the type Strings[A]
+	OpMatchType
+	// OpMatchArray pops a value and pushes whether it is an arr of A elements, or at least A elements if B is 1
+	OpMatchArray
+	// OpMatchObject pops a value and pushes whether it's an obj or record with every key of Objects[A]
+	OpMatchObject
+	// OpElement pops an arr and pushes its element A
+	OpElement
+	// OpRest pops an arr and pushes a new arr of its elements from A
+	OpRest
+	// OpField pops an obj or record and pushes its property or field Strings[A]
+	OpField
+	// OpDestructureArray pops a value, raising an error unless it is an arr of A elements, or at least A elements if B is 1
+	OpDestructureArray
+	// OpDestructureObject pops a value, raising an error unless it's an obj or record
+	OpDestructureObject
+	// OpDestructureField pops an obj or record and pushes its property or field Strings[A], raising an error if it doesn't have one
+	OpDestructureField
+
+	// OpEtch pops A strs and prints them
+	OpEtch
+	// OpRead prints the prompt Strings[A] if A isn't -1, and pushes a line of input or nil if there isn't any left
+	OpRead
+	// OpThrow pops an err or str and raises it as an error
+	OpThrow
+	// OpTry executes Tries[A]
+	OpTry
+	// OpImport executes Imports[A] and pushes each of the values it imports
+	OpImport
+	// OpExport pops a value and exports it as Strings[A]
+	OpExport
+)
+
+var opcodeNames = [...]string{
+	OpConstant:          "CONSTANT",
+	OpNil:               "NIL",
+	OpUndefined:         "UNDEFINED",
+	OpPop:               "POP",
+	OpError:             "ERROR",
+	OpEnterScope:        "ENTER_SCOPE",
+	OpGetLocal:          "GET_LOCAL",
+	OpLoadLocal:         "LOAD_LOCAL",
+	OpDeclareLocal:      "DECLARE_LOCAL",
+	OpAssignLocal:       "ASSIGN_LOCAL",
+	OpStoreLocal:        "STORE_LOCAL",
+	OpGetCapture:        "GET_CAPTURE",
+	OpLoadCapture:       "LOAD_CAPTURE",
+	OpAssignCapture:     "ASSIGN_CAPTURE",
+	OpStoreCapture:      "STORE_CAPTURE",
+	OpMark:              "MARK",
+	OpUnwind:            "UNWIND",
+	OpSkipIfBound:       "SKIP_IF_BOUND",
+	OpJump:              "JUMP",
+	OpJumpIfFalse:       "JUMP_IF_FALSE",
+	OpJumpIfTrue:        "JUMP_IF_TRUE",
+	OpAnd:               "AND",
+	OpOr:                "OR",
+	OpBool:              "BOOL",
+	OpCoalesce:          "COALESCE",
+	OpBinary:            "BINARY",
+	OpNegate:            "NEGATE",
+	OpNot:               "NOT",
+	OpArray:             "ARRAY",
+	OpObject:            "OBJECT",
+	OpStringify:         "STRINGIFY",
+	OpInterpolate:       "INTERPOLATE",
+	OpClosure:           "CLOSURE",
+	OpMethod:            "METHOD",
+	OpType:              "TYPE",
+	OpProperty:          "PROPERTY",
+	OpCall:              "CALL",
+	OpInvoke:            "INVOKE",
+	OpTailCall:          "TAIL_CALL",
+	OpBuiltin:           "BUILTIN",
+	OpReturn:            "RETURN",
+	OpEnd:               "END",
+	OpElementRef:        "ELEMENT_REF",
+	OpPropertyRef:       "PROPERTY_REF",
+	OpRefGet:            "REF_GET",
+	OpRefSet:            "REF_SET",
+	OpIter:              "ITER",
+	OpNext:              "NEXT",
+	OpMatchLiteral:      "MATCH_LITERAL",
+	OpMatchRange:        "MATCH_RANGE",
+	OpMatchType:         "MATCH_TYPE",
+	OpMatchArray:        "MATCH_ARRAY",
+	OpMatchObject:       "MATCH_OBJECT",
+	OpElement:           "ELEMENT",
+	OpRest:              "REST",
+	OpField:             "FIELD",
+	OpDestructureArray:  "DESTRUCTURE_ARRAY",
+	OpDestructureObject: "DESTRUCTURE_OBJECT",
+	OpDestructureField:  "DESTRUCTURE_FIELD",
+	OpEtch:              "ETCH",
+	OpRead:              "READ",
+	OpThrow:             "THROW",
+	OpTry:               "TRY",
+	OpImport:            "IMPORT",
+	OpExport:            "EXPORT",
+}
+
+func (op Opcode) String() string {
+	if int(op) < len(opcodeNames) {
+		return opcodeNames[op]
+	}
+	return "UNKNOWN"
+}
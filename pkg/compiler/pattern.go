@@ -0,0 +1,155 @@
+package compiler
+
+import (
+	"github.com/mcjcloud/taurine/pkg/ast"
+	"github.com/mcjcloud/taurine/pkg/value"
+)
+
+// match compiles a match expression, whose arms are tried in order until one of their patterns matches
+// the match is nil if no pattern matches, or if the arm that matched is a block
+func (s *funcState) match(m *ast.MatchExpression) {
+	s.expression(m.Value)
+	subject := s.hidden()
+	s.emit(OpStoreLocal, subject, 0)
+	s.emit(OpPop, 0, 0)
+
+	ends := make([]int, 0, len(m.Arms))
+	for _, arm := range m.Arms {
+		// the variables stored by a pattern are only visible in its arm
+		s.enterScope()
+		fails := s.pattern(arm.Pattern, func() { s.emit(OpLoadLocal, subject, 0) })
+		if arm.Body == nil {
+			s.expression(arm.Value)
+		} else {
+			s.statement(arm.Body)
+			s.emit(OpNil, 0, 0)
+		}
+		s.exitScope()
+		ends = append(ends, s.emit(OpJump, 0, 0))
+		for _, fail := range fails {
+			s.patch(fail)
+		}
+	}
+	s.emit(OpNil, 0, 0)
+	for _, end := range ends {
+		s.patch(end)
+	}
+}
+
+// pattern compiles the checks of a pattern against the value pushed by load, storing the variables of the pattern as it goes
+// it returns the jumps taken when the value doesn't match, which the caller points at the next arm
+func (s *funcState) pattern(pattern ast.Pattern, load func()) []int {
+	defer s.at(pattern)()
+
+	var fails []int
+	check := func() {
+		fails = append(fails, s.emit(OpJumpIfFalse, 0, -1))
+	}
+	switch p := pattern.(type) {
+	case *ast.LiteralPattern:
+		load()
+		s.expression(p.Value)
+		s.emit(OpMatchLiteral, 0, 0)
+		check()
+	case *ast.RangePattern:
+		load()
+		s.expression(p.Start)
+		s.expression(p.End)
+		s.emit(OpMatchRange, 0, 0)
+		check()
+	case *ast.BindingPattern:
+		if p.Symbol != ast.WILDCARD {
+			load()
+			s.emit(OpStoreLocal, s.bind(p.Symbol), 0)
+			s.emit(OpPop, 0, 0)
+		}
+	case *ast.TypePattern:
+		load()
+		s.emit(OpMatchType, s.str(p.SymbolType), 0)
+		check()
+		if p.Symbol != ast.WILDCARD {
+			load()
+			s.emit(OpDeclareLocal, s.declare(p.Symbol, ast.Symbol(p.SymbolType)), 0)
+			s.emit(OpPop, 0, 0)
+		}
+	case *ast.ArrayPattern:
+		load()
+		s.emit(OpMatchArray, len(p.Elements), boolOperand(p.Rest != nil))
+		check()
+		for i, el := range p.Elements {
+			i := i
+			fails = append(fails, s.pattern(el, func() { load(); s.emit(OpElement, i, 0) })...)
+		}
+		if p.Rest != nil {
+			fails = append(fails, s.pattern(p.Rest, func() { load(); s.emit(OpRest, len(p.Elements), 0) })...)
+		}
+	case *ast.ObjectPattern:
+		keys := make([]string, len(p.Properties))
+		for i, prop := range p.Properties {
+			keys[i] = prop.Key
+		}
+		s.fn.Objects = append(s.fn.Objects, keys)
+		load()
+		s.emit(OpMatchObject, len(s.fn.Objects)-1, 0)
+		check()
+		for _, prop := range p.Properties {
+			key := s.str(prop.Key)
+			fails = append(fails, s.pattern(prop.Pattern, func() { load(); s.emit(OpField, key, 0) })...)
+		}
+	default:
+		s.constant(&value.Bool{Value: false})
+		check()
+	}
+	return fails
+}
+
+// destructure compiles storing the parts of the value pushed by load in the variables of a pattern
+// unlike matching a pattern, it is an error for the value not to have the shape of the pattern
+func (s *funcState) destructure(pattern ast.Pattern, load func()) {
+	switch p := pattern.(type) {
+	case *ast.BindingPattern:
+		if p.Symbol != ast.WILDCARD {
+			load()
+			s.emit(OpStoreLocal, s.bind(p.Symbol), 0)
+			s.emit(OpPop, 0, 0)
+		}
+	case *ast.TypePattern:
+		if p.Symbol == ast.WILDCARD {
+			return
+		}
+		if s.declared(p.Symbol) {
+			s.fail("variable '%s' already exists", p.Symbol)
+			return
+		}
+		load()
+		s.emit(OpDeclareLocal, s.declare(p.Symbol, ast.Symbol(p.SymbolType)), 0)
+		s.emit(OpPop, 0, 0)
+	case *ast.ArrayPattern:
+		load()
+		s.emit(OpDestructureArray, len(p.Elements), boolOperand(p.Rest != nil))
+		for i, el := range p.Elements {
+			i := i
+			s.destructure(el, func() { load(); s.emit(OpElement, i, 0) })
+		}
+		if p.Rest != nil {
+			s.destructure(p.Rest, func() { load(); s.emit(OpRest, len(p.Elements), 0) })
+		}
+	case *ast.ObjectPattern:
+		load()
+		s.emit(OpDestructureObject, 0, 0)
+		for _, prop := range p.Properties {
+			key := s.str(prop.Key)
+			s.destructure(prop.Pattern, func() { load(); s.emit(OpDestructureField, key, 0) })
+		}
+	default:
+		s.fail("cannot destructure %s", pattern)
+	}
+}
+
+// boolOperand is 1 for true and 0 for false, for instructions which take a flag
+func boolOperand(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,73 @@
+package compiler
+
+import (
+	"github.com/mcjcloud/taurine/pkg/ast"
+	"github.com/mcjcloud/taurine/pkg/value"
+)
+
+// Program is the compiled code of a file and every file it imports
+type Program struct {
+	Main    *Function            // the top level of the file being run
+	Modules map[string]*Function // the top level of each imported file, by absolute path
+}
+
+// Instruction is a single step of a function's code
+// the meaning of the operands A and B depends on the Opcode
+type Instruction struct {
+	Op Opcode
+	A  int
+	B  int
+}
+
+// Slot is a local variable of a function
+type Slot struct {
+	Name     string     // the name of the variable, or "" for a value the compiler stores itself
+	Type     ast.Symbol // the declared type of the variable, or "" if it can hold anything
+	Captured bool       // true if a function declared inside this one uses the variable
+}
+
+// Capture is a variable from an enclosing function which a function uses
+type Capture struct {
+	Local bool // true if the variable is a slot of the enclosing function, false if the enclosing function captured it too
+	Index int  // the slot or capture of the enclosing function
+	Name  string
+	Type  ast.Symbol
+}
+
+// Try is a try statement, whose blocks are ranges of code
+type Try struct {
+	Statement  *ast.TryStatement
+	Body       [2]int // the start and end of the try block
+	Catch      [2]int // the start and end of the catch block, whose first instruction stores the error; empty if there isn't one
+	Finally    [2]int // the start and end of the finally block; empty if there isn't one
+	End        int    // the instruction after the try statement
+	ParamSlot  int    // the slot of the catch block's variable
+	ParamScope int    // the scope the catch block's variable is declared in
+}
+
+// Import is an import statement and the file it resolved to
+type Import struct {
+	Statement *ast.ImportStatement
+	Path      string    // the absolute path of the imported file
+	Module    *Function // the top level of the imported file, or nil if it isn't in the import graph
+}
+
+// Function is the compiled code of a function, or the top level of a file
+type Function struct {
+	Name        string
+	Declaration *ast.FunctionLiteral // nil for the top level of a file
+	FilePath    string
+	Code        []Instruction
+	Refs        []*ast.SourceRef // the location in the source code of each instruction
+	Constants   []value.Value
+	Strings     []string // names, operators, and messages used by instructions
+	Functions   []*Function
+	Types       []*ast.TypeDeclaration
+	Calls       []*ast.FunctionCall
+	Objects     [][]string // the keys of each object literal
+	Tries       []*Try
+	Imports     []*Import
+	Slots       []Slot
+	Scopes      [][]int // the slots of each scope, which are cleared each time the scope is entered
+	Captures    []Capture
+}
@@ -0,0 +1,199 @@
+package compiler
+
+import (
+	"path/filepath"
+
+	"github.com/mcjcloud/taurine/pkg/ast"
+	"github.com/mcjcloud/taurine/pkg/util"
+)
+
+func (s *funcState) statement(stmt ast.Statement) {
+	defer s.at(stmt)()
+
+	switch t := stmt.(type) {
+	case *ast.ImportStatement:
+		s.importStatement(t)
+	case *ast.ExportStatement:
+		s.expression(t.Value)
+		s.emit(OpExport, s.str(t.Identifier.Name), 0)
+	case *ast.ExpressionStatement:
+		s.expression(t.Expression)
+		s.emit(OpPop, 0, 0)
+	case *ast.EtchStatement:
+		for _, exp := range t.Expressions {
+			s.stringify(exp)
+		}
+		s.emit(OpEtch, len(t.Expressions), 0)
+	case *ast.ReadStatement:
+		prompt := -1
+		if t.Prompt != nil {
+			prompt = s.str(t.Prompt.Value)
+		}
+		s.emit(OpRead, prompt, 0)
+		s.store(t.Identifier.Name)
+		s.emit(OpPop, 0, 0)
+	case *ast.BlockStatement:
+		s.block(t)
+	case *ast.IfStatement:
+		s.ifStatement(t)
+	case *ast.ForLoopStatement:
+		s.forStatement(t)
+	case *ast.WhileLoopStatement:
+		s.whileStatement(t)
+	case *ast.ReturnStatement:
+		if t.Tail {
+			s.tailCall(t.Value.(*ast.FunctionCall))
+		} else if t.Value != nil {
+			s.expression(t.Value)
+		} else {
+			s.emit(OpNil, 0, 0)
+		}
+		s.emit(OpReturn, 0, 0)
+	case *ast.BreakStatement:
+		s.loopControl(func(l *loop) *[]int { return &l.breaks })
+	case *ast.ContinueStatement:
+		s.loopControl(func(l *loop) *[]int { return &l.continues })
+	case *ast.ThrowStatement:
+		s.expression(t.Value)
+		s.emit(OpThrow, 0, 0)
+	case *ast.TryStatement:
+		s.tryStatement(t)
+	default:
+		s.fail("unkown statement %s", stmt)
+	}
+}
+
+func (s *funcState) block(block *ast.BlockStatement) {
+	s.enterScope()
+	for _, stmt := range block.Statements {
+		s.statement(stmt)
+	}
+	s.exitScope()
+}
+
+func (s *funcState) importStatement(stmt *ast.ImportStatement) {
+	absPath := util.ResolveImport(filepath.Dir(s.fn.FilePath), stmt.Source)
+	s.fn.Imports = append(s.fn.Imports, &Import{Statement: stmt, Path: absPath, Module: s.module(absPath)})
+	s.emit(OpImport, len(s.fn.Imports)-1, 0)
+
+	// the imported values are pushed in order, so they're stored starting with the last
+	for i := len(stmt.Imports) - 1; i >= 0; i-- {
+		s.store(stmt.Imports[i].Name)
+		s.emit(OpPop, 0, 0)
+	}
+}
+
+func (s *funcState) ifStatement(stmt *ast.IfStatement) {
+	s.expression(stmt.Condition)
+	skip := s.emit(OpJumpIfFalse, 0, s.str("if expression must evaluate to boolean"))
+	s.statement(stmt.Statement)
+	if stmt.ElseIf == nil {
+		s.patch(skip)
+		return
+	}
+	end := s.emit(OpJump, 0, 0)
+	s.patch(skip)
+	s.statement(stmt.ElseIf)
+	s.patch(end)
+}
+
+// forStatement compiles a loop which stores each element of the iterator in the control variable
+// each iteration has its own scope, so functions declared in the loop capture that iteration's variables
+func (s *funcState) forStatement(stmt *ast.ForLoopStatement) {
+	s.expression(stmt.Iterator)
+	s.emit(OpIter, stmt.Step, 0)
+	iter := s.hidden()
+	s.emit(OpStoreLocal, iter, 0)
+	s.emit(OpPop, 0, 0)
+	l := s.pushLoop()
+
+	next := s.emit(OpNext, 0, iter)
+	s.enterScope()
+	if stmt.Pattern != nil {
+		element := s.hidden()
+		s.emit(OpStoreLocal, element, 0)
+		s.emit(OpPop, 0, 0)
+		s.destructure(stmt.Pattern, func() { s.emit(OpLoadLocal, element, 0) })
+	} else {
+		// each iteration declares a new control variable, even if there's a variable of the same name outside of the loop
+		s.emit(OpStoreLocal, s.declare(stmt.Control.Name, ""), 0)
+		s.emit(OpPop, 0, 0)
+	}
+	s.statement(stmt.Statement)
+	s.exitScope()
+	s.emit(OpJump, next, 0)
+	s.patch(next)
+	s.popLoop(l, next)
+}
+
+// whileStatement compiles a loop which checks its condition after each iteration
+// the condition has a different error for not being a bool the first time than for the times after
+func (s *funcState) whileStatement(stmt *ast.WhileLoopStatement) {
+	l := s.pushLoop()
+	s.expression(stmt.Condition)
+	skip := s.emit(OpJumpIfFalse, 0, s.str("while expression must evaluate to boolean"))
+	body := len(s.fn.Code)
+	s.statement(stmt.Statement)
+	condition := len(s.fn.Code)
+	s.expression(stmt.Condition)
+	s.emit(OpJumpIfTrue, body, s.str("while expression is no longer boolean"))
+	s.patch(skip)
+	s.popLoop(l, condition)
+}
+
+// pushLoop starts a loop, marking the size of the stack so a break or continue inside of an expression can unwind it
+func (s *funcState) pushLoop() *loop {
+	l := &loop{mark: s.hidden()}
+	s.emit(OpMark, l.mark, 0)
+	s.loops = append(s.loops, l)
+	return l
+}
+
+// popLoop ends a loop, making its breaks jump to the next instruction and its continues jump to next
+func (s *funcState) popLoop(l *loop, next int) {
+	for _, index := range l.breaks {
+		s.patch(index)
+	}
+	for _, index := range l.continues {
+		s.fn.Code[index].A = next
+	}
+	s.loops = s.loops[:len(s.loops)-1]
+}
+
+// loopControl compiles a break or continue, whose jump is added to the list of the innermost loop chosen by jumps
+func (s *funcState) loopControl(jumps func(l *loop) *[]int) {
+	if len(s.loops) == 0 {
+		s.fail("break and continue must be inside of a loop")
+		return
+	}
+	l := s.loops[len(s.loops)-1]
+	s.emit(OpUnwind, l.mark, 0)
+	list := jumps(l)
+	*list = append(*list, s.emit(OpJump, 0, 0))
+}
+
+// tryStatement compiles the blocks of a try statement as ranges of code which OpTry executes
+func (s *funcState) tryStatement(stmt *ast.TryStatement) {
+	try := &Try{Statement: stmt}
+	s.fn.Tries = append(s.fn.Tries, try)
+	s.emit(OpTry, len(s.fn.Tries)-1, 0)
+
+	try.Body[0] = len(s.fn.Code)
+	s.statement(stmt.Statement)
+	try.Body[1] = len(s.fn.Code)
+	if stmt.Catch != nil {
+		sc := s.pushScope()
+		try.ParamScope = sc.index
+		try.ParamSlot = s.declare(stmt.CatchParameter.Symbol, ast.ERR)
+		try.Catch[0] = len(s.fn.Code)
+		s.statement(stmt.Catch)
+		try.Catch[1] = len(s.fn.Code)
+		s.exitScope()
+	}
+	if stmt.Finally != nil {
+		try.Finally[0] = len(s.fn.Code)
+		s.statement(stmt.Finally)
+		try.Finally[1] = len(s.fn.Code)
+	}
+	try.End = len(s.fn.Code)
+}
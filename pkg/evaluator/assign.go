@@ -0,0 +1,171 @@
+package evaluator
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/mcjcloud/taurine/pkg/ast"
+	"github.com/mcjcloud/taurine/pkg/value"
+)
+
+// lvalue is a location a value can be assigned to
+type lvalue interface {
+	get() (value.Value, error)
+	set(val value.Value) (value.Value, error)
+}
+
+// variableRef is a variable declared in a scope
+type variableRef struct {
+	scope *Scope
+	id    *ast.Identifier
+}
+
+func (r *variableRef) get() (value.Value, error) {
+	return r.scope.Get(r.id.Binding), nil
+}
+
+func (r *variableRef) set(val value.Value) (value.Value, error) {
+	return r.scope.Assign(r.id.Binding, r.id.Name, val)
+}
+
+// elementRef is an element of an array e.g. arr@i
+type elementRef struct {
+	arr   *value.Arr
+	index int
+}
+
+func (r *elementRef) get() (value.Value, error) {
+	return r.arr.Elements[r.index], nil
+}
+
+func (r *elementRef) set(val value.Value) (value.Value, error) {
+	r.arr.Elements[r.index] = val
+	return val, nil
+}
+
+// propertyRef is a property of an object e.g. obj.prop, which is created when it is first assigned
+type propertyRef struct {
+	obj  *value.Obj
+	name string
+}
+
+func (r *propertyRef) get() (value.Value, error) {
+	if val, ok := r.obj.Properties[r.name]; ok {
+		return val, nil
+	}
+	return nil, fmt.Errorf("property '%s' does not exist", r.name)
+}
+
+func (r *propertyRef) set(val value.Value) (value.Value, error) {
+	r.obj.Properties[r.name] = val
+	return val, nil
+}
+
+// fieldRef is a field of a record e.g. point.x, which can only hold values of the field's type
+type fieldRef struct {
+	rec   *value.Record
+	field value.Field
+}
+
+func (r *fieldRef) get() (value.Value, error) {
+	return r.rec.Fields[r.field.Name], nil
+}
+
+func (r *fieldRef) set(val value.Value) (value.Value, error) {
+	conformed, err := value.ConformField(r.field, val)
+	if err != nil {
+		return nil, err
+	}
+	r.rec.Fields[r.field.Name] = conformed
+	return conformed, nil
+}
+
+func evaluateAssignmentExpression(asn *ast.AssignmentExpression, scope *Scope) (value.Value, error) {
+	ref, err := resolveLvalue(asn.Target, scope)
+	if err != nil {
+		return nil, err
+	}
+
+	// compound assignments apply their operator to the current value first, e.g. x += 1 is x = x + 1
+	var current value.Value
+	binary, compound := ast.COMPOUND_OPERATORS[asn.Operator]
+	if compound {
+		if current, err = ref.get(); err != nil {
+			return nil, err
+		}
+	}
+
+	val, err := evaluateExpression(asn.Value, scope)
+	if err != nil {
+		return nil, err
+	}
+	if compound {
+		val, err = value.BinaryOperation(binary, current, val)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return ref.set(val)
+}
+
+// resolveLvalue evaluates everything but the final step of target, so the location it refers to can be read and written
+func resolveLvalue(target ast.Expression, scope *Scope) (lvalue, error) {
+	switch t := target.(type) {
+	case *ast.Identifier:
+		if scope.Get(t.Binding) == nil {
+			return nil, fmt.Errorf("'%s' was not declared", t.Name)
+		}
+		return &variableRef{scope: scope, id: t}, nil
+	case *ast.OperationExpression:
+		if t.Operator == ast.AT {
+			return resolveElement(t, scope)
+		} else if t.Operator == ast.DOT {
+			return resolveProperty(t, scope)
+		}
+	}
+	return nil, errors.New("expected left side of assignment to be an identifier, index, or property")
+}
+
+func resolveElement(op *ast.OperationExpression, scope *Scope) (lvalue, error) {
+	left, right, err := evaluateOperands(op.LeftExpression, op.RightExpression, scope)
+	if err != nil {
+		return nil, err
+	}
+	index, ok := right.(*value.Int)
+	if !ok {
+		return nil, errors.New("'@' operator must be in form arr@integer")
+	}
+	i := int(index.Value.Int64())
+
+	arr, ok := left.(*value.Arr)
+	if !ok {
+		return nil, fmt.Errorf("cannot assign to an element of %s", left.Type())
+	}
+	if i < 0 || i >= len(arr.Elements) {
+		return nil, fmt.Errorf("index %d out of range", i)
+	}
+	return &elementRef{arr: arr, index: i}, nil
+}
+
+func resolveProperty(op *ast.OperationExpression, scope *Scope) (lvalue, error) {
+	id, ok := op.RightExpression.(*ast.Identifier)
+	if !ok {
+		return nil, errors.New("expected property name on right side of '.'")
+	}
+	left, err := evaluateExpression(op.LeftExpression, scope)
+	if err != nil {
+		return nil, err
+	}
+	if rec, ok := left.(*value.Record); ok {
+		field, ok := rec.RecordType.Field(id.Name)
+		if !ok {
+			return nil, fmt.Errorf("%s has no field '%s'", rec.Type(), id.Name)
+		}
+		return &fieldRef{rec: rec, field: field}, nil
+	}
+	obj, ok := left.(*value.Obj)
+	if !ok {
+		return nil, fmt.Errorf("cannot assign to a property of %s", left.Type())
+	}
+	return &propertyRef{obj: obj, name: id.Name}, nil
+}
@@ -4,173 +4,49 @@ import (
 	"fmt"
 
 	"github.com/mcjcloud/taurine/pkg/ast"
+	"github.com/mcjcloud/taurine/pkg/value"
 )
 
-func equalEqual(leftExp, rightExp ast.Expression, scope *Scope) (ast.Expression, error) {
-	left, right, err := evaluateOperands(leftExp, rightExp, scope)
+// evaluateBoolean evaluates exp, expecting it to be a bool operand of op
+func evaluateBoolean(exp ast.Expression, op ast.Operator, scope *Scope) (*value.Bool, error) {
+	val, err := evaluateExpression(exp, scope)
 	if err != nil {
 		return nil, err
 	}
-
-	leftNum, lok := left.(*ast.NumberLiteral)
-	if lok {
-		rightVal, err := conformDataType(ast.NUM, right)
-		if err != nil {
-			return nil, err
-		}
-		rightNum, rok := rightVal.(*ast.NumberLiteral)
-		if rok {
-			return &ast.BooleanLiteral{Value: leftNum.Value == rightNum.Value}, nil
-		}
-	}
-
-	leftInt, lok := left.(*ast.IntegerLiteral)
-	rightInt, rok := right.(*ast.IntegerLiteral)
-	if lok && rok {
-		return &ast.BooleanLiteral{Value: leftInt.Value.Cmp(rightInt.Value) == 0}, nil
-	}
-
-	leftStr, lok := left.(*ast.StringLiteral)
-	rightStr, rok := right.(*ast.StringLiteral)
-	if lok && rok {
-		return &ast.BooleanLiteral{Value: leftStr.Value == rightStr.Value}, nil
-	}
-
-	leftBool, lok := left.(*ast.BooleanLiteral)
-	rightBool, rok := right.(*ast.BooleanLiteral)
-	if lok && rok {
-		return &ast.BooleanLiteral{Value: leftBool.Value == rightBool.Value}, nil
-	}
-
-	return nil, fmt.Errorf("'==' cannot be applied to '%s' and '%s'", leftExp, rightExp)
-}
-
-func notEqual(leftExp, rightExp ast.Expression, scope *Scope) (ast.Expression, error) {
-	left, right, err := evaluateOperands(leftExp, rightExp, scope)
-	if err != nil {
-		return nil, err
-	}
-
-	leftNum, lok := left.(*ast.NumberLiteral)
-	if lok {
-		rightVal, err := conformDataType(ast.NUM, right)
-		if err != nil {
-			return nil, err
-		}
-		rightNum, rok := rightVal.(*ast.NumberLiteral)
-		if rok {
-			return &ast.BooleanLiteral{Value: leftNum.Value != rightNum.Value}, nil
-		}
-	}
-
-	leftInt, lok := left.(*ast.IntegerLiteral)
-	rightInt, rok := right.(*ast.IntegerLiteral)
-	if lok && rok {
-		return &ast.BooleanLiteral{Value: leftInt.Value.Cmp(rightInt.Value) != 0}, nil
-	}
-
-	leftStr, lok := left.(*ast.StringLiteral)
-	rightStr, rok := right.(*ast.StringLiteral)
-	if lok && rok {
-		return &ast.BooleanLiteral{Value: leftStr.Value != rightStr.Value}, nil
-	}
-
-	leftBool, lok := left.(*ast.BooleanLiteral)
-	rightBool, rok := right.(*ast.BooleanLiteral)
-	if lok && rok {
-		return &ast.BooleanLiteral{Value: leftBool.Value != rightBool.Value}, nil
-	}
-
-	return nil, fmt.Errorf("'!=' cannot be applied to '%s' and '%s'", leftExp, rightExp)
-}
-
-func lessThan(leftExp, rightExp ast.Expression, scope *Scope) (ast.Expression, error) {
-	left, right, err := evaluateOperands(leftExp, rightExp, scope)
-	if err != nil {
-		return nil, err
+	if boolVal, ok := val.(*value.Bool); ok {
+		return boolVal, nil
 	}
-
-	if leftNum, ok := left.(*ast.NumberLiteral); ok {
-		rightVal, err := conformDataType(ast.NUM, right)
-		if err != nil {
-			return nil, err
-		}
-		if rightNum, ok := rightVal.(*ast.NumberLiteral); ok {
-			return &ast.BooleanLiteral{Value: leftNum.Value < rightNum.Value}, nil
-		}
-	} else if leftInt, ok := left.(*ast.IntegerLiteral); ok {
-		if rightInt, ok := right.(*ast.IntegerLiteral); ok {
-			return &ast.BooleanLiteral{Value: leftInt.Value.Cmp(rightInt.Value) < 0}, nil
-		}
-	}
-	return nil, fmt.Errorf("'<' cannot be applied to '%s' and '%s'", leftExp, rightExp)
+	return nil, fmt.Errorf("'%s' cannot be applied to %s", op, val.Type())
 }
 
-func lessEqual(leftExp, rightExp ast.Expression, scope *Scope) (ast.Expression, error) {
-	left, right, err := evaluateOperands(leftExp, rightExp, scope)
+func logicalAnd(leftExp, rightExp ast.Expression, scope *Scope) (value.Value, error) {
+	left, err := evaluateBoolean(leftExp, ast.AND, scope)
 	if err != nil {
 		return nil, err
 	}
-
-	if leftNum, ok := left.(*ast.NumberLiteral); ok {
-		rightVal, err := conformDataType(ast.NUM, right)
-		if err != nil {
-			return nil, err
-		}
-		if rightNum, ok := rightVal.(*ast.NumberLiteral); ok {
-			return &ast.BooleanLiteral{Value: leftNum.Value <= rightNum.Value}, nil
-		}
-	} else if leftInt, ok := left.(*ast.IntegerLiteral); ok {
-		if rightInt, ok := right.(*ast.IntegerLiteral); ok {
-			return &ast.BooleanLiteral{Value: leftInt.Value.Cmp(rightInt.Value) <= 0}, nil
-		}
+	// the right side is only evaluated if the left side doesn't decide the result
+	if !left.Value {
+		return left, nil
 	}
-
-	return nil, fmt.Errorf("'<=' cannot be applied to '%s' and '%s'", leftExp, rightExp)
+	return evaluateBoolean(rightExp, ast.AND, scope)
 }
 
-func greaterThan(leftExp, rightExp ast.Expression, scope *Scope) (ast.Expression, error) {
-	left, right, err := evaluateOperands(leftExp, rightExp, scope)
+func logicalOr(leftExp, rightExp ast.Expression, scope *Scope) (value.Value, error) {
+	left, err := evaluateBoolean(leftExp, ast.OR, scope)
 	if err != nil {
 		return nil, err
 	}
-
-	if leftNum, ok := left.(*ast.NumberLiteral); ok {
-		rightVal, err := conformDataType(ast.NUM, right)
-		if err != nil {
-			return nil, err
-		}
-		if rightNum, ok := rightVal.(*ast.NumberLiteral); ok {
-			return &ast.BooleanLiteral{Value: leftNum.Value > rightNum.Value}, nil
-		}
-	} else if leftInt, ok := left.(*ast.IntegerLiteral); ok {
-		if rightInt, ok := right.(*ast.IntegerLiteral); ok {
-			return &ast.BooleanLiteral{Value: leftInt.Value.Cmp(rightInt.Value) > 0}, nil
-		}
+	// the right side is only evaluated if the left side doesn't decide the result
+	if left.Value {
+		return left, nil
 	}
-
-	return nil, fmt.Errorf("'>' cannot be applied to '%s' and '%s'", leftExp, rightExp)
+	return evaluateBoolean(rightExp, ast.OR, scope)
 }
 
-func greaterEqual(leftExp, rightExp ast.Expression, scope *Scope) (ast.Expression, error) {
-	left, right, err := evaluateOperands(leftExp, rightExp, scope)
+func logicalNot(exp ast.Expression, scope *Scope) (value.Value, error) {
+	val, err := evaluateBoolean(exp, ast.NOT, scope)
 	if err != nil {
 		return nil, err
 	}
-
-	if leftNum, ok := left.(*ast.NumberLiteral); ok {
-		rightVal, err := conformDataType(ast.NUM, right)
-		if err != nil {
-			return nil, err
-		}
-		if rightNum, ok := rightVal.(*ast.NumberLiteral); ok {
-			return &ast.BooleanLiteral{Value: leftNum.Value >= rightNum.Value}, nil
-		}
-	} else if leftInt, ok := left.(*ast.IntegerLiteral); ok {
-		if rightInt, ok := right.(*ast.IntegerLiteral); ok {
-			return &ast.BooleanLiteral{Value: leftInt.Value.Cmp(rightInt.Value) >= 0}, nil
-		}
-	}
-
-	return nil, fmt.Errorf("'>=' cannot be applied to '%s' and '%s'", leftExp, rightExp)
+	return &value.Bool{Value: !val.Value}, nil
 }
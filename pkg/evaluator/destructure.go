@@ -0,0 +1,82 @@
+package evaluator
+
+import (
+	"fmt"
+
+	"github.com/mcjcloud/taurine/pkg/ast"
+	"github.com/mcjcloud/taurine/pkg/value"
+)
+
+// evaluateDestructuringDeclaration declares the variables in a pattern, returning the value they were taken from
+func evaluateDestructuringDeclaration(decl *ast.DestructuringDeclaration, scope *Scope) (value.Value, error) {
+	val, err := evaluateExpression(decl.Value, scope)
+	if err != nil {
+		return nil, err
+	}
+	if err := destructure(decl.Pattern, val, scope); err != nil {
+		return nil, err
+	}
+	return val, nil
+}
+
+// destructure stores the parts of val in the variables of pattern, declared in scope
+// unlike matching a pattern, it is an error for val not to have the shape of the pattern
+func destructure(pattern ast.Pattern, val value.Value, scope *Scope) error {
+	switch p := pattern.(type) {
+	case *ast.BindingPattern:
+		if p.Symbol != ast.WILDCARD {
+			scope.store(p.Slot, "", val)
+		}
+		return nil
+	case *ast.TypePattern:
+		if p.Symbol == ast.WILDCARD {
+			return nil
+		}
+		_, err := scope.Declare(p.Slot, p.Symbol, ast.Symbol(p.SymbolType), val)
+		return err
+	case *ast.ArrayPattern:
+		arr, ok := val.(*value.Arr)
+		if !ok {
+			return fmt.Errorf("cannot destructure %s as an array", val.Type())
+		}
+		if p.Rest == nil && len(arr.Elements) != len(p.Elements) {
+			return fmt.Errorf("expected an array of %d elements but found %d", len(p.Elements), len(arr.Elements))
+		}
+		if len(arr.Elements) < len(p.Elements) {
+			return fmt.Errorf("expected an array of at least %d elements but found %d", len(p.Elements), len(arr.Elements))
+		}
+		for i, el := range p.Elements {
+			if err := destructure(el, arr.Elements[i], scope); err != nil {
+				return err
+			}
+		}
+		if p.Rest != nil {
+			return destructure(p.Rest, rest(arr, len(p.Elements)), scope)
+		}
+		return nil
+	case *ast.ObjectPattern:
+		_, isObj := val.(*value.Obj)
+		_, isRecord := val.(*value.Record)
+		if !isObj && !isRecord {
+			return fmt.Errorf("cannot destructure %s as an object", val.Type())
+		}
+		for _, prop := range p.Properties {
+			propVal, ok := property(val, prop.Key)
+			if !ok {
+				return fmt.Errorf("cannot destructure missing property '%s' of %s", prop.Key, val.Type())
+			}
+			if err := destructure(prop.Pattern, propVal, scope); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return fmt.Errorf("cannot destructure %s", pattern)
+}
+
+// rest returns a new array of the elements of arr from index start
+func rest(arr *value.Arr, start int) *value.Arr {
+	elements := make([]value.Value, len(arr.Elements)-start)
+	copy(elements, arr.Elements[start:])
+	return &value.Arr{Elements: elements}
+}
@@ -1,66 +1,194 @@
 package evaluator
 
-import "github.com/mcjcloud/taurine/pkg/ast"
+import (
+	"fmt"
 
-// Function represents a function in a particular scope
+	"github.com/mcjcloud/taurine/pkg/ast"
+	"github.com/mcjcloud/taurine/pkg/value"
+)
+
+// ScopedFunction is a function value along with the scope it was declared in
 type ScopedFunction struct {
 	Scope    *Scope
 	Function *ast.FunctionLiteral
 }
 
-// implement Expression interface to allow scope to be stored with the function
-func (s *ScopedFunction) Evaluate() {}
+func (s *ScopedFunction) Type() ast.Symbol                  { return ast.FUNC }
+func (s *ScopedFunction) Name() string                      { return s.Function.Symbol }
+func (s *ScopedFunction) Declaration() *ast.FunctionLiteral { return s.Function }
 func (s *ScopedFunction) String() string {
-	return s.Function.String()
+	if s.Function.Symbol == "" {
+		return fmt.Sprintf("func (%s)", s.Function.ReturnType)
+	}
+	return fmt.Sprintf("func (%s) %s", s.Function.ReturnType, s.Function.Symbol)
 }
 
+// tailCall is returned by a function whose last act is to call another function, which is called once the function's frame has ended
+type tailCall struct {
+	fn   *ScopedFunction
+	call *ast.FunctionCall
+	args []value.Value
+}
+
+func (t *tailCall) Type() ast.Symbol { return ast.FUNC }
+func (t *tailCall) String() string   { return "tail call" }
+
+// Signal represents a return, break, or continue which interrupts the statements of a function or loop
+type Signal int
+
+const (
+	// NoSignal means statements execute normally
+	NoSignal Signal = iota
+	// BreakSignal exits the nearest loop
+	BreakSignal
+	// ContinueSignal skips to the next iteration of the nearest loop
+	ContinueSignal
+	// ReturnSignal exits the function, with or without a return value
+	ReturnSignal
+)
+
 // Scope represents data within a scope during execution
+// each variable is stored in the slot the resolver gave it, which is nil until the variable is declared
 type Scope struct {
-	Parent      *Scope                    // the parent scope
-	Variables   map[string]ast.Expression // a map of variable names to values
-	ReturnValue ast.Expression            // if the scope is for a function, this will hold the return value
+	Parent      *Scope               // the parent scope
+	Values      []value.Value        // the values of the variables in this scope, by slot
+	Types       []ast.Symbol         // the declared types of the variables, which is empty for a variable that can hold anything e.g. one stored by read
+	ReturnValue value.Value          // if the scope is for a function, this will hold the return value
+	Signal      Signal               // set when a return, break, or continue interrupts the scope
+	Function    *ast.FunctionLiteral // if the scope is the frame of a function call, this is the function being called
+	file        *file                // if the scope is the top level of a file, this is the file being evaluated
+	runtime     *Runtime             // the state of the program the scope belongs to
 }
 
-// NewScope creates a new Scope
-func NewScope() *Scope {
-	return &Scope{
-		Parent:    nil,
-		Variables: map[string]ast.Expression{},
-	}
+// NewScope creates a new Scope for a program run with the given options
+func NewScope(opts Options) *Scope {
+	return &Scope{runtime: NewRuntime(opts)}
+}
+
+// Runtime returns the state of the program the scope belongs to
+func (s *Scope) Runtime() *Runtime {
+	return s.runtime
 }
 
 // NewScopeWithParent creates a new scope with a parent scope
 func NewScopeWithParent(par *Scope) *Scope {
-	return &Scope{
-		Parent:    par,
-		Variables: map[string]ast.Expression{},
+	return &Scope{Parent: par, runtime: par.runtime}
+}
+
+// Get returns the current value of the variable b refers to, or nil if it hasn't been declared
+func (s *Scope) Get(b ast.Binding) value.Value {
+	val, _ := s.lookup(b)
+	return val
+}
+
+// Declare creates a variable of type dType in the given slot of this scope, regardless of whether a parent scope has one of the same name
+// the value is converted to dType, and later assignments to the variable must conform to it as well
+func (s *Scope) Declare(slot int, symbol string, dType ast.Symbol, val value.Value) (value.Value, error) {
+	// a variable declared without a value is nil until it's assigned
+	if val == nil {
+		val = &value.Nil{}
+	} else {
+		conformed, err := value.ConformVariable(symbol, dType, val)
+		if err != nil {
+			return nil, err
+		}
+		val = conformed
+	}
+	s.store(slot, dType, val)
+	return val, nil
+}
+
+// Assign updates the existing variable b refers to, named symbol
+// the value is converted to the variable's declared type
+func (s *Scope) Assign(b ast.Binding, symbol string, val value.Value) (value.Value, error) {
+	current, dType := s.lookup(b)
+	if current == nil {
+		return nil, fmt.Errorf("'%s' was not declared", symbol)
 	}
+	if dType != "" {
+		conformed, err := value.ConformVariable(symbol, dType, val)
+		if err != nil {
+			return nil, err
+		}
+		val = conformed
+	}
+	s.ancestor(b.Depth).Values[b.Slot] = val
+	return val, nil
+}
+
+// Set stores a value in the variable b refers to without converting it, declaring a variable without a type if it hasn't been declared
+func (s *Scope) Set(b ast.Binding, val value.Value) {
+	_, dType := s.lookup(b)
+	s.ancestor(b.Depth).store(b.Slot, dType, val)
 }
 
-// NewScopeOfObject creates a new scope with an objects properties as variables
-func NewScopeOfObject(obj *ast.ObjectLiteral, par *Scope) *Scope {
-	return &Scope{
-		Parent:    par,
-		Variables: obj.Value,
+// lookup returns the value and declared type of the variable b refers to, or a nil value if it hasn't been declared
+func (s *Scope) lookup(b ast.Binding) (value.Value, ast.Symbol) {
+	owner := s.ancestor(b.Depth)
+	if owner == nil || b.Slot >= len(owner.Values) {
+		return nil, ""
 	}
+	return owner.Values[b.Slot], owner.Types[b.Slot]
 }
 
-// Get returns the current value for a symbol
-func (s *Scope) Get(symbol string) ast.Expression {
-	if val, ok := s.Variables[symbol]; ok {
-		return val
+// ancestor returns the scope depth levels above s, or nil for a depth which doesn't refer to a scope
+func (s *Scope) ancestor(depth int) *Scope {
+	if depth < 0 {
+		return nil
 	}
-	if s.Parent != nil {
-		return s.Parent.Get(symbol)
+	scope := s
+	for i := 0; i < depth && scope != nil; i++ {
+		scope = scope.Parent
+	}
+	return scope
+}
+
+// store puts a value in a slot of this scope, growing the scope to fit it
+func (s *Scope) store(slot int, dType ast.Symbol, val value.Value) {
+	for len(s.Values) <= slot {
+		s.Values = append(s.Values, nil)
+		s.Types = append(s.Types, "")
+	}
+	s.Values[slot], s.Types[slot] = val, dType
+}
+
+// function returns the function whose frame the scope is in, or nil if the scope is outside of any function
+func (s *Scope) function() *ast.FunctionLiteral {
+	for scope := s; scope != nil; scope = scope.Parent {
+		if scope.Function != nil {
+			return scope.Function
+		}
 	}
 	return nil
 }
 
-// Set creates or updates a value in the scope
-func (s *Scope) Set(symbol string, val ast.Expression) {
-	if s.Variables[symbol] == nil && s.Parent != nil && s.Parent.Get(symbol) != nil {
-		s.Parent.Set(symbol, val)
-		return
+// currentFile returns the state of the file the scope is in, creating it for a scope made by NewScope
+func (s *Scope) currentFile() *file {
+	if s.file == nil && s.Parent != nil {
+		return s.Parent.currentFile()
 	}
-	s.Variables[symbol] = val
+	if s.file == nil {
+		s.file = &file{exports: make(map[string]value.Value), imports: make(map[string]*file)}
+	}
+	return s.file
+}
+
+// builtins returns the scope above the top level of the file the scope is in, which holds the variables every file can use
+func (s *Scope) builtins() *Scope {
+	scope := s
+	for scope.file == nil && scope.Parent != nil {
+		scope = scope.Parent
+	}
+	return scope.Parent
+}
+
+// interrupted returns true if a return, break, or continue has stopped the statements in the scope
+func (s *Scope) interrupted() bool {
+	return s.Signal != NoSignal
+}
+
+// propagate passes a return value or signal up to the given scope
+func (s *Scope) propagate(to *Scope) {
+	to.ReturnValue = s.ReturnValue
+	to.Signal = s.Signal
 }
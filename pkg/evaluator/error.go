@@ -0,0 +1,86 @@
+package evaluator
+
+import (
+	"fmt"
+
+	"github.com/mcjcloud/taurine/pkg/ast"
+	"github.com/mcjcloud/taurine/pkg/value"
+)
+
+// RuntimeError represents an error during evaluation
+type RuntimeError struct {
+	Message string
+	Ref     *ast.SourceRef // where in the source code the error occurred
+	Stack   []*CallFrame   // the calls that led to the error, starting with the innermost
+	Value   *value.Err     // the error passed to throw, or nil if the error was raised by the evaluator
+	Err     error          // the error which was given a location, if it wasn't thrown
+}
+
+// CallFrame records a function call or import that was being evaluated when an error occurred
+type CallFrame struct {
+	Name   string         // the name of the function or the path of the imported file
+	Import bool           // true if the frame is for an import rather than a function call
+	Ref    *ast.SourceRef // where in the source code the call or import was made
+}
+
+func (e *RuntimeError) Error() string {
+	return fmt.Sprintf("%s:%d:%d: %s", e.Ref.FilePath, e.Ref.Position.Row, e.Ref.Position.Col, e.Message)
+}
+
+// Unwrap returns the error which was given a location, so errors.As can find e.g. a StepLimitError
+func (e *RuntimeError) Unwrap() error {
+	return e.Err
+}
+
+// withRef converts err to a RuntimeError located at node
+// errors which are already RuntimeErrors, and nodes which weren't parsed from source code, are left alone
+// so the innermost node with a location is the one that gets reported
+func withRef(err error, node ast.Node) error {
+	if err == nil {
+		return nil
+	}
+	if _, ok := err.(*RuntimeError); ok {
+		return err
+	}
+	referable, ok := node.(ast.Referable)
+	if !ok || referable.Ref().FilePath == "" {
+		return err
+	}
+	return &RuntimeError{
+		Message: err.Error(),
+		Ref:     referable.Ref(),
+		Err:     err,
+	}
+}
+
+// Throw returns the error raised by throwing val at ref, where a string is thrown as an error with that message
+func Throw(val value.Value, ref *ast.SourceRef) error {
+	var errVal *value.Err
+	if e, ok := val.(*value.Err); ok {
+		errVal = e
+	} else if str, ok := val.(*value.Str); ok {
+		errVal = &value.Err{Message: str.Value}
+	} else {
+		return fmt.Errorf("cannot throw %s", val.Type())
+	}
+	return &RuntimeError{Message: errVal.Message, Ref: ref, Value: errVal}
+}
+
+// ErrorValue returns the err value that catching err stores in the catch block's variable
+func ErrorValue(err error) *value.Err {
+	if rtErr, ok := err.(*RuntimeError); ok {
+		if rtErr.Value != nil {
+			return rtErr.Value
+		}
+		return &value.Err{Message: rtErr.Message}
+	}
+	return &value.Err{Message: err.Error()}
+}
+
+// WithFrame adds a call frame to err if it is a RuntimeError
+func WithFrame(err error, frame *CallFrame) error {
+	if rtErr, ok := err.(*RuntimeError); ok {
+		rtErr.Stack = append(rtErr.Stack, frame)
+	}
+	return err
+}
@@ -0,0 +1,370 @@
+package evaluator
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/mcjcloud/taurine/pkg/parser"
+)
+
+// evaluateSource parses and evaluates src, failing the test if there are parse errors
+func evaluateSource(t *testing.T, src string) error {
+	t.Helper()
+	return evaluateWithOptions(t, src, Options{})
+}
+
+// evaluateWithOptions parses and evaluates src with the given options, failing the test if there are parse errors
+func evaluateWithOptions(t *testing.T, src string, opts Options) error {
+	t.Helper()
+	ctx, err := parser.NewParseContextFromSource("/src/main.tc", src)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	tree := parser.Parse(ctx)
+	ctx.PopImportWithTree(tree)
+	if ctx.HasErrors() {
+		t.Fatal("unexpected parse errors")
+	}
+	return Evaluate(tree, ctx.ImportGraph, opts)
+}
+
+func TestRuntimeErrorStack(t *testing.T) {
+	src := `func (num) inner(num a) {
+  return a * "b";
+}
+func (num) outer(num a) {
+  return inner(a);
+}
+etch outer(1);
+`
+	err := evaluateSource(t, src)
+	rtErr, ok := err.(*RuntimeError)
+	if !ok {
+		t.Fatalf("expected RuntimeError but found %v", err)
+	}
+	if rtErr.Ref.FilePath != "/src/main.tc" || rtErr.Ref.Position.Row != 2 || rtErr.Ref.Position.Col != 12 {
+		t.Errorf("expected error at /src/main.tc:2:12 but found %s", rtErr)
+	}
+
+	expected := []struct {
+		name string
+		row  int
+		col  int
+	}{
+		{"inner", 5, 15},
+		{"outer", 7, 11},
+	}
+	if len(rtErr.Stack) != len(expected) {
+		t.Fatalf("expected %d frames but found %d", len(expected), len(rtErr.Stack))
+	}
+	for i, e := range expected {
+		frame := rtErr.Stack[i]
+		if frame.Name != e.name || frame.Ref.Position.Row != e.row || frame.Ref.Position.Col != e.col {
+			t.Errorf("expected frame %d to be %s at %d:%d but found %s at %d:%d",
+				i, e.name, e.row, e.col, frame.Name, frame.Ref.Position.Row, frame.Ref.Position.Col)
+		}
+	}
+}
+
+func TestDeclaredTypes(t *testing.T) {
+	tests := []struct {
+		src string
+		msg string
+	}{
+		{"func (str) f() { return \"a\"; }\nvar (num) x = f();", "cannot assign str to 'x' of type num"},
+		{"var (int) x = 1;\nx = 1.5;", "cannot assign num to 'x' of type int"},
+		{"var (int) x = 1;\nx += 0.5;", "cannot assign num to 'x' of type int"},
+		{"var (obj) o = {a: 1};\no = [1];", "cannot assign arr to 'o' of type obj"},
+		{"func (int) f(int a) { return a; }\nf(\"a\");", "cannot assign str to 'a' of type int"},
+		{"func (int) f() { return 1; }\nf = 2;", "cannot assign int to 'f' of type func"},
+	}
+	for _, test := range tests {
+		err := evaluateSource(t, test.src)
+		rtErr, ok := err.(*RuntimeError)
+		if !ok {
+			t.Errorf("expected RuntimeError for %q but found %v", test.src, err)
+			continue
+		}
+		if rtErr.Message != test.msg {
+			t.Errorf("expected %q for %q but found %q", test.msg, test.src, rtErr.Message)
+		}
+	}
+}
+
+func TestReturnTypes(t *testing.T) {
+	tests := []struct {
+		src string
+		msg string
+		row int
+	}{
+		{"func (num) f() {\n  return \"a\";\n}\nf();", "cannot return str from function of type num", 2},
+		{"func (int) f(bool b) {\n  if b {\n    return 1;\n  }\n}\nf(false);", "function of type int ended without returning a value", 1},
+		{"func (int) f() {\n  while true {\n    return 1.5;\n  }\n}\nf();", "cannot return num from function of type int", 3},
+		{"func (num) half(int n) {\n  return n / 2;\n}\nfunc (int) f() {\n  return half(3);\n}\nf();", "cannot return num from function of type int", 5},
+	}
+	for _, test := range tests {
+		err := evaluateSource(t, test.src)
+		rtErr, ok := err.(*RuntimeError)
+		if !ok {
+			t.Errorf("expected RuntimeError for %q but found %v", test.src, err)
+			continue
+		}
+		if rtErr.Message != test.msg || rtErr.Ref.Position.Row != test.row {
+			t.Errorf("expected %q on row %d for %q but found %q on row %d", test.msg, test.row, test.src, rtErr.Message, rtErr.Ref.Position.Row)
+		}
+	}
+}
+
+func TestAssignmentErrors(t *testing.T) {
+	tests := []struct {
+		src string
+		msg string
+	}{
+		{"var (arr) a = [1];\na@1 = 2;", "index 1 out of range"},
+		{"var (str) s = \"abc\";\ns@0 = \"x\";", "cannot assign to an element of str"},
+		{"var (int) n = 1;\nn.x = 2;", "cannot assign to a property of int"},
+		{"var (obj) o = {a: 1};\no.b += 1;", "property 'b' does not exist"},
+		{"var (arr) a = [\"x\"];\na@0 -= 1;", "'-' operator only applies to type num"},
+		{"var (arr) a = [1];\na@\"0\" = 2;", "'@' operator must be in form arr@integer"},
+	}
+	for _, test := range tests {
+		err := evaluateSource(t, test.src)
+		rtErr, ok := err.(*RuntimeError)
+		if !ok {
+			t.Errorf("expected RuntimeError for %q but found %v", test.src, err)
+			continue
+		}
+		if rtErr.Message != test.msg {
+			t.Errorf("expected %q for %q but found %q", test.msg, test.src, rtErr.Message)
+		}
+	}
+}
+
+func TestNilErrors(t *testing.T) {
+	tests := []struct {
+		src string
+		msg string
+	}{
+		{"var (str) s;\netch s;", "'s' of type str is nil"},
+		{"var (int) x = 1;\nx = nil;", "cannot assign nil to 'x' of type int"},
+		{"func (int) f(int a) { return a; }\nf(nil);", "cannot assign nil to 'a' of type int"},
+		{"func (str) f(obj o) { return o.name; }\nf({id: 1});", "cannot return nil from function of type str"},
+		{"var (int?) x;\netch x + 1;", "'+' operator is not applicable to arguments nil and int"},
+	}
+	for _, test := range tests {
+		err := evaluateSource(t, test.src)
+		rtErr, ok := err.(*RuntimeError)
+		if !ok {
+			t.Errorf("expected RuntimeError for %q but found %v", test.src, err)
+			continue
+		}
+		if rtErr.Message != test.msg {
+			t.Errorf("expected %q for %q but found %q", test.msg, test.src, rtErr.Message)
+		}
+	}
+}
+
+func TestThrownErrors(t *testing.T) {
+	src := `func (int) check(int n) {
+  if n < 0 {
+    throw err("negative");
+  }
+  return n;
+}
+try {
+  check(-1);
+} finally {
+  etch "done";
+}
+`
+	err := evaluateSource(t, src)
+	rtErr, ok := err.(*RuntimeError)
+	if !ok {
+		t.Fatalf("expected RuntimeError but found %v", err)
+	}
+	if rtErr.Value == nil || rtErr.Value.Message != "negative" || rtErr.Message != "negative" {
+		t.Errorf("expected thrown error with message \"negative\" but found %v", rtErr.Value)
+	}
+	if rtErr.Ref.Position.Row != 3 {
+		t.Errorf("expected error on row 3 but found row %d", rtErr.Ref.Position.Row)
+	}
+	if len(rtErr.Stack) != 1 || rtErr.Stack[0].Name != "check" || rtErr.Stack[0].Ref.Position.Row != 8 {
+		t.Errorf("expected a single frame for check on row 8 but found %v", rtErr.Stack)
+	}
+
+	// only strings and err values can be thrown
+	err = evaluateSource(t, "throw 1;")
+	if rtErr, ok := err.(*RuntimeError); !ok || rtErr.Message != "cannot throw int" || rtErr.Value != nil {
+		t.Errorf("expected \"cannot throw int\" but found %v", err)
+	}
+
+	// errors raised by the evaluator are caught with their message
+	err = evaluateSource(t, "try {\n  etch [1]@2;\n} catch (err e) {\n  throw \"wrapped: \" + e.message;\n}")
+	if rtErr, ok := err.(*RuntimeError); !ok || rtErr.Message != "wrapped: index 2 out of range" {
+		t.Errorf("expected \"wrapped: index 2 out of range\" but found %v", err)
+	}
+}
+
+func TestRecordErrors(t *testing.T) {
+	tests := []struct {
+		src string
+		msg string
+	}{
+		{"type Point { num x, num y }\nPoint(1, \"a\");", "cannot assign str to field 'y' of type num"},
+		{"type Point { num x, num y }\nPoint(1);", "missing argument for 'y' in call to 'Point'"},
+		{"type Point { num x, num y }\nvar (Point) p = Point(1, 2);\np.x = nil;", "cannot assign nil to field 'x' of type num"},
+		{"type Point { num x, num y }\nvar (Point) p = Point(1, 2);\np.z = 1;", "Point has no field 'z'"},
+		{"type Point { num x, num y }\netch Point(1, 2).z;", "Point has no field 'z'"},
+		{"type Point { num x, num y }\nPoint(1, 2).norm();", "Point has no method 'norm'"},
+		{"type Point { num x, num y }\nvar (obj) o = {x: 1, y: 2};\nvar (Point) p = o;", "cannot assign obj to 'p' of type Point"},
+		{"type Point { num x }\nfunc (void) Point.f(Point p) {}\nfunc (void) Point.f(Point p) {}", "method 'f' is already declared on Point"},
+	}
+	for _, test := range tests {
+		err := evaluateSource(t, test.src)
+		rtErr, ok := err.(*RuntimeError)
+		if !ok {
+			t.Errorf("expected RuntimeError for %q but found %v", test.src, err)
+			continue
+		}
+		if rtErr.Message != test.msg {
+			t.Errorf("expected %q for %q but found %q", test.msg, test.src, rtErr.Message)
+		}
+	}
+}
+
+func TestDestructuringErrors(t *testing.T) {
+	tests := []struct {
+		src string
+		msg string
+	}{
+		{"var [num a, num b] = [1];", "expected an array of 2 elements but found 1"},
+		{"var [num a, arr rest...] = [];", "expected an array of at least 1 elements but found 0"},
+		{"var (obj) o = {a: 1};\nvar [num a] = o;", "cannot destructure obj as an array"},
+		{"var (arr) a = [1];\nvar {num x} = a;", "cannot destructure arr as an object"},
+		{"var (obj) o = {a: 1};\nvar {num b} = o;", "cannot destructure missing property 'b' of obj"},
+		{"var [str s] = [1];", "cannot assign int to 's' of type str"},
+		{"for [k, v] in [[1, 2], 3] {}", "cannot destructure int as an array"},
+	}
+	for _, test := range tests {
+		err := evaluateSource(t, test.src)
+		rtErr, ok := err.(*RuntimeError)
+		if !ok {
+			t.Errorf("expected RuntimeError for %q but found %v", test.src, err)
+			continue
+		}
+		if rtErr.Message != test.msg {
+			t.Errorf("expected %q for %q but found %q", test.msg, test.src, rtErr.Message)
+		}
+	}
+}
+
+func TestParameterErrors(t *testing.T) {
+	tests := []struct {
+		src string
+		msg string
+	}{
+		{"func (int) f(int a, int b = 1) { return a + b; }\nf(b: 2);", "missing argument for 'a' in call to 'f'"},
+		{"func (int) f(int a, int b = 1) { return a + b; }\nf(1, 2, 3);", "expected at most '2' arguments but got '3' for call to 'f'"},
+		{"func (int) f(int a) { return a; }\nf(b: 2);", "unknown argument name 'b' in call to 'f'"},
+		{"func (int) f(int a) { return a; }\nf(1, a: 2);", "'a' was passed more than once in call to 'f'"},
+		{"func (int) f(int nums...) { return 0; }\nf(nums: [1]);", "cannot pass variadic parameter 'nums' by name in call to 'f'"},
+		{"func (int) f(int nums...) { return 0; }\nf(1, \"a\");", "cannot assign str to 'nums' of type int"},
+		{"func (int) f(int a = \"a\") { return a; }\nf();", "cannot assign str to 'a' of type int"},
+		{"type Point { num x, num y }\nPoint(x: 1, z: 2);", "unknown argument name 'z' in call to 'Point'"},
+		{"etch len(s: \"a\");", "cannot pass 's' by name, since only functions and types have named parameters"},
+	}
+	for _, test := range tests {
+		err := evaluateSource(t, test.src)
+		rtErr, ok := err.(*RuntimeError)
+		if !ok {
+			t.Errorf("expected RuntimeError for %q but found %v", test.src, err)
+			continue
+		}
+		if rtErr.Message != test.msg {
+			t.Errorf("expected %q for %q but found %q", test.msg, test.src, rtErr.Message)
+		}
+	}
+}
+
+func TestStackOverflow(t *testing.T) {
+	src := `func (int) deep(int n) {
+  return 1 + deep(n + 1);
+}
+deep(0);
+`
+	err := evaluateSource(t, src)
+	rtErr, ok := err.(*RuntimeError)
+	if !ok {
+		t.Fatalf("expected RuntimeError but found %v", err)
+	}
+	if rtErr.Message != "stack overflow in call to 'deep'" || rtErr.Ref.Position.Row != 2 {
+		t.Errorf("expected stack overflow on row 2 but found %q on row %d", rtErr.Message, rtErr.Ref.Position.Row)
+	}
+	if len(rtErr.Stack) != DefaultMaxDepth {
+		t.Errorf("expected %d frames but found %d", DefaultMaxDepth, len(rtErr.Stack))
+	}
+
+	// tail calls don't add to the depth of the stack, and a stack overflow can be caught
+	tests := []string{
+		"func (int) count(int n, int total) {\n  if n == 0 {\n    return total;\n  }\n  return count(n - 1, total + 1);\n}\ncount(100000, 0);",
+		"func (bool) even(int n) {\n  if n == 0 {\n    return true;\n  }\n  return odd(n - 1);\n}\nfunc (bool) odd(int n) {\n  if n == 0 {\n    return false;\n  }\n  return even(n - 1);\n}\neven(100001);",
+		"func (int) deep(int n) {\n  return 1 + deep(n + 1);\n}\ntry {\n  deep(0);\n} catch (err e) {}",
+	}
+	for _, src := range tests {
+		if err := evaluateSource(t, src); err != nil {
+			t.Errorf("unexpected error for %q: %s", src, err)
+		}
+	}
+}
+
+func TestLimits(t *testing.T) {
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+	deadline, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	loop := "var (int) i = 0;\nwhile true {\n  i += 1;\n}"
+	tests := []struct {
+		src    string
+		opts   Options
+		target interface{}
+	}{
+		{loop, Options{MaxSteps: 1000}, new(*StepLimitError)},
+		{loop, Options{Context: canceled}, new(*CanceledError)},
+		{loop, Options{Context: deadline}, new(*CanceledError)},
+		{"var (arr) a = [];\nwhile true {\n  a.push(1);\n}", Options{MaxSize: 100}, new(*SizeLimitError)},
+		{"var (str) s = \"a\";\nwhile true {\n  s = s + s;\n}", Options{MaxSize: 100}, new(*SizeLimitError)},
+		{"etch 0..1000000000;", Options{MaxSize: 100}, new(*SizeLimitError)},
+		{"var (arr) a = [];\nfor i in 0..50 {\n  a.push(\"abc\");\n}\netch a.join(\"\");", Options{MaxSize: 100}, new(*SizeLimitError)},
+		{"while true {\n  etch \"hello\";\n}", Options{MaxOutput: 100}, new(*OutputLimitError)},
+		// the limits stop the program, even inside of a try statement
+		{"try {\n  " + loop + "\n} catch (err e) {}", Options{MaxSteps: 1000}, new(*StepLimitError)},
+		{"try {\n  " + loop + "\n} finally {\n  etch \"finally\";\n}", Options{MaxSteps: 1000}, new(*StepLimitError)},
+	}
+	for _, test := range tests {
+		err := evaluateWithOptions(t, test.src, test.opts)
+		if !errors.As(err, test.target) {
+			t.Errorf("expected %T for %q but found %v", test.target, test.src, err)
+		}
+	}
+
+	// a deadline can be compared with the error of the context
+	if err := evaluateWithOptions(t, loop, Options{Context: deadline}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected %v but found %v", context.DeadlineExceeded, err)
+	}
+	if err := evaluateWithOptions(t, "etch 1..10;", Options{MaxSteps: 1000, MaxSize: 100, MaxOutput: 100}); err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+
+	// a program waiting for input stops once its deadline passes
+	input, _ := io.Pipe()
+	waiting, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	src := "var (str?) s;\nread s, \"name: \";\netch s;"
+	if err := evaluateWithOptions(t, src, Options{Context: waiting, Stdin: input, Stdout: io.Discard}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected %v but found %v", context.DeadlineExceeded, err)
+	}
+}
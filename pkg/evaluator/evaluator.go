@@ -2,76 +2,68 @@ package evaluator
 
 import (
 	"errors"
-	"fmt"
 
 	"github.com/mcjcloud/taurine/pkg/ast"
 	"github.com/mcjcloud/taurine/pkg/util"
+	"github.com/mcjcloud/taurine/pkg/value"
 )
 
+// file is the state of a file whose top level statements are being evaluated
+type file struct {
+	exports map[string]value.Value // the values the file has exported so far
+	imports map[string]*file       // every file that has been imported, by path; shared by all of the files in a program
+}
+
 // Evaluate evaluates the code and does stuff
-func Evaluate(tree *ast.Ast, importGraph *util.ImportGraph) error {
+func Evaluate(tree *ast.Ast, importGraph *util.ImportGraph, opts Options) error {
+	_, err := evaluateFile(tree, importGraph, make(map[string]*file), NewRuntime(opts), nil)
+	return err
+}
+
+// EvaluateIn evaluates the code with builtins as the parent of the top level scope of each file, and returns the values the main file exported
+// builtins holds the variables declared by ParseContext.Declare, in the slots it gave them
+func EvaluateIn(tree *ast.Ast, importGraph *util.ImportGraph, builtins *Scope) (map[string]value.Value, error) {
+	main, err := evaluateFile(tree, importGraph, make(map[string]*file), builtins.runtime, builtins)
+	if err != nil {
+		return nil, err
+	}
+	return main.exports, nil
+}
+
+// evaluateFile evaluates the statements of tree in a new scope, and returns the state of the file once it's done
+func evaluateFile(tree *ast.Ast, importGraph *util.ImportGraph, imports map[string]*file, runtime *Runtime, builtins *Scope) (*file, error) {
 	// check that the ast has a blockstatement
 	var block *ast.BlockStatement
 	if b, ok := tree.Statement.(*ast.BlockStatement); !ok {
-		return errors.New("ast must contain block statement")
+		return nil, errors.New("ast must contain block statement")
 	} else {
 		block = b
 	}
 
 	// execute block statements
-	scope := NewScope()
+	scope := &Scope{Parent: builtins, runtime: runtime}
+	scope.file = &file{exports: make(map[string]value.Value), imports: imports}
 	for _, stmt := range block.Statements {
-		if importStmt, ok := stmt.(*ast.ImportStatement); ok {
-			if err := executeImportStatement(importStmt, scope, tree, importGraph); err != nil {
-				return err
-			}
-		} else if exportStmt, ok := stmt.(*ast.ExportStatement); ok {
-			if err := executeExportStatement(exportStmt, scope, tree); err != nil {
-				return err
-			}
-		} else {
-			// do the statement
-			err := executeStatement(stmt, scope)
-			if err != nil {
-				return err
-			}
+		if _, err := EvaluateStatement(stmt, scope, tree, importGraph); err != nil {
+			return nil, err
 		}
 	}
-	return nil
+	return scope.file, nil
 }
 
-func conformDataType(dType ast.Symbol, exp ast.Expression) (ast.Expression, error) {
-	switch dType {
-	case ast.NUM:
-		if _, nok := exp.(*ast.NumberLiteral); nok {
-			return exp, nil
-		}
-		if iExp, iok := exp.(*ast.IntegerLiteral); iok {
-			return &ast.NumberLiteral{Value: float64(iExp.Value.Int64())}, nil
-		}
-	case ast.INT:
-		if _, iok := exp.(*ast.IntegerLiteral); iok {
-			return exp, nil
-		}
-	case ast.STR:
-		if _, sok := exp.(*ast.StringLiteral); sok {
-			return exp, nil
-		}
-	case ast.BOOL:
-		if _, iok := exp.(*ast.BooleanLiteral); iok {
-			return exp, nil
-		}
-	case ast.ARR:
-		if _, iok := exp.(*ast.ArrayExpression); iok {
-			return exp, nil
-		}
-	case ast.FUNC:
-		if _, fok := exp.(*ast.FunctionLiteral); fok {
-			return exp, nil
-		}
-		if _, fok := exp.(*ScopedFunction); fok {
-			return exp, nil
-		}
+// EvaluateStatement executes a top level statement of tree in the given scope
+// if the statement is an expression statement, the value of the expression is returned
+func EvaluateStatement(stmt ast.Statement, scope *Scope, tree *ast.Ast, importGraph *util.ImportGraph) (val value.Value, err error) {
+	defer func() { err = withRef(err, stmt) }()
+
+	switch t := stmt.(type) {
+	case *ast.ImportStatement:
+		return nil, executeImportStatement(t, scope, tree, importGraph)
+	case *ast.ExportStatement:
+		return nil, executeExportStatement(t, scope)
+	case *ast.ExpressionStatement:
+		return evaluateExpression(t.Expression, scope)
+	default:
+		return nil, executeStatement(stmt, scope)
 	}
-	return nil, fmt.Errorf("%s is not of type %s", exp, dType)
 }
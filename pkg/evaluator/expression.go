@@ -5,16 +5,32 @@ import (
 	"fmt"
 
 	"github.com/mcjcloud/taurine/pkg/ast"
+	"github.com/mcjcloud/taurine/pkg/value"
 )
 
-func evaluateExpression(exp ast.Expression, scope *Scope) (ast.Expression, error) {
+func evaluateExpression(exp ast.Expression, scope *Scope) (val value.Value, err error) {
+	// errors are reported at the innermost expression that was parsed from source code
+	defer func() {
+		if err == nil {
+			err = scope.runtime.CheckSize(val)
+		}
+		err = withRef(err, exp)
+	}()
+	if err := scope.runtime.Step(); err != nil {
+		return nil, err
+	}
+
 	switch t := exp.(type) {
 	case *ast.OperationExpression:
 		return evaluateOperation(t, scope)
+	case *ast.UnaryExpression:
+		return evaluateUnary(t, scope)
 	case *ast.Identifier:
-		return scope.Get(t.Name), nil
+		return evaluateIdentifier(t, scope)
 	case *ast.VariableDecleration:
 		return evaluateVariableDecleration(t, scope)
+	case *ast.DestructuringDeclaration:
+		return evaluateDestructuringDeclaration(t, scope)
 	case *ast.AssignmentExpression:
 		return evaluateAssignmentExpression(t, scope)
 	case *ast.FunctionCall:
@@ -27,54 +43,69 @@ func evaluateExpression(exp ast.Expression, scope *Scope) (ast.Expression, error
 		return evaluateFunctionLiteral(t, scope)
 	case *ast.ObjectLiteral:
 		return evaluateObjectLiteral(t, scope)
+	case *ast.TypeDeclaration:
+		return evaluateTypeDeclaration(t, scope)
+	case *ast.MatchExpression:
+		return evaluateMatchExpression(t, scope)
+	case *ast.InterpolatedString:
+		return evaluateInterpolatedString(t, scope)
+	case *ast.NamedArgument:
+		return nil, fmt.Errorf("cannot pass '%s' by name, since only functions and types have named parameters", t.Name)
+	case *ast.NumberLiteral:
+		return &value.Num{Value: t.Value}, nil
+	case *ast.IntegerLiteral:
+		return &value.Int{Value: t.Value}, nil
+	case *ast.StringLiteral:
+		return &value.Str{Value: t.Value}, nil
+	case *ast.BooleanLiteral:
+		return &value.Bool{Value: t.Value}, nil
+	case *ast.NilLiteral:
+		return &value.Nil{}, nil
 	default:
-		return exp, nil
+		return nil, fmt.Errorf("cannot evaluate %s", exp)
 	}
 }
 
-func evaluateVariableDecleration(decl *ast.VariableDecleration, scope *Scope) (ast.Expression, error) {
-	val, err := evaluateExpression(decl.Value, scope)
-	if err != nil {
-		return nil, err
+// evaluateIdentifier returns the value of a variable
+// a variable whose type isn't optional can only be nil if it was declared without a value, which is an error to use
+func evaluateIdentifier(id *ast.Identifier, scope *Scope) (value.Value, error) {
+	val, dType := scope.lookup(id.Binding)
+	if val == nil {
+		return nil, fmt.Errorf("'%s' was not declared", id.Name)
 	}
-
-	if scope.Variables[decl.Symbol] != nil {
-		return nil, fmt.Errorf("variable '%s' already exists", decl.Symbol)
+	if dType != "" && !dType.IsOptional() {
+		if _, isNil := val.(*value.Nil); isNil {
+			return nil, fmt.Errorf("'%s' of type %s is nil", id.Name, dType)
+		}
 	}
-	scope.Set(decl.Symbol, val)
-
 	return val, nil
 }
 
-func evaluateAssignmentExpression(asn *ast.AssignmentExpression, scope *Scope) (ast.Expression, error) {
-	// make sure the identifier exists
-	if scope.Get(asn.Identifier.Name) == nil {
-		return nil, fmt.Errorf("'%s' was not declared", asn.Identifier.Name)
-	}
-
-	val, err := evaluateExpression(asn.Value, scope)
-	if err != nil {
-		return nil, err
+func evaluateVariableDecleration(decl *ast.VariableDecleration, scope *Scope) (value.Value, error) {
+	var val value.Value
+	if decl.Value != nil {
+		v, err := evaluateExpression(decl.Value, scope)
+		if err != nil {
+			return nil, err
+		}
+		val = v
 	}
-
-	// update the scope and return the evaluated value
-	scope.Set(asn.Identifier.Name, val)
-	return val, nil
+	return scope.Declare(decl.Slot, decl.Symbol, ast.Symbol(decl.SymbolType), val)
 }
 
-func evaluateArrayExpression(arr *ast.ArrayExpression, scope *Scope) (ast.Expression, error) {
-	exp := &ast.ArrayExpression{Expressions: make([]ast.Expression, len(arr.Expressions))}
+func evaluateArrayExpression(arr *ast.ArrayExpression, scope *Scope) (value.Value, error) {
+	exp := &value.Arr{Elements: make([]value.Value, len(arr.Expressions))}
 	for i, el := range arr.Expressions {
 		val, err := evaluateExpression(el, scope)
 		if err != nil {
 			return nil, err
 		}
-		exp.Expressions[i] = val
+		exp.Elements[i] = val
 	}
 	return exp, nil
 }
 
-func evaluateFunctionCall(call *ast.FunctionCall, scope *Scope) (ast.Expression, error) {
+func evaluateFunctionCall(call *ast.FunctionCall, scope *Scope) (value.Value, error) {
 	// TODO: make this cleaner, maybe move built-in functions someplace else
 	if id, ok := call.Function.(*ast.Identifier); ok && id.Name == "len" {
 		if len(call.Arguments) != 1 {
@@ -83,6 +114,11 @@ func evaluateFunctionCall(call *ast.FunctionCall, scope *Scope) (ast.Expression,
 		return builtInLen(call.Arguments[0], scope)
 	} else if ok && id.Name == "int" {
 		return builtInInt(call.Arguments[0], scope)
+	} else if ok && id.Name == "err" {
+		if len(call.Arguments) != 1 {
+			return nil, errors.New("err takes only one argument")
+		}
+		return builtInErr(call.Arguments[0], scope)
 	}
 
 	// must be a non-built-in function
@@ -90,41 +126,223 @@ func evaluateFunctionCall(call *ast.FunctionCall, scope *Scope) (ast.Expression,
 	if err != nil {
 		return nil, err
 	}
+	return callValue(fn, call, nil, scope)
+}
+
+// callValue calls fn with the arguments of call, which are evaluated in scope
+// if a method is being called, receiver is the record it was called on, which is passed before the other arguments
+func callValue(fn value.Value, call *ast.FunctionCall, receiver value.Value, scope *Scope) (value.Value, error) {
+	// calling a type creates a record of that type
+	if recordType, ok := fn.(*value.RecordType); ok {
+		return construct(recordType, call, scope)
+	}
+
+	// a function written in Go is called without a frame, since it has no variables of its own
+	if hostFn, ok := fn.(*HostFunction); ok {
+		args, err := bindArguments(hostFn.Function, call, receiver, scope)
+		if err != nil {
+			return nil, err
+		}
+		return hostFn.call(args)
+	}
 
 	// expect that the expression evaluates to ScopedFunction
 	scopedFn, ok := fn.(*ScopedFunction)
 	if !ok {
 		return nil, errors.New("called expression did not evaluate to function")
 	}
+	args, err := bindArguments(scopedFn.Function, call, receiver, scope)
+	if err != nil {
+		return nil, err
+	}
+	val, err := callFunction(scopedFn, args)
+	if err != nil {
+		return nil, WithFrame(err, &CallFrame{Name: FunctionName(call, scopedFn.Function), Ref: call.Ref()})
+	}
+	return val, nil
+}
 
-	// check that the number of parameters are correct
-	if len(scopedFn.Function.Parameters) != len(call.Arguments) {
-		return nil, fmt.Errorf("expected '%d' arguments but got '%d' for call to '%s'", len(scopedFn.Function.Parameters), len(call.Arguments), call.Function)
+// bindArguments evaluates the arguments of call in scope, and returns them in the order of the parameters of fn
+// if a method is being called, receiver is the record it was called on, which is passed before the other arguments
+func bindArguments(fn *ast.FunctionLiteral, call *ast.FunctionCall, receiver value.Value, scope *Scope) ([]value.Value, error) {
+	// the receiver is passed as the first parameter, and the arguments as the rest
+	params := fn.Parameters
+	args := make([]value.Value, len(params))
+	offset := 0
+	if receiver != nil {
+		args[0] = receiver
+		offset = 1
+	}
+	bound, err := ast.BindArguments(call.Function.String(), params[offset:], call.Arguments)
+	if err != nil {
+		return nil, err
 	}
 
-	// evaluate arguments and populate scope
-	for i, argExp := range call.Arguments {
-		exp, err := evaluateExpression(argExp, scope)
+	// evaluate arguments in the caller's scope
+	vals, err := evaluateArguments(call, scope)
+	if err != nil {
+		return nil, err
+	}
+	for i, indexes := range bound {
+		if params[offset+i].Variadic {
+			rest := &value.Arr{Elements: make([]value.Value, len(indexes))}
+			for j, index := range indexes {
+				rest.Elements[j] = vals[index]
+			}
+			args[offset+i] = rest
+		} else if len(indexes) > 0 {
+			args[offset+i] = vals[indexes[0]]
+		}
+	}
+	return args, nil
+}
+
+// construct creates a record of type t, whose fields are given by the arguments of call
+func construct(t *value.RecordType, call *ast.FunctionCall, scope *Scope) (value.Value, error) {
+	vals, err := evaluateArguments(call, scope)
+	if err != nil {
+		return nil, err
+	}
+	return Construct(t, call, vals)
+}
+
+// Construct creates a record of type t, whose fields are given by vals, the values of the arguments of call, in the order they were declared
+func Construct(t *value.RecordType, call *ast.FunctionCall, vals []value.Value) (value.Value, error) {
+	fields := make([]*ast.VariableDecleration, len(t.Fields))
+	for i, field := range t.Fields {
+		fields[i] = &ast.VariableDecleration{Symbol: field.Name, SymbolType: string(field.Type)}
+	}
+	bound, err := ast.BindArguments(call.Function.String(), fields, call.Arguments)
+	if err != nil {
+		return nil, err
+	}
+	rec := &value.Record{RecordType: t, Fields: make(map[string]value.Value, len(t.Fields))}
+	for i, indexes := range bound {
+		val, err := value.ConformField(t.Fields[i], vals[indexes[0]])
 		if err != nil {
-			return nil, err
+			// the error is located at the argument, if it was parsed from source code
+			return nil, withRef(err, call.Arguments[indexes[0]])
 		}
+		rec.Fields[t.Fields[i].Name] = val
+	}
+	return rec, nil
+}
 
-		dType := ast.Symbol(scopedFn.Function.Parameters[i].SymbolType)
-		arg, err := conformDataType(dType, exp)
+// evaluateArguments evaluates the arguments of call in the order they were passed
+func evaluateArguments(call *ast.FunctionCall, scope *Scope) ([]value.Value, error) {
+	vals := make([]value.Value, len(call.Arguments))
+	for i, arg := range call.Arguments {
+		if named, ok := arg.(*ast.NamedArgument); ok {
+			arg = named.Value
+		}
+		val, err := evaluateExpression(arg, scope)
 		if err != nil {
 			return nil, err
 		}
+		vals[i] = val
+	}
+	return vals, nil
+}
 
-		scopedFn.Scope.Set(scopedFn.Function.Parameters[i].Symbol, arg)
+// FunctionName returns the name a function declared by decl is referred to by in a call stack
+// call is nil if the function wasn't called from source code, e.g. by a method like map
+func FunctionName(call *ast.FunctionCall, decl *ast.FunctionLiteral) string {
+	if decl.Receiver != "" {
+		return decl.Receiver + "." + decl.Symbol
+	}
+	if call != nil {
+		if id, ok := call.Function.(*ast.Identifier); ok {
+			return id.Name
+		}
+	}
+	if decl.Symbol != "" {
+		return decl.Symbol
+	}
+	return "anonymous function"
+}
+
+// callFunction executes a function with already evaluated arguments
+// each call gets a new frame whose parent is the scope the function was defined in,
+// so recursive calls and closures don't overwrite each other's parameters or return values
+// a function which returns a tail call is replaced by the function it calls, without adding to the depth of the stack
+func callFunction(scopedFn *ScopedFunction, args []value.Value) (value.Value, error) {
+	runtime := scopedFn.Scope.runtime
+	if err := runtime.Call(FunctionName(nil, scopedFn.Function)); err != nil {
+		return nil, err
+	}
+	defer runtime.Return()
+
+	val, err := runFunction(scopedFn, args)
+	for err == nil {
+		tc, ok := val.(*tailCall)
+		if !ok {
+			return val, nil
+		}
+		// the frame of the function making the tail call is gone, so the call is the only one left to report
+		if val, err = runFunction(tc.fn, tc.args); err != nil {
+			return nil, WithFrame(err, &CallFrame{Name: FunctionName(tc.call, tc.fn.Function), Ref: tc.call.Ref()})
+		}
+	}
+	return nil, err
+}
+
+// runFunction executes the body of a function in a new frame
+// a parameter whose argument is nil or missing gets its default value, which can use the parameters before it
+func runFunction(scopedFn *ScopedFunction, args []value.Value) (value.Value, error) {
+	frame := NewScopeWithParent(scopedFn.Scope)
+	frame.Function = scopedFn.Function
+	for i, param := range scopedFn.Function.Parameters {
+		var arg value.Value
+		if i < len(args) {
+			arg = args[i]
+		}
+		if arg == nil && param.Variadic {
+			arg = &value.Arr{Elements: make([]value.Value, 0)}
+		} else if arg == nil && param.Value != nil {
+			val, err := evaluateExpression(param.Value, frame)
+			if err != nil {
+				return nil, err
+			}
+			arg = val
+		} else if arg == nil {
+			continue
+		}
+		if err := declareParameter(frame, param, arg); err != nil {
+			return nil, err
+		}
 	}
+
 	// execute statements
-	if err := executeStatement(scopedFn.Function.Body, scopedFn.Scope); err != nil {
+	if err := executeStatement(scopedFn.Function.Body, frame); err != nil {
 		return nil, err
 	}
-	return scopedFn.Scope.ReturnValue, nil
+
+	// only void functions can end without returning
+	if frame.Signal != ReturnSignal && scopedFn.Function.ReturnType != ast.VOID {
+		err := fmt.Errorf("function of type %s ended without returning a value", scopedFn.Function.ReturnType)
+		return nil, withRef(err, scopedFn.Function)
+	}
+	if frame.ReturnValue == nil {
+		return &value.Nil{}, nil
+	}
+	return frame.ReturnValue, nil
 }
 
-func evaluateFunctionLiteral(fnVal *ast.FunctionLiteral, scope *Scope) (ast.Expression, error) {
+// declareParameter declares param in frame with the value of its argument
+func declareParameter(frame *Scope, param *ast.VariableDecleration, arg value.Value) error {
+	dType := ast.Symbol(param.SymbolType)
+	if param.Variadic {
+		rest, err := value.ConformParameter(param, arg)
+		if err != nil {
+			return err
+		}
+		arg, dType = rest, ast.ARR
+	}
+	_, err := frame.Declare(param.Slot, param.Symbol, dType, arg)
+	return err
+}
+
+func evaluateFunctionLiteral(fnVal *ast.FunctionLiteral, scope *Scope) (value.Value, error) {
 	// if evaluating a FunctionLiteral, wrap it in the current scope
 	// this allows that scope to be accessed during execution
 	sf := &ScopedFunction{
@@ -132,23 +350,51 @@ func evaluateFunctionLiteral(fnVal *ast.FunctionLiteral, scope *Scope) (ast.Expr
 		Function: fnVal,
 	}
 
+	// a method is stored in the type it belongs to rather than in scope
+	if fnVal.Receiver != "" {
+		return sf, value.DeclareMethod(scope.Get(fnVal.ReceiverType), fnVal, sf)
+	}
+
 	// if there is a symbol name, store the function in scope
 	// TODO: eventually I should distinguish between functinos and anon functions..
 	// right now, you could name a variable function and it could be stored twice
 	if fnVal.Symbol != "" {
-		scope.Set(fnVal.Symbol, sf)
+		if _, err := scope.Declare(fnVal.Slot, fnVal.Symbol, ast.FUNC, sf); err != nil {
+			return nil, err
+		}
 	}
 	return sf, nil
 }
 
-func evaluateObjectLiteral(objExp *ast.ObjectLiteral, scope *Scope) (ast.Expression, error) {
-	// if evaluating an object literal, evaluate each of it's properties
+func evaluateTypeDeclaration(decl *ast.TypeDeclaration, scope *Scope) (value.Value, error) {
+	fields := make([]value.Field, len(decl.Fields))
+	for i, f := range decl.Fields {
+		fields[i] = value.Field{Name: f.Symbol, Type: ast.Symbol(f.SymbolType)}
+	}
+	return scope.Declare(decl.Slot, decl.Symbol, ast.TYPE, value.NewRecordType(decl.Symbol, fields))
+}
+
+func evaluateObjectLiteral(objExp *ast.ObjectLiteral, scope *Scope) (value.Value, error) {
+	// each evaluation creates a new object
+	obj := &value.Obj{Properties: make(map[string]value.Value, len(objExp.Value))}
 	for k, v := range objExp.Value {
 		newExp, err := evaluateExpression(v, scope)
 		if err != nil {
 			return nil, err
 		}
-		objExp.Value[k] = newExp
+		obj.Properties[k] = newExp
+	}
+	return obj, nil
+}
+
+func evaluateInterpolatedString(str *ast.InterpolatedString, scope *Scope) (value.Value, error) {
+	var val string
+	for _, part := range str.Parts {
+		exp, err := evaluateExpression(part, scope)
+		if err != nil {
+			return nil, err
+		}
+		val += value.Stringify(exp)
 	}
-	return objExp, nil
+	return &value.Str{Value: val}, nil
 }
@@ -0,0 +1,77 @@
+package evaluator
+
+import (
+	"fmt"
+
+	"github.com/mcjcloud/taurine/pkg/ast"
+	"github.com/mcjcloud/taurine/pkg/value"
+)
+
+// HostFunction is a function written in Go, which is called like any other function with the signature of Function
+// its arguments are converted to the types of the parameters before Go is called, and its result to the return type
+type HostFunction struct {
+	Function *ast.FunctionLiteral
+	Go       func(args []value.Value) (value.Value, error)
+}
+
+func (h *HostFunction) Type() ast.Symbol                  { return ast.FUNC }
+func (h *HostFunction) Name() string                      { return h.Function.Symbol }
+func (h *HostFunction) Declaration() *ast.FunctionLiteral { return h.Function }
+func (h *HostFunction) String() string {
+	return fmt.Sprintf("func (%s) %s", h.Function.ReturnType, h.Function.Symbol)
+}
+
+// call calls the Go function with arguments given in the order of the parameters
+// a missing argument is nil, or an empty arr for a variadic parameter
+func (h *HostFunction) call(args []value.Value) (value.Value, error) {
+	conformed := make([]value.Value, len(h.Function.Parameters))
+	for i, param := range h.Function.Parameters {
+		var arg value.Value
+		if i < len(args) {
+			arg = args[i]
+		}
+		if arg == nil && param.Variadic {
+			arg = &value.Arr{Elements: make([]value.Value, 0)}
+		} else if arg == nil {
+			conformed[i] = &value.Nil{}
+			continue
+		}
+		val, err := value.ConformParameter(param, arg)
+		if err != nil {
+			return nil, err
+		}
+		conformed[i] = val
+	}
+
+	val, err := h.Go(conformed)
+	if err != nil {
+		return nil, err
+	}
+	if h.Function.ReturnType == ast.VOID || val == nil {
+		return &value.Nil{}, nil
+	}
+	return value.ConformReturn(h.Function, val)
+}
+
+// Call calls fn, a function value, with arguments given in the order of its parameters
+// the arguments after the last parameter that isn't variadic are the elements of the variadic one
+func Call(fn value.Value, args []value.Value) (value.Value, error) {
+	f, ok := fn.(value.Func)
+	if !ok {
+		return nil, fmt.Errorf("cannot call %s", fn.Type())
+	}
+	decl := f.Declaration()
+	params := decl.Parameters
+	if n := len(params); n > 0 && params[n-1].Variadic && len(args) >= n-1 {
+		rest := &value.Arr{Elements: append([]value.Value{}, args[n-1:]...)}
+		args = append(args[:n-1:n-1], rest)
+	} else if len(args) > len(params) {
+		return nil, fmt.Errorf("expected '%d' arguments but got '%d' for call to '%s'", len(params), len(args), f.Name())
+	}
+	for i, param := range params {
+		if i >= len(args) && param.Value == nil && !param.Variadic {
+			return nil, fmt.Errorf("missing argument for '%s' in call to '%s'", param.Symbol, f.Name())
+		}
+	}
+	return callInternal(f, args)
+}
@@ -4,14 +4,46 @@ import (
 	"fmt"
 
 	"github.com/mcjcloud/taurine/pkg/ast"
+	"github.com/mcjcloud/taurine/pkg/value"
 )
 
 // attempts to evaluate an internal function or property (prop) on some type (obj)
-func evaluateIntern(obj, prop ast.Expression, scope *Scope) (ast.Expression, error) {
-	if strObj, ok := obj.(*ast.StringLiteral); ok {
-		return evaluateInternStr(strObj, prop, scope)
-	} else if arrObj, ok := obj.(*ast.ArrayExpression); ok {
-		return evaluateInternArr(arrObj, prop, scope)
+func evaluateIntern(obj value.Value, prop ast.Expression, scope *Scope) (value.Value, error) {
+	if id, ok := prop.(*ast.Identifier); ok {
+		return value.Member(obj, id.Name)
+	} else if fn, ok := prop.(*ast.FunctionCall); ok {
+		// the function should be an identifier
+		if id, ok := fn.Function.(*ast.Identifier); ok {
+			args := make([]value.Value, len(fn.Arguments))
+			for i, arg := range fn.Arguments {
+				val, err := evaluateExpression(arg, scope)
+				if err != nil {
+					return nil, err
+				}
+				args[i] = val
+			}
+			if err := scope.runtime.CheckMember(obj, id.Name, args); err != nil {
+				return nil, err
+			}
+			val, err := value.CallMember(obj, id.Name, args, callInternal)
+			if err != nil {
+				return nil, err
+			}
+			// a method like push grows the arr it's called on
+			return val, scope.runtime.CheckSize(obj)
+		}
 	}
-	return nil, fmt.Errorf("'.' cannot be applied to %v", obj)
+	return nil, fmt.Errorf("error resolving property '%s'", prop)
+}
+
+// callInternal calls a function passed to a method like map, with arguments given in the order of its parameters
+func callInternal(fn value.Func, args []value.Value) (value.Value, error) {
+	if hostFn, ok := fn.(*HostFunction); ok {
+		return hostFn.call(args)
+	}
+	scopedFn, ok := fn.(*ScopedFunction)
+	if !ok {
+		return nil, fmt.Errorf("cannot call %s", fn.Type())
+	}
+	return callFunction(scopedFn, args)
 }
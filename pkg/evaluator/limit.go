@@ -0,0 +1,99 @@
+package evaluator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/mcjcloud/taurine/pkg/ast"
+)
+
+// DefaultMaxDepth is the number of calls that can be in progress at once when Options doesn't set a limit
+const DefaultMaxDepth = 10000
+
+// MaxDepthLimit is the largest number of calls that can be in progress at once, which a larger MaxDepth is lowered to
+// each call uses the Go stack, and running out of it crashes the process instead of raising an error that can be caught
+const MaxDepthLimit = 50000
+
+// Options controls how a program is run
+// a limit of 0 means there's no limit, except for MaxDepth
+type Options struct {
+	Stdin     io.Reader       // where read gets its input, or os.Stdin if it's nil
+	Stdout    io.Writer       // where etch and the prompts of read are written, or os.Stdout if it's nil
+	MaxDepth  int             // the number of calls that can be in progress at once before a stack overflow error, or DefaultMaxDepth if it's 0, up to MaxDepthLimit
+	MaxSteps  int             // the number of steps the program can take, where a step is a statement or expression evaluated, or an instruction run by the virtual machine
+	Context   context.Context // stops the program once it's canceled or its deadline passes
+	MaxSize   int             // the number of elements an arr, or bytes a str, can have
+	MaxOutput int             // the number of bytes etch and the prompts of read can write
+}
+
+// DepthLimit returns the number of calls that can be in progress at once
+func (o Options) DepthLimit() int {
+	if o.MaxDepth <= 0 {
+		return DefaultMaxDepth
+	}
+	if o.MaxDepth > MaxDepthLimit {
+		return MaxDepthLimit
+	}
+	return o.MaxDepth
+}
+
+// StepLimitError stops a program which has taken more steps than Options.MaxSteps
+type StepLimitError struct {
+	Limit int
+}
+
+func (e *StepLimitError) Error() string {
+	return fmt.Sprintf("exceeded the limit of %d steps", e.Limit)
+}
+
+// CanceledError stops a program whose context was canceled or passed its deadline
+type CanceledError struct {
+	Err error // the error of the context
+}
+
+func (e *CanceledError) Error() string {
+	return fmt.Sprintf("execution stopped: %s", e.Err)
+}
+
+func (e *CanceledError) Unwrap() error {
+	return e.Err
+}
+
+// SizeLimitError stops a program which created an arr or str larger than Options.MaxSize
+type SizeLimitError struct {
+	Type  ast.Symbol // arr or str
+	Limit int
+}
+
+func (e *SizeLimitError) Error() string {
+	if e.Type == ast.STR {
+		return fmt.Sprintf("str is larger than the limit of %d bytes", e.Limit)
+	}
+	return fmt.Sprintf("arr is larger than the limit of %d elements", e.Limit)
+}
+
+// OutputLimitError stops a program which tried to write more than Options.MaxOutput bytes
+type OutputLimitError struct {
+	Limit int
+}
+
+func (e *OutputLimitError) Error() string {
+	return fmt.Sprintf("output exceeded the limit of %d bytes of output", e.Limit)
+}
+
+// IsLimit returns true if err was raised by going past one of the limits set by Options other than MaxDepth
+// these errors stop the program, so they can't be caught and don't run finally blocks
+func IsLimit(err error) bool {
+	var stepErr *StepLimitError
+	var canceledErr *CanceledError
+	var sizeErr *SizeLimitError
+	var outputErr *OutputLimitError
+	return errors.As(err, &stepErr) || errors.As(err, &canceledErr) || errors.As(err, &sizeErr) || errors.As(err, &outputErr)
+}
+
+// StackOverflow returns the error raised by calling the function named name once the call depth limit has been reached
+func StackOverflow(name string) error {
+	return fmt.Errorf("stack overflow in call to '%s'", name)
+}
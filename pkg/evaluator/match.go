@@ -0,0 +1,129 @@
+package evaluator
+
+import (
+	"github.com/mcjcloud/taurine/pkg/ast"
+	"github.com/mcjcloud/taurine/pkg/value"
+)
+
+// evaluateMatchExpression evaluates the first arm whose pattern matches the value
+// the match is nil if no pattern matches, or if the arm that matched is a block
+func evaluateMatchExpression(match *ast.MatchExpression, scope *Scope) (value.Value, error) {
+	val, err := evaluateExpression(match.Value, scope)
+	if err != nil {
+		return nil, err
+	}
+	for _, arm := range match.Arms {
+		// the variables stored by a pattern are only visible in its arm
+		armScope := NewScopeWithParent(scope)
+		matched, err := matchPattern(arm.Pattern, val, armScope)
+		if err != nil {
+			return nil, err
+		}
+		if !matched {
+			continue
+		}
+		if arm.Body == nil {
+			return evaluateExpression(arm.Value, armScope)
+		}
+
+		// a return, break, or continue in the block applies to the statement containing the match
+		if err := executeStatement(arm.Body, armScope); err != nil {
+			return nil, err
+		}
+		if armScope.interrupted() {
+			armScope.propagate(scope)
+		}
+		return &value.Nil{}, nil
+	}
+	return &value.Nil{}, nil
+}
+
+// matchPattern returns true if val matches pattern, declaring the variables the pattern stores in scope
+func matchPattern(pattern ast.Pattern, val value.Value, scope *Scope) (bool, error) {
+	switch p := pattern.(type) {
+	case *ast.LiteralPattern:
+		lit, err := evaluateExpression(p.Value, scope)
+		if err != nil {
+			return false, err
+		}
+		return value.Comparable(lit, val) && value.Equal(lit, val), nil
+	case *ast.RangePattern:
+		return matchRange(p, val, scope)
+	case *ast.BindingPattern:
+		if p.Symbol != ast.WILDCARD {
+			scope.store(p.Slot, "", val)
+		}
+		return true, nil
+	case *ast.TypePattern:
+		dType := ast.Symbol(p.SymbolType)
+		if _, isNil := val.(*value.Nil); val.Type() != dType.BaseType() && !(isNil && dType.IsOptional()) {
+			return false, nil
+		}
+		if p.Symbol != ast.WILDCARD {
+			if _, err := scope.Declare(p.Slot, p.Symbol, dType, val); err != nil {
+				return false, err
+			}
+		}
+		return true, nil
+	case *ast.ArrayPattern:
+		arr, ok := val.(*value.Arr)
+		if !ok || len(arr.Elements) < len(p.Elements) || (p.Rest == nil && len(arr.Elements) != len(p.Elements)) {
+			return false, nil
+		}
+		for i, el := range p.Elements {
+			if matched, err := matchPattern(el, arr.Elements[i], scope); !matched || err != nil {
+				return false, err
+			}
+		}
+		if p.Rest != nil {
+			return matchPattern(p.Rest, rest(arr, len(p.Elements)), scope)
+		}
+		return true, nil
+	case *ast.ObjectPattern:
+		for _, prop := range p.Properties {
+			propVal, ok := property(val, prop.Key)
+			if !ok {
+				return false, nil
+			}
+			if matched, err := matchPattern(prop.Pattern, propVal, scope); !matched || err != nil {
+				return false, err
+			}
+		}
+		_, isObj := val.(*value.Obj)
+		_, isRecord := val.(*value.Record)
+		return isObj || isRecord, nil
+	}
+	return false, nil
+}
+
+// matchRange returns true if val is one of the numbers the range would contain, from its start up to but not including its end
+func matchRange(p *ast.RangePattern, val value.Value, scope *Scope) (bool, error) {
+	start, end, err := evaluateOperands(p.Start, p.End, scope)
+	if err != nil {
+		return false, err
+	}
+	x, ok := value.ToFloat(val)
+	if !ok {
+		return false, nil
+	}
+	s, _ := value.ToFloat(start)
+	e, _ := value.ToFloat(end)
+	if s <= e {
+		return s <= x && (x < e || x == s), nil
+	}
+	// a range from a larger number counts down
+	return e < x && x <= s, nil
+}
+
+// property returns the value of a property of an object or a field of a record, and false if it doesn't have one
+func property(val value.Value, key string) (value.Value, bool) {
+	switch t := val.(type) {
+	case *value.Obj:
+		prop, ok := t.Properties[key]
+		return prop, ok
+	case *value.Record:
+		field, ok := t.Fields[key]
+		return field, ok
+	}
+	return nil, false
+}
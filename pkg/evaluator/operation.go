@@ -3,19 +3,12 @@ package evaluator
 import (
 	"errors"
 	"fmt"
-	"math/big"
 
 	"github.com/mcjcloud/taurine/pkg/ast"
+	"github.com/mcjcloud/taurine/pkg/value"
 )
 
-func assertIdentifier(exp ast.Expression) (*ast.Identifier, error) {
-	if id, ok := exp.(*ast.Identifier); ok {
-		return id, nil
-	}
-	return nil, fmt.Errorf("expected identifier but found %v", exp)
-}
-
-func evaluateOperands(leftExp, rightExp ast.Expression, scope *Scope) (ast.Expression, ast.Expression, error) {
+func evaluateOperands(leftExp, rightExp ast.Expression, scope *Scope) (value.Value, value.Value, error) {
 	left, err := evaluateExpression(leftExp, scope)
 	if err != nil {
 		return nil, nil, err
@@ -27,71 +20,80 @@ func evaluateOperands(leftExp, rightExp ast.Expression, scope *Scope) (ast.Expre
 	return left, right, nil
 }
 
-func evaluateOperation(op *ast.OperationExpression, scope *Scope) (ast.Expression, error) {
-	left := op.LeftExpression
-	right := op.RightExpression
+func evaluateOperation(op *ast.OperationExpression, scope *Scope) (value.Value, error) {
+	// these operators don't always evaluate their right side, so they are given the expressions
 	switch op.Operator {
-	case ast.PLUS:
-		return add(left, right, scope)
-	case ast.PLUS_EQUAL:
-		return addAndAssign(left, right, scope)
-	case ast.MINUS:
-		return minus(left, right, scope)
-	case ast.MINUS_EQUAL:
-		return minusAndAssign(left, right, scope)
-	case ast.MULTIPLY:
-		return multiply(left, right, scope)
-	case ast.MULTIPLY_EQUAL:
-		return multiplyAndAssign(left, right, scope)
-	case ast.DIVIDE:
-		return divide(left, right, scope)
-	case ast.DIVIDE_EQUAL:
-		return divideAndAssign(left, right, scope)
-	case ast.MODULO:
-		return modulo(left, right, scope)
-	case ast.MODULO_EQUAL:
-		return moduloAndAssign(left, right, scope)
-	case ast.EQUAL_EQUAL:
-		return equalEqual(left, right, scope)
-	case ast.NOT_EQUAL:
-		return notEqual(left, right, scope)
-	case ast.LESS_THAN:
-		return lessThan(left, right, scope)
-	case ast.LESS_EQUAL:
-		return lessEqual(left, right, scope)
-	case ast.GREATER_THAN:
-		return greaterThan(left, right, scope)
-	case ast.GREATER_EQUAL:
-		return greaterEqual(left, right, scope)
-	case ast.AT:
-		return arrayIndex(left, right, scope)
-	case ast.RANGE:
-		return createRange(left, right, scope)
 	case ast.DOT:
-		return dot(left, right, scope)
+		return dot(op.LeftExpression, op.RightExpression, scope)
+	case ast.AND:
+		return logicalAnd(op.LeftExpression, op.RightExpression, scope)
+	case ast.OR:
+		return logicalOr(op.LeftExpression, op.RightExpression, scope)
+	case ast.COALESCE:
+		return coalesce(op.LeftExpression, op.RightExpression, scope)
+	}
+
+	left, right, err := evaluateOperands(op.LeftExpression, op.RightExpression, scope)
+	if err != nil {
+		return nil, err
+	}
+	if err := scope.runtime.CheckOperation(op.Operator, left, right); err != nil {
+		return nil, err
+	}
+	return value.BinaryOperation(op.Operator, left, right)
+}
+
+// coalesce returns the left side, or the right side if the left side is nil
+func coalesce(leftExp, rightExp ast.Expression, scope *Scope) (value.Value, error) {
+	left, err := evaluateExpression(leftExp, scope)
+	if err != nil {
+		return nil, err
+	}
+	if _, isNil := left.(*value.Nil); !isNil {
+		return left, nil
+	}
+	return evaluateExpression(rightExp, scope)
+}
+
+func evaluateUnary(op *ast.UnaryExpression, scope *Scope) (value.Value, error) {
+	switch op.Operator {
+	case ast.NOT:
+		return logicalNot(op.Expression, scope)
+	case ast.MINUS:
+		return negate(op.Expression, scope)
 	default:
-		return nil, fmt.Errorf("unrecognized operator '%s'", op.Operator)
+		return nil, fmt.Errorf("unrecognized unary operator '%s'", op.Operator)
+	}
+}
+
+func negate(exp ast.Expression, scope *Scope) (value.Value, error) {
+	val, err := evaluateExpression(exp, scope)
+	if err != nil {
+		return nil, err
 	}
+	return value.Negate(val)
 }
 
-func builtInLen(exp ast.Expression, scope *Scope) (*ast.IntegerLiteral, error) {
-	evExp, err := evaluateExpression(exp, scope)
+func builtInLen(exp ast.Expression, scope *Scope) (*value.Int, error) {
+	val, err := evaluateExpression(exp, scope)
 	if err != nil {
 		return nil, err
 	}
-	if strExp, ok := evExp.(*ast.StringLiteral); ok {
-		return &ast.IntegerLiteral{Value: big.NewInt(int64(len(strExp.Value)))}, nil
-	} else if arrExp, ok := evExp.(*ast.ArrayExpression); ok {
-		return &ast.IntegerLiteral{Value: big.NewInt(int64(len(arrExp.Expressions)))}, nil
+	return value.Len(val)
+}
+
+func builtInInt(exp ast.Expression, scope *Scope) (*value.Int, error) {
+	val, err := evaluateExpression(exp, scope)
+	if err != nil {
+		return nil, errors.New("int() can only be called on type num or str")
 	}
-	return nil, errors.New("len can only be called on type str or arr")
+	return value.ToInt(val)
 }
 
-func builtInInt(exp ast.Expression, scope *Scope) (*ast.NumberLiteral, error) {
-	if expEv, err := evaluateExpression(exp, scope); err == nil {
-		if num, ok := expEv.(*ast.NumberLiteral); ok {
-			return &ast.NumberLiteral{Value: float64(int(num.Value))}, nil
-		}
+func builtInErr(exp ast.Expression, scope *Scope) (*value.Err, error) {
+	msg, err := evaluateExpression(exp, scope)
+	if err != nil {
+		return nil, err
 	}
-	return nil, errors.New("int() can only be called on type num")
+	return value.NewErr(msg)
 }
@@ -0,0 +1,226 @@
+package evaluator
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+
+	"github.com/mcjcloud/taurine/pkg/ast"
+	"github.com/mcjcloud/taurine/pkg/value"
+)
+
+// contextInterval is the number of steps between checks of whether the context of a program is done
+const contextInterval = 256
+
+// Runtime is the state a running program shares between all of its scopes: how much of the limits set by Options it has used, and its input and output
+// the virtual machine uses it as well, so both run programs in the same way
+type Runtime struct {
+	options Options
+	depth   int             // the number of calls in progress
+	steps   int             // the number of steps taken
+	written int             // the number of bytes written by etch and the prompts of read
+	input   *bufio.Scanner  // the lines of Options.Stdin, which is only read once the program reads it
+	pending chan scanResult // a line still being read when the context of the program was done
+}
+
+// scanResult is the result of reading a line of input
+type scanResult struct {
+	line string
+	ok   bool
+	err  error
+}
+
+// NewRuntime creates a Runtime for a program run with opts
+func NewRuntime(opts Options) *Runtime {
+	return &Runtime{options: opts}
+}
+
+// Reset starts counting the steps taken and the bytes written from 0 again, so a program can be called into again with the whole of its limits
+func (r *Runtime) Reset() {
+	r.steps, r.written = 0, 0
+}
+
+// Call starts a call of the function named name, which must be ended with Return
+func (r *Runtime) Call(name string) error {
+	if r.depth >= r.options.DepthLimit() {
+		return StackOverflow(name)
+	}
+	r.depth++
+	return nil
+}
+
+// Return ends the innermost call in progress
+func (r *Runtime) Return() {
+	r.depth--
+}
+
+// Step takes a step of the program, returning an error if it has run out of steps or its context is done
+func (r *Runtime) Step() error {
+	r.steps++
+	if r.options.MaxSteps > 0 && r.steps > r.options.MaxSteps {
+		return &StepLimitError{Limit: r.options.MaxSteps}
+	}
+	if r.steps%contextInterval == 1 {
+		return r.canceled()
+	}
+	return nil
+}
+
+// CheckSize returns an error if val is an arr or str larger than the limit
+func (r *Runtime) CheckSize(val value.Value) error {
+	limit := r.options.MaxSize
+	if limit <= 0 {
+		return nil
+	}
+	switch t := val.(type) {
+	case *value.Arr:
+		if len(t.Elements) > limit {
+			return &SizeLimitError{Type: ast.ARR, Limit: limit}
+		}
+	case *value.Str:
+		if len(t.Value) > limit {
+			return &SizeLimitError{Type: ast.STR, Limit: limit}
+		}
+	}
+	return nil
+}
+
+// CheckOperation returns an error if applying op to left and right would create an arr larger than the limit
+// the size of a range is checked before it's created, since it can be much larger than its operands
+func (r *Runtime) CheckOperation(op ast.Operator, left, right value.Value) error {
+	limit := r.options.MaxSize
+	if limit <= 0 || op != ast.RANGE {
+		return nil
+	}
+	start, ok := left.(*value.Int)
+	end, ok2 := right.(*value.Int)
+	if !ok || !ok2 {
+		return nil
+	}
+	size := new(big.Int).Sub(end.Value, start.Value)
+	if size.CmpAbs(big.NewInt(int64(limit))) > 0 {
+		return &SizeLimitError{Type: ast.ARR, Limit: limit}
+	}
+	return nil
+}
+
+// CheckMember returns an error if calling the method name on target would create a str larger than the limit
+// joining an arr is checked as it's stringified, since the elements can be much larger than the arr
+func (r *Runtime) CheckMember(target value.Value, name string, args []value.Value) error {
+	limit := r.options.MaxSize
+	arr, ok := target.(*value.Arr)
+	if limit <= 0 || !ok || name != "join" || len(args) < 1 {
+		return nil
+	}
+	sep, ok := args[0].(*value.Str)
+	if !ok {
+		return nil
+	}
+	size := 0
+	for i, el := range arr.Elements {
+		if i > 0 {
+			size += len(sep.Value)
+		}
+		if size += len(value.Stringify(el)); size > limit {
+			return &SizeLimitError{Type: ast.STR, Limit: limit}
+		}
+	}
+	return nil
+}
+
+// Etch writes a line of output, or as much of it as fits in the limit
+func (r *Runtime) Etch(line string) error {
+	return r.write(line + "\n")
+}
+
+// Read writes prompt, then reads a line of input, which is nil if there's nothing left to read
+// it stops waiting for the line once the context of the program is done
+func (r *Runtime) Read(prompt string) (value.Value, error) {
+	if err := r.canceled(); err != nil {
+		return nil, err
+	}
+	if prompt != "" {
+		if err := r.write(prompt); err != nil {
+			return nil, err
+		}
+	}
+	if r.input == nil {
+		stdin := r.options.Stdin
+		if stdin == nil {
+			stdin = os.Stdin
+		}
+		r.input = bufio.NewScanner(stdin)
+	}
+
+	var res scanResult
+	if ctx := r.options.Context; ctx == nil {
+		res = r.scan()
+	} else {
+		// the line is read in the background so the program can stop without it, and the next read picks it up
+		if r.pending == nil {
+			r.pending = make(chan scanResult, 1)
+			go func(pending chan scanResult) { pending <- r.scan() }(r.pending)
+		}
+		select {
+		case res = <-r.pending:
+			r.pending = nil
+		case <-ctx.Done():
+			return nil, &CanceledError{Err: ctx.Err()}
+		}
+		if err := r.canceled(); err != nil {
+			return nil, err
+		}
+	}
+
+	if res.err != nil {
+		return nil, fmt.Errorf("error reading input: %s", res.err)
+	}
+	if !res.ok {
+		return &value.Nil{}, nil
+	}
+	line := &value.Str{Value: res.line}
+	if err := r.CheckSize(line); err != nil {
+		return nil, err
+	}
+	return line, nil
+}
+
+// scan reads the next line of input
+func (r *Runtime) scan() scanResult {
+	ok := r.input.Scan()
+	return scanResult{line: r.input.Text(), ok: ok, err: r.input.Err()}
+}
+
+// canceled returns an error if the context of the program is done
+func (r *Runtime) canceled() error {
+	if ctx := r.options.Context; ctx != nil {
+		if err := ctx.Err(); err != nil {
+			return &CanceledError{Err: err}
+		}
+	}
+	return nil
+}
+
+// write writes s to the output, or as much of it as fits in the limit
+func (r *Runtime) write(s string) error {
+	var err error
+	if limit := r.options.MaxOutput; limit > 0 && r.written+len(s) > limit {
+		s = s[:limit-r.written]
+		err = &OutputLimitError{Limit: limit}
+	}
+	r.written += len(s)
+	if _, writeErr := io.WriteString(r.stdout(), s); writeErr != nil {
+		return fmt.Errorf("error writing output: %s", writeErr)
+	}
+	return err
+}
+
+// stdout returns where the program writes its output
+func (r *Runtime) stdout() io.Writer {
+	if r.options.Stdout == nil {
+		return os.Stdout
+	}
+	return r.options.Stdout
+}
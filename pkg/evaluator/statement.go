@@ -1,18 +1,22 @@
 package evaluator
 
 import (
-	"bufio"
 	"errors"
 	"fmt"
-	"os"
 	"path/filepath"
 	"strings"
 
 	"github.com/mcjcloud/taurine/pkg/ast"
 	"github.com/mcjcloud/taurine/pkg/util"
+	"github.com/mcjcloud/taurine/pkg/value"
 )
 
-func executeStatement(stmt ast.Statement, scope *Scope) error {
+func executeStatement(stmt ast.Statement, scope *Scope) (err error) {
+	defer func() { err = withRef(err, stmt) }()
+	if err := scope.runtime.Step(); err != nil {
+		return err
+	}
+
 	switch t := stmt.(type) {
 	case *ast.EtchStatement:
 		return executeEtchStatement(t, scope)
@@ -31,6 +35,16 @@ func executeStatement(stmt ast.Statement, scope *Scope) error {
 		return executeWhileStatement(t, scope)
 	case *ast.ReturnStatement:
 		return executeReturnStatement(t, scope)
+	case *ast.BreakStatement:
+		scope.Signal = BreakSignal
+		return nil
+	case *ast.ContinueStatement:
+		scope.Signal = ContinueSignal
+		return nil
+	case *ast.ThrowStatement:
+		return executeThrowStatement(t, scope)
+	case *ast.TryStatement:
+		return executeTryStatement(t, scope)
 	default:
 		return fmt.Errorf("unkown statement %s", stmt)
 	}
@@ -43,9 +57,9 @@ func executeBlockStatement(blockStmt *ast.BlockStatement, scope *Scope) error {
 		if err != nil {
 			return err
 		}
-		// if a block exists within the current scope, the return value should propogate up
-		if subScope.ReturnValue != nil {
-			scope.ReturnValue = subScope.ReturnValue
+		// if a block exists within the current scope, the return value or signal should propogate up
+		if subScope.interrupted() {
+			subScope.propagate(scope)
 			break
 		}
 	}
@@ -55,78 +69,64 @@ func executeBlockStatement(blockStmt *ast.BlockStatement, scope *Scope) error {
 func executeEtchStatement(stmt *ast.EtchStatement, scope *Scope) error {
 	var toEtch []string
 	for _, exp := range stmt.Expressions {
-		if numExp, ok := exp.(*ast.NumberLiteral); ok {
-			toEtch = append(toEtch, numExp.String())
-		} else if strExp, ok := exp.(*ast.StringLiteral); ok {
-			toEtch = append(toEtch, strExp.String())
-		} else if idExp, ok := exp.(*ast.Identifier); ok {
-			idVal := scope.Get(idExp.Name)
-			if idVal != nil {
-				toEtch = append(toEtch, idVal.String())
-			} else {
-				toEtch = append(toEtch, "nil")
-			}
-		} else {
-			expEval, err := evaluateExpression(exp, scope)
-			if err != nil {
-				return err
-			}
-			toEtch = append(toEtch, expEval.String())
+		expEval, err := evaluateExpression(exp, scope)
+		if err != nil {
+			return err
 		}
+		toEtch = append(toEtch, value.Stringify(expEval))
 	}
-	fmt.Println(strings.Join(toEtch, " "))
-	return nil
+	return scope.runtime.Etch(strings.Join(toEtch, " "))
 }
 
 func executeReadStatement(stmt *ast.ReadStatement, scope *Scope) error {
+	prompt := ""
 	if stmt.Prompt != nil {
-		fmt.Printf("%s", stmt.Prompt)
+		prompt = stmt.Prompt.Value
 	}
-	scanner := bufio.NewScanner(os.Stdin)
-	if scanner.Scan() {
-		scope.Set(stmt.Identifier.Name, &ast.StringLiteral{Value: scanner.Text()})
-	} else {
-		return errors.New("error reading input")
+	val, err := scope.runtime.Read(prompt)
+	if err != nil {
+		return err
 	}
+	scope.Set(stmt.Identifier.Binding, val)
 	return nil
 }
 
 func executeImportStatement(stmt *ast.ImportStatement, scope *Scope, tree *ast.Ast, g *util.ImportGraph) error {
 	absPath := util.ResolveImport(filepath.Dir(tree.FilePath), stmt.Source)
-	// check that the referenced ast has been evaluated
-	var node *util.ImportNode
-	if n, ok := g.Nodes[absPath]; !ok {
-		return fmt.Errorf("could not find referenced file %s", absPath)
-	} else if !n.Ast.Evaluated {
-		evalErr := Evaluate(n.Ast, g)
-		if evalErr != nil {
-			return evalErr
-		}
-		n.Ast.Evaluated = true
-		node = n
-	} else {
-		node = n
+	// each file is only evaluated the first time it's imported
+	current := scope.currentFile()
+	imported, ok := current.imports[absPath]
+	if !ok {
+		node, ok := g.Nodes[absPath]
+		if !ok {
+			return fmt.Errorf("could not find referenced file %s", absPath)
+		}
+		var err error
+		imported, err = evaluateFile(node.Ast, g, current.imports, scope.runtime, scope.builtins())
+		if err != nil {
+			return WithFrame(err, &CallFrame{Name: absPath, Import: true, Ref: stmt.Ref()})
+		}
+		current.imports[absPath] = imported
 	}
 
-	// imported values should now exist in the Ast exports
 	// add all the evaluated exports to the scope
 	for _, id := range stmt.Imports {
-		if exp, ok := node.Ast.Exports[id.Name]; !ok {
+		if val, ok := imported.exports[id.Name]; !ok {
 			return fmt.Errorf("symbol '%s' is not exported from %s", id.Name, absPath)
 		} else {
-			scope.Set(id.Name, exp)
+			scope.Set(id.Binding, val)
 		}
 	}
 	return nil
 }
 
-func executeExportStatement(stmt *ast.ExportStatement, scope *Scope, tree *ast.Ast) error {
+func executeExportStatement(stmt *ast.ExportStatement, scope *Scope) error {
 	// evaluate the expression value
 	val, err := evaluateExpression(stmt.Value, scope)
 	if err != nil {
 		return err
 	}
-	tree.Exports[stmt.Identifier.Name] = val
+	scope.currentFile().exports[stmt.Identifier.Name] = val
 	return nil
 }
 
@@ -135,7 +135,7 @@ func executeIfStatement(ifStmt *ast.IfStatement, scope *Scope) error {
 	if err != nil {
 		return err
 	}
-	if boolExp, ok := exp.(*ast.BooleanLiteral); ok {
+	if boolExp, ok := exp.(*value.Bool); ok {
 		if boolExp.Value {
 			if err := executeStatement(ifStmt.Statement, scope); err != nil {
 				return err
@@ -156,32 +156,37 @@ func executeForStatement(forStmt *ast.ForLoopStatement, scope *Scope) error {
 	if err != nil {
 		return err
 	}
-	var arr *ast.ArrayExpression
-	if a, ok := arrExp.(*ast.ArrayExpression); ok {
-		arr = a
-	} else if s, ok := arrExp.(*ast.StringLiteral); ok {
-		chars := make([]ast.Expression, len(s.Value))
-		for i, c := range s.Value {
-			chars[i] = &ast.StringLiteral{Value: string(c)}
+	var elements []value.Value
+	if a, ok := arrExp.(*value.Arr); ok {
+		elements = a.Elements
+	} else if s, ok := arrExp.(*value.Str); ok {
+		for _, c := range s.Value {
+			elements = append(elements, &value.Str{Value: string(c)})
 		}
-		arr = &ast.ArrayExpression{Expressions: chars}
 	} else {
-		return fmt.Errorf("expected array or string iterator but found %s", arrExp)
+		return fmt.Errorf("expected array or string iterator but found %s", arrExp.Type())
 	}
 
 	// loop through the array
-	for i := 0; i < len(arr.Expressions); i += forStmt.Step {
-		control, err := evaluateExpression(arr.Expressions[i], scope)
-		if err != nil {
-			return err
-		}
+	for i := 0; i < len(elements); i += forStmt.Step {
+		// objects and arrays in the array are shared with the control variable
 		forScope := NewScopeWithParent(scope)
-		forScope.Set(forStmt.Control.Name, control)
+		if forStmt.Pattern != nil {
+			if err := destructure(forStmt.Pattern, elements[i], forScope); err != nil {
+				return err
+			}
+		} else {
+			forScope.Set(forStmt.Control.Binding, elements[i])
+		}
 		if err := executeStatement(forStmt.Statement, forScope); err != nil {
 			return err
 		}
-		if forScope.ReturnValue != nil {
-			scope.ReturnValue = forScope.ReturnValue
+		// each iteration has a new scope, so a continue signal doesn't need to be cleared
+		if forScope.Signal == ReturnSignal {
+			forScope.propagate(scope)
+			break
+		}
+		if forScope.Signal == BreakSignal {
 			break
 		}
 	}
@@ -193,26 +198,32 @@ func executeWhileStatement(whileStmt *ast.WhileLoopStatement, scope *Scope) erro
 	if err != nil {
 		return err
 	}
-	if boolExp, ok := exp.(*ast.BooleanLiteral); ok {
+	if boolExp, ok := exp.(*value.Bool); ok {
 		subScope := NewScopeWithParent(scope)
 		for boolExp.Value {
 			err := executeStatement(whileStmt.Statement, subScope)
 			if err != nil {
 				return err
 			}
-			exp, err = evaluateExpression(whileStmt.Condition, subScope)
+			// if there is a return or break, the loop should end
+			if subScope.Signal == ReturnSignal {
+				subScope.propagate(scope)
+				break
+			}
+			if subScope.Signal == BreakSignal {
+				break
+			}
+			subScope.Signal = NoSignal
+
+			// the condition is outside of the loop's scope, where its variables were resolved
+			exp, err = evaluateExpression(whileStmt.Condition, scope)
 			if err != nil {
 				return err
 			}
-			boolExp, ok = exp.(*ast.BooleanLiteral)
+			boolExp, ok = exp.(*value.Bool)
 			if !ok {
 				return errors.New("while expression is no longer boolean")
 			}
-			// if there is a return value, the loop should end
-			if subScope.ReturnValue != nil {
-				scope.ReturnValue = subScope.ReturnValue
-				break
-			}
 		}
 	} else {
 		return errors.New("while expression must evaluate to boolean")
@@ -221,10 +232,99 @@ func executeWhileStatement(whileStmt *ast.WhileLoopStatement, scope *Scope) erro
 }
 
 func executeReturnStatement(rtnStmt *ast.ReturnStatement, scope *Scope) error {
-	exp, err := evaluateExpression(rtnStmt.Value, scope)
+	if rtnStmt.Tail {
+		return executeTailCall(rtnStmt.Value.(*ast.FunctionCall), scope)
+	}
+
+	var exp value.Value = &value.Nil{}
+	if rtnStmt.Value != nil {
+		val, err := evaluateExpression(rtnStmt.Value, scope)
+		if err != nil {
+			return err
+		}
+		exp = val
+	}
+	return returnValue(exp, scope)
+}
+
+// returnValue ends the function surrounding scope with a return value
+// the value must match the return type of the function being returned from
+func returnValue(exp value.Value, scope *Scope) error {
+	val, err := value.ConformReturn(scope.function(), exp)
 	if err != nil {
 		return err
 	}
-	scope.ReturnValue = exp
+	scope.ReturnValue = val
+	scope.Signal = ReturnSignal
 	return nil
 }
+
+// executeTailCall returns the value of call from the function surrounding scope
+// the call is made once the function's frame has ended, so a function can recurse in a tail call without using up the stack
+// the frame is only replaced if the function being called has the same return type, so its value doesn't need to be converted
+func executeTailCall(call *ast.FunctionCall, scope *Scope) error {
+	fn, err := evaluateExpression(call.Function, scope)
+	if err != nil {
+		return err
+	}
+	caller := scope.function()
+	scopedFn, ok := fn.(*ScopedFunction)
+	if !ok || caller == nil || caller.ReturnType != scopedFn.Function.ReturnType {
+		val, err := callValue(fn, call, nil, scope)
+		if err != nil {
+			return withRef(err, call)
+		}
+		return returnValue(val, scope)
+	}
+	args, err := bindArguments(scopedFn.Function, call, nil, scope)
+	if err != nil {
+		return withRef(err, call)
+	}
+	scope.ReturnValue = &tailCall{fn: scopedFn, call: call, args: args}
+	scope.Signal = ReturnSignal
+	return nil
+}
+
+func executeThrowStatement(stmt *ast.ThrowStatement, scope *Scope) error {
+	val, err := evaluateExpression(stmt.Value, scope)
+	if err != nil {
+		return err
+	}
+	return Throw(val, stmt.Ref())
+}
+
+func executeTryStatement(stmt *ast.TryStatement, scope *Scope) error {
+	err := executeStatement(stmt.Statement, scope)
+	if IsLimit(err) {
+		return err
+	}
+	if err != nil && stmt.Catch != nil {
+		catchScope := NewScopeWithParent(scope)
+		if _, err := catchScope.Declare(stmt.CatchParameter.Slot, stmt.CatchParameter.Symbol, ast.ERR, ErrorValue(err)); err != nil {
+			return err
+		}
+		err = executeStatement(stmt.Catch, catchScope)
+		if IsLimit(err) {
+			return err
+		}
+		if catchScope.interrupted() {
+			catchScope.propagate(scope)
+		}
+	}
+	if stmt.Finally == nil {
+		return err
+	}
+
+	// the finally block runs even if the try or catch block returned, or ended with an error
+	// a return, break, or continue in the finally block replaces whichever one came before it
+	returnValue, signal := scope.ReturnValue, scope.Signal
+	scope.ReturnValue, scope.Signal = nil, NoSignal
+	if finallyErr := executeStatement(stmt.Finally, scope); finallyErr != nil {
+		return finallyErr
+	}
+	if scope.interrupted() {
+		return nil
+	}
+	scope.ReturnValue, scope.Signal = returnValue, signal
+	return err
+}
@@ -3,7 +3,9 @@ package lexer
 import (
 	"fmt"
 	"regexp"
+	"strconv"
 	"strings"
+	"unicode"
 
 	"github.com/mcjcloud/taurine/pkg/token"
 )
@@ -11,6 +13,8 @@ import (
 var numberRe = regexp.MustCompile(`[.0-9]`)
 var symbolRe = regexp.MustCompile(`[_a-zA-Z0-9]`)
 var boolRe = regexp.MustCompile(`(^true$)|(^false$)`)
+var nilRe = regexp.MustCompile(`^nil$`)
+var hexRe = regexp.MustCompile(`[0-9a-fA-F]`)
 
 func isWhitespace(c byte) bool {
 	return c == ' ' || c == '\n' || c == '\t' || c == '\r'
@@ -24,15 +28,45 @@ func isOperation(c byte) bool {
 	return c == '+' || c == '-' || c == '*' || c == '/' || c == '%' || c == '!' || c == '<' || c == '>' || c == '@' || c == '.'
 }
 
+// LexError represents an error found while scanning source code
+type LexError struct {
+	Message  string
+	Position token.Pos
+}
+
+func (e *LexError) Error() string {
+	return fmt.Sprintf("%d:%d: %s", e.Position.Row, e.Position.Col, e.Message)
+}
+
+// newLexError creates a LexError for the characters starting at pos
+func newLexError(pos token.Pos, length int, msg string) *LexError {
+	pos.Length = length
+	return &LexError{
+		Message:  msg,
+		Position: pos,
+	}
+}
+
 // Analyze creates a series of tokens from source code
 func Analyze(source string) (tkns []*token.Token, err error) {
-	tkns = make([]*token.Token, 0)
 	scanner := token.NewScanner(source)
+	return analyze(scanner, make([]*token.Token, 0), false)
+}
 
-	// vars for tracking token positions
+// analyze appends the tokens read from scanner to tkns
+// if interpolating is true, the scanner is inside of a string and stops at the ')' which ends the interpolated expression
+func analyze(scanner *token.Scanner, tkns []*token.Token, interpolating bool) ([]*token.Token, error) {
+	// the number of unclosed '(' in an interpolated expression
+	var depth int
 	for scanner.HasNext() {
+		pos := scanner.Pos()
 		c := scanner.Next()
 
+		// interpolated expressions are part of a string, so they can't span lines
+		if interpolating && c == '\n' {
+			return tkns, newLexError(pos, 1, "expected ')' to end interpolated expression but found end of line")
+		}
+
 		// skip whitespace
 		if isWhitespace(c) {
 			if c == '\n' {
@@ -72,22 +106,34 @@ func Analyze(source string) (tkns []*token.Token, err error) {
 		}
 
 		if c == '"' {
-      str, err := scanString(scanner)
-      if err != nil {
-        return tkns, err
-      }
-			tkns = append(tkns, str)
-		} else if c == '-' {
-			nxt := scanner.Next()
-			if numberRe.Match([]byte{nxt}) {
-				scanner.Unread()
-				tkns = append(tkns, scanNumber(c, scanner))
-			} else if nxt == '=' {
-				tkns = append(tkns, token.NewToken("operation", string(c)+string(nxt), *scanner))
+			var err error
+			if tkns, err = scanString(scanner, tkns, pos); err != nil {
+				return tkns, err
+			}
+		} else if interpolating && c == ')' && depth == 0 {
+			return tkns, nil
+		} else if c == '&' || c == '|' {
+			// logical operators are always doubled
+			if nxt := scanner.Next(); nxt != c {
+				return tkns, newLexError(pos, 1, fmt.Sprintf("unexpected character '%c'", c))
+			}
+			tkns = append(tkns, token.NewToken("operation", string(c)+string(c), *scanner))
+		} else if c == '?' {
+			// '??' is an operator, and a single '?' marks a type as optional
+			if nxt := scanner.Next(); nxt == '?' {
+				tkns = append(tkns, token.NewToken("operation", "??", *scanner))
 			} else {
-				tkns = append(tkns, token.NewToken("operation", string(c), *scanner))
+				if nxt != token.EOF {
+					scanner.Unread()
+				}
+				tkns = append(tkns, token.NewToken("?", "?", *scanner))
 			}
 		} else if isSpecial(c) {
+			if c == '(' {
+				depth += 1
+			} else if c == ')' {
+				depth -= 1
+			}
 			tkns = append(tkns, token.NewToken(string(c), string(c), *scanner))
 		} else if isOperation(c) {
 			tkns = append(tkns, scanOperation(c, scanner))
@@ -96,6 +142,9 @@ func Analyze(source string) (tkns []*token.Token, err error) {
 			nxt := scanner.Next()
 			if nxt == '=' {
 				tkns = append(tkns, token.NewToken("operation", "==", *scanner))
+			} else if nxt == '>' {
+				// '=>' separates a pattern from its arm in a match
+				tkns = append(tkns, token.NewToken("=>", "=>", *scanner))
 			} else {
 				scanner.Unread()
 				tkns = append(tkns, token.NewToken(string(c), string(c), *scanner))
@@ -106,34 +155,122 @@ func Analyze(source string) (tkns []*token.Token, err error) {
 			tkn := scan(c, scanner, symbolRe, "symbol")
 			if boolRe.MatchString(tkn.Value) { // boolean
 				tkns = append(tkns, token.NewToken("bool", tkn.Value, *scanner))
+			} else if nilRe.MatchString(tkn.Value) {
+				tkns = append(tkns, token.NewToken("nil", tkn.Value, *scanner))
 			} else {
 				tkns = append(tkns, tkn)
 			}
 		} else {
-			panic(fmt.Sprintf("Unexpected character %v\n", c))
+			return tkns, newLexError(pos, 1, fmt.Sprintf("unexpected character '%c'", c))
 		}
 	}
-	return
+	if interpolating {
+		return tkns, newLexError(scanner.Pos(), 0, "expected ')' to end interpolated expression but found end of file")
+	}
+	return tkns, nil
 }
 
-// scan a string from the reader, including the double quotes
-func scanString(scanner *token.Scanner) (*token.Token, error) {
+// scan a string from the reader, the opening double quote at start has already been read
+// a string containing \(expression) is split into string_start, string_middle, and string_end tokens
+// with the tokens of each interpolated expression between them
+// the position of each string token covers its source code, including quotes and escape sequences
+func scanString(scanner *token.Scanner, tkns []*token.Token, start token.Pos) ([]*token.Token, error) {
 	var val string
-	c := scanner.Next()
-	for c != '"' && c != '\n' && scanner.HasNext() {
-		val += string(c)
-		c = scanner.Next()
-		if c == '\\' {
-			val += string(c)
-			c = scanner.Next()
+	segmentType := "string"
+	for {
+		pos := scanner.Pos()
+		if !scanner.HasNext() {
+			return tkns, newLexError(pos, 0, "expected closing quote '\"' but found end of file")
+		}
+		c := scanner.Next()
+		if c == '"' {
+			break
+		}
+		if c == '\n' {
+			return tkns, newLexError(pos, 1, "expected closing quote '\"' but found end of line")
+		}
+		if c != '\\' {
 			val += string(c)
-			c = scanner.Next()
+			continue
 		}
+
+		// decode the escape sequence
+		nxt := scanner.Next()
+		switch nxt {
+		case '(':
+			// end the current segment and scan the interpolated expression
+			if segmentType == "string" {
+				segmentType = "string_start"
+			}
+			tkns = append(tkns, newStringToken(segmentType, val, start, scanner))
+			var err error
+			if tkns, err = analyze(scanner, tkns, true); err != nil {
+				return tkns, err
+			}
+			// the next segment starts at the ')' ending the expression
+			val = ""
+			segmentType = "string_middle"
+			start = token.Pos{Row: scanner.Row, Col: scanner.Col - 1}
+		case '"', '\\':
+			val += string(nxt)
+		case 'n':
+			val += "\n"
+		case 't':
+			val += "\t"
+		case 'r':
+			val += "\r"
+		case '0':
+			val += "\x00"
+		case 'u':
+			r, err := scanUnicodeEscape(scanner, pos)
+			if err != nil {
+				return tkns, err
+			}
+			val += string(r)
+		default:
+			if nxt == token.EOF || nxt == '\n' {
+				return tkns, newLexError(pos, 1, "expected escape sequence after '\\'")
+			}
+			return tkns, newLexError(pos, 2, fmt.Sprintf("invalid escape sequence '\\%c'", nxt))
+		}
+	}
+	if segmentType != "string" {
+		segmentType = "string_end"
+	}
+	return append(tkns, newStringToken(segmentType, val, start, scanner)), nil
+}
+
+// newStringToken creates a token for the string segment which started at start and ends at the scanner's position
+func newStringToken(t, val string, start token.Pos, scanner *token.Scanner) *token.Token {
+	start.Length = scanner.Col - start.Col
+	return token.NewTokenAt(t, val, start)
+}
+
+// scanUnicodeEscape scans the {hex} part of a \u escape sequence starting at pos
+func scanUnicodeEscape(scanner *token.Scanner, pos token.Pos) (rune, error) {
+	if scanner.Next() != '{' {
+		return 0, newLexError(pos, scanner.Col-pos.Col, "expected '{' after '\\u'")
+	}
+	var hex string
+	for c := scanner.Next(); c != '}'; c = scanner.Next() {
+		if !hexRe.Match([]byte{c}) {
+			// don't include the end of the string in the error
+			if c == '\n' || c == '"' {
+				scanner.Unread()
+			}
+			return 0, newLexError(pos, scanner.Col-pos.Col, "expected hexadecimal digits and '}' in unicode escape sequence")
+		}
+		hex += string(c)
+	}
+	length := scanner.Col - pos.Col
+	if len(hex) == 0 || len(hex) > 6 {
+		return 0, newLexError(pos, length, "unicode escape sequence must have 1-6 hexadecimal digits")
+	}
+	code, _ := strconv.ParseUint(hex, 16, 32)
+	if code > unicode.MaxRune || (code >= 0xD800 && code <= 0xDFFF) {
+		return 0, newLexError(pos, length, fmt.Sprintf("invalid unicode code point '%s'", hex))
 	}
-  if c == '\n' || !scanner.HasNext() {
-    return nil, fmt.Errorf("expected closing quote '\"', found %c", c)
-  }
-	return token.NewToken("string", val, *scanner), nil
+	return rune(code), nil
 }
 
 func scanOperation(c byte, scanner *token.Scanner) *token.Token {
@@ -144,6 +281,14 @@ func scanOperation(c byte, scanner *token.Scanner) *token.Token {
 		val += string(b)
 	} else if b == '.' {
 		val += string(c)
+		// '...' marks the rest of an array in a destructuring pattern
+		if val == ".." {
+			if nxt := scanner.Next(); nxt == '.' {
+				return token.NewToken("...", "...", *scanner)
+			} else if nxt != token.EOF {
+				scanner.Unread()
+			}
+		}
 	} else {
 		scanner.Unread()
 	}
@@ -154,27 +299,18 @@ func scanOperation(c byte, scanner *token.Scanner) *token.Token {
 func scanNumber(c byte, scanner *token.Scanner) *token.Token {
 	var val string
 	b := c
-	if c == '-' {
-		val += "-"
-		b = scanner.Next()
-	}
-	var lastB byte
 	for numberRe.Match([]byte{b}) {
-		// prevent range operator '..' from being interpreted as a number
-		if b == '.' && lastB == '.' {
-			scanner.UnreadByte()
-			scanner.UnreadByte()
-			val = val[:len(val)-1]
-			var valType string
-			if strings.Contains(val, ".") {
-				valType = "number"
-			} else {
-				valType = "integer"
+		// a '.' which isn't followed by a digit is an operator, e.g. the range in 0..10 or the property in arr@0.prop
+		if b == '.' {
+			nxt := scanner.Next()
+			if nxt != token.EOF {
+				scanner.Unread()
+			}
+			if nxt < '0' || nxt > '9' {
+				break
 			}
-			return token.NewToken(valType, val, *scanner)
 		}
 		val += string(b)
-		lastB = b
 		b = scanner.Next()
 		if b == token.EOF {
 			break
@@ -0,0 +1,185 @@
+package lexer
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestStringEscapes(t *testing.T) {
+	tkns, err := Analyze(`"a\tb\n\"c\"\\ \u{1F600}\u{e9}"`)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(tkns) != 1 || tkns[0].Type != "string" {
+		t.Fatalf("expected a single string token but found %v", tkns)
+	}
+	if expected := "a\tb\n\"c\"\\ \U0001F600\u00e9"; tkns[0].Value != expected {
+		t.Errorf("expected %q but found %q", expected, tkns[0].Value)
+	}
+	// the position should cover the quotes and escape sequences
+	if pos := tkns[0].Position; pos.Row != 1 || pos.Col != 1 || pos.Length != 31 {
+		t.Errorf("expected string at 1:1 with length 31 but found %d:%d with length %d", pos.Row, pos.Col, pos.Length)
+	}
+}
+
+func TestStringEscapeErrors(t *testing.T) {
+	tests := []struct {
+		src    string
+		row    int
+		col    int
+		length int
+	}{
+		{"etch \"ok\";\netch \"bad \\q\";", 2, 11, 2},
+		{`"\u{110000}"`, 1, 2, 10},
+		{`"\u{12x}"`, 1, 2, 6},
+		{`"\u12"`, 1, 2, 3},
+		{"\"abc\n\"", 1, 5, 1},
+	}
+	for _, test := range tests {
+		_, err := Analyze(test.src)
+		lexErr, ok := err.(*LexError)
+		if !ok {
+			t.Errorf("expected LexError for %q but found %v", test.src, err)
+			continue
+		}
+		if pos := lexErr.Position; pos.Row != test.row || pos.Col != test.col || pos.Length != test.length {
+			t.Errorf("expected error for %q at %d:%d with length %d but found %d:%d with length %d",
+				test.src, test.row, test.col, test.length, pos.Row, pos.Col, pos.Length)
+		}
+	}
+}
+
+func TestInterpolatedStringTokens(t *testing.T) {
+	tkns, err := Analyze(`"a \(x) b \("c") d"`)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	expected := []struct{ typ, val string }{
+		{"string_start", "a "},
+		{"symbol", "x"},
+		{"string_middle", " b "},
+		{"string", "c"},
+		{"string_end", " d"},
+	}
+	if len(tkns) != len(expected) {
+		t.Fatalf("expected %d tokens but found %d", len(expected), len(tkns))
+	}
+	for i, e := range expected {
+		if tkns[i].Type != e.typ || tkns[i].Value != e.val {
+			t.Errorf("expected token %d to be %s %q but found %s %q", i, e.typ, e.val, tkns[i].Type, tkns[i].Value)
+		}
+	}
+}
+
+func TestMinusTokens(t *testing.T) {
+	tests := map[string][]string{
+		"a-1;":    {"a", "-", "1", ";"},
+		"-1;":     {"-", "1", ";"},
+		"a -= 2;": {"a", "-=", "2", ";"},
+		"1..-2;":  {"1", "..", "-", "2", ";"},
+		"a@0.b;":  {"a", "@", "0", ".", "b", ";"},
+		"1.5..2;": {"1.5", "..", "2", ";"},
+	}
+	for src, expected := range tests {
+		tkns, err := Analyze(src)
+		if err != nil {
+			t.Fatalf("unexpected error for %q: %s", src, err)
+		}
+		var values []string
+		for _, tkn := range tkns {
+			values = append(values, tkn.Value)
+		}
+		if strings.Join(values, " ") != strings.Join(expected, " ") {
+			t.Errorf("expected %q to be split into %q but found %q", src, expected, values)
+		}
+	}
+}
+
+func TestOptionalTokens(t *testing.T) {
+	tkns, err := Analyze("var (str?) s = a ?? nil;")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	expected := []struct{ typ, val string }{
+		{"symbol", "var"},
+		{"(", "("},
+		{"symbol", "str"},
+		{"?", "?"},
+		{")", ")"},
+		{"symbol", "s"},
+		{"=", "="},
+		{"symbol", "a"},
+		{"operation", "??"},
+		{"nil", "nil"},
+		{";", ";"},
+	}
+	if len(tkns) != len(expected) {
+		t.Fatalf("expected %d tokens but found %d", len(expected), len(tkns))
+	}
+	for i, e := range expected {
+		if tkns[i].Type != e.typ || tkns[i].Value != e.val {
+			t.Errorf("expected token %d to be %s %q but found %s %q", i, e.typ, e.val, tkns[i].Type, tkns[i].Value)
+		}
+	}
+}
+
+func TestMatchTokens(t *testing.T) {
+	tkns, err := Analyze("match n { 0..9 => a, _ => b }")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	expected := []struct{ typ, val string }{
+		{"symbol", "match"},
+		{"symbol", "n"},
+		{"{", "{"},
+		{"integer", "0"},
+		{"operation", ".."},
+		{"integer", "9"},
+		{"=>", "=>"},
+		{"symbol", "a"},
+		{",", ","},
+		{"symbol", "_"},
+		{"=>", "=>"},
+		{"symbol", "b"},
+		{"}", "}"},
+	}
+	if len(tkns) != len(expected) {
+		t.Fatalf("expected %d tokens but found %d", len(expected), len(tkns))
+	}
+	for i, e := range expected {
+		if tkns[i].Type != e.typ || tkns[i].Value != e.val {
+			t.Errorf("expected token %d to be %s %q but found %s %q", i, e.typ, e.val, tkns[i].Type, tkns[i].Value)
+		}
+	}
+}
+
+func TestRestToken(t *testing.T) {
+	tkns, err := Analyze("var [int a, arr rest...] = 0..3;")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	expected := []struct{ typ, val string }{
+		{"symbol", "var"},
+		{"[", "["},
+		{"symbol", "int"},
+		{"symbol", "a"},
+		{",", ","},
+		{"symbol", "arr"},
+		{"symbol", "rest"},
+		{"...", "..."},
+		{"]", "]"},
+		{"=", "="},
+		{"integer", "0"},
+		{"operation", ".."},
+		{"integer", "3"},
+		{";", ";"},
+	}
+	if len(tkns) != len(expected) {
+		t.Fatalf("expected %d tokens but found %d", len(expected), len(tkns))
+	}
+	for i, e := range expected {
+		if tkns[i].Type != e.typ || tkns[i].Value != e.val {
+			t.Errorf("expected token %d to be %s %q but found %s %q", i, e.typ, e.val, tkns[i].Type, tkns[i].Value)
+		}
+	}
+}
@@ -78,14 +78,14 @@ func (it *TokenIterator) AtIndex(i int) *token.Token {
 
 // SkipStatement advance the iterator past the next ';' or '}'
 func (it *TokenIterator) SkipStatement() {
-	for nxt := it.Next(); nxt.Type != ";"; nxt = it.Next() {
+	for nxt := it.Next(); nxt != nil && nxt.Type != ";"; nxt = it.Next() {
 	}
 }
 
 // SkipToClosingBracket skips to the closing bracket matching the last '{'
 func (it *TokenIterator) SkipToClosingBracket() {
 	var depth int
-	for nxt := it.Next(); nxt.Type != "}" || depth > 0; nxt = it.Next() {
+	for nxt := it.Next(); nxt != nil && (nxt.Type != "}" || depth > 0); nxt = it.Next() {
 		if nxt.Type == "{" {
 			depth += 1
 		} else if nxt.Type == "}" {
@@ -96,7 +96,7 @@ func (it *TokenIterator) SkipToClosingBracket() {
 
 // SkipTo advances the iterator to the next occurance of the given token
 func (it *TokenIterator) SkipTo(tkn token.Token) {
-	for nxt := it.Next(); nxt.Type != tkn.Type || nxt.Value != tkn.Value; nxt = it.Next() {
+	for nxt := it.Next(); nxt != nil && (nxt.Type != tkn.Type || nxt.Value != tkn.Value); nxt = it.Next() {
 	}
 }
 
@@ -119,7 +119,7 @@ func (it TokenIterator) PrintTokens() {
 	for i, tkn := range it.Tokens {
 		var val string
 		if tkn.Type != tkn.Value {
-			val = tkn.Value
+			val = token.Escape(tkn.Value)
 		}
 		fmt.Printf("%02d:%02d %04d %s %s\n", tkn.Position.Row, tkn.Position.Col, i, tkn.Type, val)
 	}
@@ -1,14 +1,18 @@
 package parser
 
 import (
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"os"
 	"path"
 	"path/filepath"
+	"sort"
+	"strings"
 
 	"github.com/mcjcloud/taurine/pkg/ast"
 	"github.com/mcjcloud/taurine/pkg/lexer"
+	"github.com/mcjcloud/taurine/pkg/token"
 	"github.com/mcjcloud/taurine/pkg/util"
 )
 
@@ -19,38 +23,89 @@ type ParseContext struct {
 	Iterators     map[string]*lexer.TokenIterator // the token iterators for all files
 	ErrorHandlers map[string]*util.ErrorHandler   // the error handlers for all files
 	ImportGraph   *util.ImportGraph               // the import gragh
+	Interactive   bool                            // set by the repl, whose inputs are parsed one at a time as parts of the main file
 
 	currentNode *util.ImportNode
+	loopDepth   int                        // the number of loops surrounding the statement being parsed
+	tryDepth    int                        // the number of try and catch blocks surrounding the statement being parsed
+	returnType  string                     // the return type of the function surrounding the statement being parsed, if there is one
+	types       map[string]map[string]bool // the names of the types declared in or imported into each file
+	globals     *scope                     // the variables declared at the top level of the main file by the inputs parsed so far
+	builtins    *scope                     // the variables declared by the program embedding the interpreter, which every file can use
 }
 
 func NewParseContext(absPath string) (*ParseContext, error) {
+	// read source code for main file
+	bytes, err := ioutil.ReadFile(absPath)
+	if err != nil {
+		return nil, fmt.Errorf("error reading referenced source: %s", err.Error())
+	}
+	return NewParseContextFromSource(absPath, string(bytes))
+}
+
+// NewParseContextFromSource creates a ParseContext for source code that wasn't read from a file
+// absPath is used to resolve imports and to report errors
+func NewParseContextFromSource(absPath, src string) (*ParseContext, error) {
 	ctx := &ParseContext{
 		MainPath:      absPath,
-		ParseStack:    util.NewStackWith(absPath),
 		Iterators:     make(map[string]*lexer.TokenIterator),
 		ErrorHandlers: make(map[string]*util.ErrorHandler),
 		ImportGraph:   util.NewImportGraph(absPath),
+		types:         make(map[string]map[string]bool),
+	}
+	if err := ctx.SetSource(src); err != nil {
+		return nil, err
 	}
+	return ctx, nil
+}
 
-	// read source code for main file and create tokens
-	bytes, err := ioutil.ReadFile(absPath)
+// SetSource replaces the source code of the main file so it can be parsed again
+// files which have already been imported are kept in the import graph and are not parsed again
+func (ctx *ParseContext) SetSource(src string) error {
+	tkns, err := lexer.Analyze(src)
 	if err != nil {
-		return nil, fmt.Errorf("error reading referenced source: %s", err.Error())
+		return err
 	}
-	src := string(bytes)
-	tkns, err := lexer.Analyze(src)
-  if err != nil {
-    return nil, err
-  }
 
 	// assign token iterator and error handlers
-	ctx.Iterators[absPath] = lexer.NewTokenIterator(tkns)
-	ctx.ErrorHandlers[absPath] = util.NewErrorHandler()
+	ctx.Iterators[ctx.MainPath] = lexer.NewTokenIterator(tkns)
+	ctx.ErrorHandlers[ctx.MainPath] = util.NewErrorHandler()
 
-	// setup currentNode
-	ctx.currentNode = ctx.ImportGraph.Nodes[absPath]
+	// setup the parse stack and currentNode
+	ctx.ParseStack = util.NewStackWith(ctx.MainPath)
+	ctx.currentNode = ctx.ImportGraph.Nodes[ctx.MainPath]
 
-	return ctx, nil
+	return nil
+}
+
+// Reset forgets every import so files with errors will be parsed again
+// the types and variables declared in the main file are kept, since the REPL has already evaluated the statements declaring them
+func (ctx *ParseContext) Reset() error {
+	fresh, err := NewParseContextFromSource(ctx.MainPath, "")
+	if err != nil {
+		return err
+	}
+	if mainTypes, ok := ctx.types[ctx.MainPath]; ok {
+		fresh.types[ctx.MainPath] = mainTypes
+	}
+	fresh.Interactive, fresh.globals, fresh.builtins = ctx.Interactive, ctx.globals, ctx.builtins
+	*ctx = *fresh
+	return nil
+}
+
+// Declare adds a variable every file can use, which is declared outside of all of them, and returns its slot
+// it must be called before parsing, and the scope holding the variables must be the parent of the top level scope of each file when they're evaluated
+func (ctx *ParseContext) Declare(name string) int {
+	if ctx.builtins == nil {
+		ctx.builtins = newScope(nil, false)
+	}
+	if v, ok := ctx.builtins.names[name]; ok {
+		return v.slot
+	}
+	slot := ctx.builtins.slots
+	ctx.builtins.slots++
+	ctx.builtins.names[name] = &variable{slot: slot}
+	return slot
 }
 
 // CurrentFilePath returns the current file path
@@ -144,36 +199,128 @@ func (ctx *ParseContext) PrintErrors() {
 		if len(handler.Errors) == 0 {
 			continue
 		}
-		it := ctx.Iterators[path]
 
 		fmt.Printf("found %d errors in %s\n", len(handler.Errors), path)
 		for _, e := range handler.Errors {
 			// print error message
-			fmt.Printf("%d:%d: %s\n", e.Token.Position.Row, e.Token.Position.Col, e.Message)
-
-			// print each token in the row with the error
-			row := it.GetRow(e.Token.Position.Row)
-			colStart := 1
-			for _, t := range row {
-				// print spaces leading up to the beginning of each token
-				for i := colStart; i < t.Position.Col; i += 1 {
-					fmt.Printf(" ")
-				}
-				// update colStart and print the token
-				colStart = t.Position.Col + t.Position.Length
-				if t.Type == "string" {
-					fmt.Printf("\"%s\"", t.Value)
-				} else {
-					fmt.Printf(t.Value)
-				}
-			}
-			fmt.Println()
-
-			// print underlines up until the error token
-			for i := 0; i < e.Token.Position.Col+e.Token.Position.Length-1; i += 1 {
-				fmt.Printf("~")
-			}
-			fmt.Println("^")
+			pos := ctx.position(path, e.Token)
+			fmt.Printf("%d:%d: %s\n", pos.Row, pos.Col, e.Message)
+			ctx.PrintSourceLine(path, pos)
 		}
 	}
 }
+
+// Err returns the errors found during parsing as one error, or nil if there weren't any
+func (ctx *ParseContext) Err() error {
+	paths := make([]string, 0, len(ctx.ErrorHandlers))
+	for path := range ctx.ErrorHandlers {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	var msgs []string
+	for _, path := range paths {
+		for _, e := range ctx.ErrorHandlers[path].Errors {
+			pos := ctx.position(path, e.Token)
+			msgs = append(msgs, fmt.Sprintf("%s:%d:%d: %s", path, pos.Row, pos.Col, e.Message))
+		}
+	}
+	if len(msgs) == 0 {
+		return nil
+	}
+	return errors.New(strings.Join(msgs, "\n"))
+}
+
+// PrintWarnings prints the warnings found during parsing to stderr, so they aren't mixed in with the output of the program
+func (ctx *ParseContext) PrintWarnings() {
+	paths := make([]string, 0, len(ctx.ErrorHandlers))
+	for path := range ctx.ErrorHandlers {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		for _, w := range ctx.ErrorHandlers[path].Warnings {
+			pos := ctx.position(path, w.Token)
+			fmt.Fprintf(os.Stderr, "warning in %s\n%d:%d: %s\n", path, pos.Row, pos.Col, w.Message)
+		}
+	}
+}
+
+// position returns the position of tkn in the file at path
+// an error found at the end of the file has no token, so it's given the position of the last token instead
+func (ctx *ParseContext) position(path string, tkn *token.Token) token.Pos {
+	if tkn != nil {
+		return tkn.Position
+	}
+	if it, ok := ctx.Iterators[path]; ok {
+		if last := it.AtIndex(len(it.Tokens) - 1); last != nil {
+			return last.Position
+		}
+	}
+	return token.Pos{Row: 1, Col: 1}
+}
+
+// PrintSourceLine prints the row of the file at path containing pos, underlined up to the end of pos
+func (ctx *ParseContext) PrintSourceLine(path string, pos token.Pos) {
+	it, ok := ctx.Iterators[path]
+	if !ok {
+		return
+	}
+
+	// print each token in the row
+	row := it.GetRow(pos.Row)
+	colStart := 1
+	for _, t := range row {
+		// print spaces leading up to the beginning of each token
+		for i := colStart; i < t.Position.Col; i += 1 {
+			fmt.Printf(" ")
+		}
+		// update colStart and print the token
+		colStart = t.Position.Col + t.Position.Length
+		switch t.Type {
+		case "string":
+			fmt.Printf("\"%s\"", token.Escape(t.Value))
+		case "string_start":
+			fmt.Printf("\"%s\\(", token.Escape(t.Value))
+		case "string_middle":
+			fmt.Printf(")%s\\(", token.Escape(t.Value))
+		case "string_end":
+			fmt.Printf(")%s\"", token.Escape(t.Value))
+		default:
+			fmt.Print(t.Value)
+		}
+	}
+	fmt.Println()
+
+	// print underlines up until the end of pos
+	for i := 0; i < pos.Col+pos.Length-1; i += 1 {
+		fmt.Printf("~")
+	}
+	fmt.Println("^")
+}
+
+// isDataType returns true if name is a built-in data type, or a type declared in or imported into the current file
+func (ctx *ParseContext) isDataType(name string) bool {
+	return ast.Symbol(name).IsDataType() || ctx.isRecordType(name)
+}
+
+// isRecordType returns true if name is a type declared with the type keyword in or imported into the current file
+func (ctx *ParseContext) isRecordType(name string) bool {
+	return ctx.types[ctx.CurrentFilePath()][name]
+}
+
+// declareType makes name usable as a data type in the rest of the file at path
+func (ctx *ParseContext) declareType(path, name string) {
+	if ctx.types[path] == nil {
+		ctx.types[path] = make(map[string]bool)
+	}
+	ctx.types[path][name] = true
+}
+
+// ref returns the location of tkn in the file currently being parsed
+func (ctx *ParseContext) ref(tkn *token.Token) ast.SourceRef {
+	ref := ast.SourceRef{FilePath: ctx.CurrentFilePath()}
+	if tkn != nil {
+		ref.Position = tkn.Position
+	}
+	return ref
+}
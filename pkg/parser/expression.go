@@ -5,194 +5,343 @@ import (
 	"math/big"
 	"strconv"
 
-	"github.com/jinzhu/copier"
 	"github.com/mcjcloud/taurine/pkg/ast"
+	"github.com/mcjcloud/taurine/pkg/lexer"
 	"github.com/mcjcloud/taurine/pkg/token"
 )
 
-func parseExpression(tkn *token.Token, ctx *ParseContext, exp ast.Expression) ast.Expression {
+// parseExpression parses the expression starting with tkn
+// when it returns, the current token is the last token of the expression
+func parseExpression(tkn *token.Token, ctx *ParseContext) ast.Expression {
+	return parseExpressionWithPrecedence(tkn, ctx, 0)
+}
+
+// parseExpressionWithPrecedence parses the expression starting with tkn, stopping at the first operator that binds
+// less tightly than minPrecedence so the caller can use the expression as an operand
+func parseExpressionWithPrecedence(tkn *token.Token, ctx *ParseContext, minPrecedence int) ast.Expression {
 	it := ctx.CurrentIterator()
-	if exp == nil {
-		if tkn.Type == "number" {
-			val, _ := strconv.ParseFloat(tkn.Value, 64)
-			return parseExpression(tkn, ctx, &ast.NumberLiteral{Value: val})
-		} else if tkn.Type == "integer" {
-			bigInt, _ := new(big.Int).SetString(tkn.Value, 10)
-			return parseExpression(tkn, ctx, &ast.IntegerLiteral{Value: bigInt})
-		} else if tkn.Type == "string" {
-			return parseExpression(tkn, ctx, &ast.StringLiteral{Value: tkn.Value})
-		} else if tkn.Type == "bool" {
-			// check for boolean value
-			if tkn.Value == "true" {
-				return parseExpression(tkn, ctx, &ast.BooleanLiteral{Value: true})
-			} else if tkn.Value == "false" {
-				return parseExpression(tkn, ctx, &ast.BooleanLiteral{Value: false})
+	exp := parsePrefixExpression(tkn, ctx)
+	for {
+		peek := it.Peek()
+		if peek == nil {
+			return exp
+		}
+
+		// calls bind to the expression before them, e.g. f(1)(2)
+		if peek.Type == "(" {
+			if ast.CALL_PRECEDENCE < minPrecedence {
+				return exp
 			}
-			return ctx.CurrentErrorHandler().Add(tkn, "invalid boolean value")
-		} else if tkn.Type == "symbol" {
-			// check if the symbol is "func", if so this is a func expression
-			if tkn.Value == ast.FUNC {
-				fn := parseFunction(tkn, ctx)
-				// pass fn back in to see if it is being operated on (e.g. a function call)
-				return parseExpression(it.Current(), ctx, fn)
-			} else if tkn.Value == ast.VAR {
-				vDecl := parseVarDeclaration(tkn, ctx)
-				return parseExpression(it.Current(), ctx, vDecl)
-			} else {
-				return parseExpression(tkn, ctx, &ast.Identifier{Name: tkn.Value})
+			it.Next()
+			exp = parseFunctionCall(exp, ctx)
+			continue
+		}
+
+		var op ast.Operator
+		if peek.Type == "=" {
+			op = ast.ASSIGN
+		} else if peek.Type == "operation" {
+			op = ast.Operator(peek.Value)
+		} else {
+			return exp
+		}
+		precedence, ok := ast.PRECEDENCE[op]
+		if !ok || precedence < minPrecedence {
+			return exp
+		}
+		opTkn := it.Next()
+
+		// the right side of a left associative operator must bind more tightly than the operator
+		rightPrecedence := precedence + 1
+		if ast.ASSOCIATIVITY[op] == ast.RightAssociative {
+			rightPrecedence = precedence
+		}
+
+		if _, compound := ast.COMPOUND_OPERATORS[op]; op == ast.ASSIGN || compound {
+			exp = parseAssignment(exp, op, opTkn, ctx, rightPrecedence)
+		} else if op == ast.DOT {
+			exp = &ast.OperationExpression{
+				SourceRef:       ctx.ref(opTkn),
+				Operator:        op,
+				LeftExpression:  exp,
+				RightExpression: parseMember(it.Next(), ctx),
 			}
-		} else if tkn.Type == "[" {
-			nxt := it.Next()
-			exprs := make([]ast.Expression, 0)
-			if nxt.Type == "]" {
-				return parseExpression(nxt, ctx, &ast.ArrayExpression{Expressions: exprs})
+		} else {
+			exp = &ast.OperationExpression{
+				SourceRef:       ctx.ref(opTkn),
+				Operator:        op,
+				LeftExpression:  exp,
+				RightExpression: parseExpressionWithPrecedence(it.Next(), ctx, rightPrecedence),
 			}
-			arrExp := parseExpression(nxt, ctx, nil)
+		}
+	}
+}
 
-			// expect a ]
-			nxt = it.Next()
-			if nxt == nil || (nxt.Type != "]" && nxt.Type != ",") {
-				it.SkipStatement()
-				return ctx.CurrentErrorHandler().Add(nxt, "expected ']' or ',' in array expression")
-			}
-			exprs = append(exprs, arrExp)
-			if nxt.Type == "," {
-				// while nxt is a ",", evaluate the next element and add it to the expression array
-				for nxt.Type == "," {
-					nxtEl := parseExpression(it.Next(), ctx, nil)
-					exprs = append(exprs, nxtEl) // add to exp array
-					nxt = it.Next()              // get next token
-				}
-				// check again that it's a closing bracket
-				if nxt == nil || nxt.Type != "]" {
-					it.SkipStatement()
-					return ctx.CurrentErrorHandler().Add(nxt, "expected ']' to end array expression")
+// parsePrefixExpression parses a literal, identifier, or unary operation starting with tkn
+func parsePrefixExpression(tkn *token.Token, ctx *ParseContext) ast.Expression {
+	it := ctx.CurrentIterator()
+	if tkn == nil {
+		return ctx.CurrentErrorHandler().Add(it.AtIndex(len(it.Tokens)-1), "expected expression but found end of file")
+	}
+	if tkn.Type == ";" {
+		// leave the ';' to end the statement
+		it.Prev()
+		return ctx.CurrentErrorHandler().Add(tkn, "expected expression but found ';'")
+	}
+	if tkn.Type == "number" {
+		val, _ := strconv.ParseFloat(tkn.Value, 64)
+		return &ast.NumberLiteral{SourceRef: ctx.ref(tkn), Value: val}
+	} else if tkn.Type == "integer" {
+		bigInt, _ := new(big.Int).SetString(tkn.Value, 10)
+		return &ast.IntegerLiteral{SourceRef: ctx.ref(tkn), Value: bigInt}
+	} else if tkn.Type == "string" {
+		return &ast.StringLiteral{SourceRef: ctx.ref(tkn), Value: tkn.Value}
+	} else if tkn.Type == "string_start" {
+		return parseInterpolatedString(tkn, ctx)
+	} else if tkn.Type == "bool" {
+		// check for boolean value
+		if tkn.Value == "true" {
+			return &ast.BooleanLiteral{SourceRef: ctx.ref(tkn), Value: true}
+		} else if tkn.Value == "false" {
+			return &ast.BooleanLiteral{SourceRef: ctx.ref(tkn), Value: false}
+		}
+		return ctx.CurrentErrorHandler().Add(tkn, "invalid boolean value")
+	} else if tkn.Type == "nil" {
+		return &ast.NilLiteral{SourceRef: ctx.ref(tkn)}
+	} else if tkn.Type == "symbol" {
+		// check if the symbol is "func", if so this is a func expression
+		if tkn.Value == ast.FUNC {
+			return parseFunction(tkn, ctx)
+		} else if tkn.Value == ast.VAR {
+			return parseVarDeclaration(tkn, ctx)
+		} else if tkn.Value == ast.TYPE {
+			return parseTypeDeclaration(tkn, ctx)
+		} else if tkn.Value == ast.MATCH {
+			return parseMatchExpression(tkn, ctx)
+		}
+		return &ast.Identifier{SourceRef: ctx.ref(tkn), Name: tkn.Value}
+	} else if precedence, ok := ast.PREFIX_PRECEDENCE[ast.Operator(tkn.Value)]; ok && tkn.Type == "operation" {
+		operand := parseExpressionWithPrecedence(it.Next(), ctx, precedence)
+		return applyUnary(ctx.ref(tkn), ast.Operator(tkn.Value), operand)
+	} else if tkn.Type == "[" {
+		nxt := it.Next()
+		exprs := make([]ast.Expression, 0)
+		if nxt != nil && nxt.Type == "]" {
+			return &ast.ArrayExpression{SourceRef: ctx.ref(tkn), Expressions: exprs}
+		}
+		arrExp := parseExpression(nxt, ctx)
+
+		// expect a ]
+		nxt = it.Next()
+		if nxt == nil || (nxt.Type != "]" && nxt.Type != ",") {
+			it.SkipStatement()
+			return ctx.CurrentErrorHandler().Add(nxt, "expected ']' or ',' in array expression")
+		}
+		exprs = append(exprs, arrExp)
+		// while nxt is a ",", evaluate the next element and add it to the expression array
+		for nxt != nil && nxt.Type == "," {
+			nxtEl := parseExpression(it.Next(), ctx)
+			exprs = append(exprs, nxtEl) // add to exp array
+			nxt = it.Next()              // get next token
+		}
+		// check again that it's a closing bracket
+		if nxt == nil || nxt.Type != "]" {
+			it.SkipStatement()
+			return ctx.CurrentErrorHandler().Add(nxt, "expected ']' to end array expression")
+		}
+		return &ast.ArrayExpression{SourceRef: ctx.ref(tkn), Expressions: exprs}
+	} else if tkn.Type == "(" {
+		// (expression)
+		grpExp := parseExpression(it.Next(), ctx)
+		if nxt := it.Next(); nxt == nil || nxt.Type != ")" {
+			return ctx.CurrentErrorHandler().Add(nxt, "expected ')' to end group expression")
+		}
+		return &ast.GroupExpression{SourceRef: ctx.ref(tkn), Expression: grpExp}
+	} else if tkn.Type == "{" {
+		// object
+		value := make(map[string]ast.Expression)
+		if peek := it.Peek(); peek != nil && peek.Type == "}" {
+			it.Next()
+			return &ast.ObjectLiteral{SourceRef: ctx.ref(tkn), Value: value}
+		}
+		keysRemain := true
+		for keysRemain {
+			// object literal
+			idExp := parseExpression(it.Next(), ctx)
+			if id, ok := idExp.(*ast.Identifier); ok {
+				// expect a ':' next
+				if nxt := it.Next(); nxt == nil || nxt.Type != ":" {
+					it.SkipToClosingBracket()
+					return ctx.CurrentErrorHandler().Add(it.Current(), "expected ':' after identifer")
 				}
-				return parseExpression(nxt, ctx, &ast.ArrayExpression{Expressions: exprs})
-			} else {
-				return parseExpression(nxt, ctx, &ast.ArrayExpression{Expressions: exprs})
-			}
-		} else if tkn.Type == "(" {
-			// (expression)
-			grpExp := parseExpression(it.Next(), ctx, nil)
-			return parseExpression(it.Next(), ctx, &ast.GroupExpression{Expression: grpExp})
-		} else if tkn.Type == "{" {
-			// object
-			value := make(map[string]ast.Expression)
-			keysRemain := true
-			var nxt *token.Token
-			for keysRemain {
-				// object literal
-				idExp := parseExpression(it.Next(), ctx, nil)
-				if id := idExp.(*ast.Identifier); id != nil {
-					// expect a ':' next
-					if it.Next().Type != ":" {
-						it.SkipToClosingBracket()
-						return ctx.CurrentErrorHandler().Add(it.Current(), "expected ':' after identifer")
-					}
-					valExp := parseExpression(it.Next(), ctx, nil)
-					nxt = it.Next()
-					if nxt.Type == "," {
-						if it.Peek().Type == "}" {
-							nxt = it.Next()
-							keysRemain = false
-						}
-					} else if nxt.Type == "}" {
+				valExp := parseExpression(it.Next(), ctx)
+				nxt := it.Next()
+				if nxt == nil {
+					return ctx.CurrentErrorHandler().Add(tkn, "expected '}' to end object literal")
+				} else if nxt.Type == "," {
+					if peek := it.Peek(); peek != nil && peek.Type == "}" {
+						it.Next()
 						keysRemain = false
-					} else {
-						it.SkipToClosingBracket()
-						return ctx.CurrentErrorHandler().Add(nxt, "expected ',' or '}' following map key-value pair")
 					}
-					// add the key value pair to the result
-					value[id.Name] = valExp
+				} else if nxt.Type == "}" {
+					keysRemain = false
 				} else {
-					// skip to the closing bracket
 					it.SkipToClosingBracket()
-					return ctx.CurrentErrorHandler().Add(it.Current(), "key must be an identifier")
+					return ctx.CurrentErrorHandler().Add(nxt, "expected ',' or '}' following map key-value pair")
 				}
+				// add the key value pair to the result
+				value[id.Name] = valExp
+			} else {
+				// skip to the closing bracket
+				it.SkipToClosingBracket()
+				return ctx.CurrentErrorHandler().Add(it.Current(), "key must be an identifier")
 			}
-			return parseExpression(nxt, ctx, &ast.ObjectLiteral{Value: value})
-		} else {
-			return ctx.CurrentErrorHandler().Add(tkn, fmt.Sprintf("unexpected start of expression: (%d, %s)", ctx.CurrentIterator().Index, tkn.Type))
 		}
+		return &ast.ObjectLiteral{SourceRef: ctx.ref(tkn), Value: value}
 	}
+	return ctx.CurrentErrorHandler().Add(tkn, fmt.Sprintf("unexpected start of expression: (%d, %s)", ctx.CurrentIterator().Index, tkn.Type))
+}
 
-	// look ahead to see if next token is an operator
-	peek := it.Peek()
-	if peek != nil && peek.Type == "operation" {
-		op := it.Next()
-		rStart := it.Next()
-		right := parseExpression(rStart, ctx, nil)
-		operation := &ast.OperationExpression{
-			Operator:        ast.Operator(op.Value),
-			LeftExpression:  exp,
-			RightExpression: right,
-		}
-		return orderOperations(operation)
-	} else if peek != nil && peek.Type == "=" {
-		// assignment
-		idExp, ok := exp.(*ast.Identifier)
-		if !ok {
-			return ctx.CurrentErrorHandler().Add(peek, "expected left side of assignment to be an identifier")
-		}
-
+// parseMember parses the property name on the right side of '.', which is called if it is followed by '('
+func parseMember(tkn *token.Token, ctx *ParseContext) ast.Expression {
+	it := ctx.CurrentIterator()
+	if tkn == nil || tkn.Type != "symbol" {
+		return ctx.CurrentErrorHandler().Add(tkn, "expected property name after '.'")
+	}
+	id := &ast.Identifier{SourceRef: ctx.ref(tkn), Name: tkn.Value}
+	if peek := it.Peek(); peek != nil && peek.Type == "(" {
 		it.Next()
-		val := parseExpression(it.Next(), ctx, nil)
-		return &ast.AssignmentExpression{
-			Identifier: idExp,
-			Value:      val,
+		return parseFunctionCall(id, ctx)
+	}
+	return id
+}
+
+// parseAssignment parses the value assigned to target with either '=' or a compound assignment operator
+func parseAssignment(target ast.Expression, op ast.Operator, opTkn *token.Token, ctx *ParseContext, precedence int) ast.Expression {
+	it := ctx.CurrentIterator()
+	val := parseExpressionWithPrecedence(it.Next(), ctx, precedence)
+	if !isAssignable(target) {
+		return ctx.CurrentErrorHandler().Add(opTkn, "expected left side of assignment to be an identifier, index, or property")
+	}
+	return &ast.AssignmentExpression{
+		SourceRef: ctx.ref(opTkn),
+		Target:    target,
+		Operator:  op,
+		Value:     val,
+	}
+}
+
+// isAssignable returns true if exp is a variable, an element of an array e.g. arr@i, or a property of an object e.g. obj.prop
+func isAssignable(exp ast.Expression) bool {
+	switch t := exp.(type) {
+	case *ast.Identifier:
+		return true
+	case *ast.OperationExpression:
+		if t.Operator == ast.AT {
+			return true
 		}
-	} else if peek != nil && peek.Type == "(" {
-		// function call
-		it.Next()
-		fnCall := parseFunctionCall(exp, ctx)
-		return parseExpression(it.Current(), ctx, fnCall)
-	} else {
-		return exp
+		_, isProperty := t.RightExpression.(*ast.Identifier)
+		return t.Operator == ast.DOT && isProperty
 	}
+	return false
 }
 
-func orderOperations(opExp *ast.OperationExpression) *ast.OperationExpression {
-	// check if the right child is an operator
-	if rightChild, rok := opExp.RightExpression.(*ast.OperationExpression); rok {
-		// if so, check the precendence and reorder the tree
-		if ast.PRECEDENCE[opExp.Operator] > ast.PRECEDENCE[rightChild.Operator] {
-			// copy to avoid modifying the parameter
-			opCopy := &ast.OperationExpression{}
-			err := copier.Copy(&opCopy, &opExp)
-			if err != nil {
-				panic(err)
-			}
+// applyUnary applies a unary operator to exp
+// negative numbers are lexed as '-' followed by the number, so they are combined back into a literal here
+func applyUnary(ref ast.SourceRef, op ast.Operator, exp ast.Expression) ast.Expression {
+	if op == ast.MINUS {
+		switch t := exp.(type) {
+		case *ast.NumberLiteral:
+			return &ast.NumberLiteral{SourceRef: ref, Value: -t.Value}
+		case *ast.IntegerLiteral:
+			return &ast.IntegerLiteral{SourceRef: ref, Value: new(big.Int).Neg(t.Value)}
+		}
+	}
+	return &ast.UnaryExpression{
+		SourceRef:  ref,
+		Operator:   op,
+		Expression: exp,
+	}
+}
 
-			// set the right child as the new parent and parent as left grandchild
-			opCopy.RightExpression = rightChild.LeftExpression
-			rightChild.LeftExpression = opCopy
+// parseInterpolatedString parses the segments and expressions of a string starting with tkn
+func parseInterpolatedString(tkn *token.Token, ctx *ParseContext) ast.Expression {
+	it := ctx.CurrentIterator()
+	parts := make([]ast.Expression, 0)
+	if tkn.Value != "" {
+		parts = append(parts, &ast.StringLiteral{SourceRef: ctx.ref(tkn), Value: tkn.Value})
+	}
+	for {
+		// each segment is followed by an expression which ends at the next segment
+		end := nextStringSegment(it)
+		if end >= len(it.Tokens) {
+			it.Index = len(it.Tokens) - 1
+			return ctx.CurrentErrorHandler().Add(tkn, "expected end of interpolated string")
+		}
+		if end == it.Index+1 {
+			it.Index = end
+			return ctx.CurrentErrorHandler().Add(it.Current(), "expected expression in string interpolation")
+		}
+		parts = append(parts, parseExpression(it.Next(), ctx))
+		if it.Index < end-1 {
+			errTkn := it.AtIndex(it.Index + 1)
+			it.Index = end
+			return ctx.CurrentErrorHandler().Add(errTkn, "expected ')' to end interpolated expression")
+		}
+		// an expression with errors may have been parsed past the segment
+		it.Index = end - 1
 
-			// recurse to order the right expression
-			rightChild.LeftExpression = orderOperations(rightChild.LeftExpression.(*ast.OperationExpression))
+		// add the next segment of the string
+		nxt := it.Next()
+		if nxt.Value != "" {
+			parts = append(parts, &ast.StringLiteral{SourceRef: ctx.ref(nxt), Value: nxt.Value})
+		}
+		if nxt.Type == "string_end" {
+			return &ast.InterpolatedString{SourceRef: ctx.ref(tkn), Parts: parts}
+		}
+	}
+}
 
-			// return the new operation
-			return rightChild
+// nextStringSegment returns the index of the next segment of the string being parsed, skipping any nested strings
+func nextStringSegment(it *lexer.TokenIterator) int {
+	var depth int
+	for i := it.Index + 1; i < len(it.Tokens); i++ {
+		switch it.Tokens[i].Type {
+		case "string_start":
+			depth += 1
+		case "string_middle":
+			if depth == 0 {
+				return i
+			}
+		case "string_end":
+			if depth == 0 {
+				return i
+			}
+			depth -= 1
 		}
 	}
-	return opExp
+	return len(it.Tokens)
 }
 
 func parseVarDeclaration(tkn *token.Token, ctx *ParseContext) ast.Expression {
 	it := ctx.CurrentIterator()
-	decl := &ast.VariableDecleration{}
+	decl := &ast.VariableDecleration{SourceRef: ctx.ref(tkn)}
+	if peek := it.Peek(); peek != nil && (peek.Type == "[" || peek.Type == "{") {
+		return parseDestructuringDeclaration(tkn, ctx)
+	}
 	if spec := it.Next(); spec.Type != "(" {
 		it.SkipStatement()
 		return ctx.CurrentErrorHandler().Add(spec, "expected '(' after var")
 	}
 
 	t := it.Next()
-	dataType := ast.Symbol(t.Value)
-	if t.Type != "symbol" || !dataType.IsDataType() {
+	typeName, ok := parseDataType(t, ctx)
+	if !ok {
 		it.SkipStatement()
 		return ctx.CurrentErrorHandler().Add(t, "expected data type after (")
 	}
-	decl.SymbolType = t.Value
+	dataType := ast.Symbol(typeName)
+	decl.SymbolType = typeName
 
 	if spec := it.Next(); spec.Type != ")" {
 		it.SkipStatement()
@@ -205,7 +354,7 @@ func parseVarDeclaration(tkn *token.Token, ctx *ParseContext) ast.Expression {
 		return ctx.CurrentErrorHandler().Add(sym, "expected identifier")
 	}
 	// TODO: this won't work properly. Create another method for reserved words
-	if s := ast.Symbol(sym.Value); s.IsStatementPrefix() || s.IsDataType() {
+	if s := ast.Symbol(sym.Value); s.IsStatementPrefix() || ctx.isDataType(sym.Value) {
 		it.SkipStatement()
 		return ctx.CurrentErrorHandler().Add(sym, fmt.Sprintf("cannot use variable name '%s' as it is a reserved word", s))
 	}
@@ -224,6 +373,42 @@ func parseVarDeclaration(tkn *token.Token, ctx *ParseContext) ast.Expression {
 	return decl
 }
 
+// parseDestructuringDeclaration parses a declaration of the variables in an array or object pattern
+// e.g. var [num a, num b] = exp
+func parseDestructuringDeclaration(tkn *token.Token, ctx *ParseContext) ast.Expression {
+	it := ctx.CurrentIterator()
+	pattern, ok := parseDestructuringPattern(it.Next(), ctx, true)
+	if !ok {
+		it.SkipStatement()
+		return pattern.(*ast.ErrorNode)
+	}
+	if spec := it.Next(); spec == nil || spec.Type != "=" {
+		it.SkipStatement()
+		return ctx.CurrentErrorHandler().Add(spec, "expected '=' after pattern")
+	}
+	return &ast.DestructuringDeclaration{
+		SourceRef: ctx.ref(tkn),
+		Pattern:   pattern,
+		Value:     parseExpression(it.Next(), ctx),
+	}
+}
+
+// parseDataType parses the data type starting with tkn, including the '?' which makes it optional e.g. str?
+// the data type may be built-in, or a type declared earlier in the file or imported into it
+// false is returned if tkn isn't a data type
+func parseDataType(tkn *token.Token, ctx *ParseContext) (string, bool) {
+	if tkn == nil || tkn.Type != "symbol" || !ctx.isDataType(tkn.Value) {
+		return "", false
+	}
+	// void can't be optional, so its '?' is left to be reported as unexpected
+	it := ctx.CurrentIterator()
+	if peek := it.Peek(); peek != nil && peek.Type == "?" && tkn.Value != ast.VOID {
+		it.Next()
+		return tkn.Value + "?", true
+	}
+	return tkn.Value, true
+}
+
 func parseFunction(tkn *token.Token, ctx *ParseContext) ast.Expression {
 	it := ctx.CurrentIterator()
 	// expect ( return type )
@@ -234,13 +419,13 @@ func parseFunction(tkn *token.Token, ctx *ParseContext) ast.Expression {
 		return ctx.CurrentErrorHandler().Add(tkn, "expected '('")
 	}
 	nxt := it.Next()
-	if nxt == nil || nxt.Type != "symbol" || !ast.Symbol(nxt.Value).IsDataType() {
+	returnType, ok := parseDataType(nxt, ctx)
+	if !ok {
 		// skip to the opening bracket, and then the closing one
 		it.SkipTo(token.Token{Type: "{", Value: "{"})
 		it.SkipToClosingBracket()
 		return ctx.CurrentErrorHandler().Add(nxt, "expected data type")
 	}
-	returnType := nxt.Value
 
 	nxt = it.Next()
 	if nxt == nil || nxt.Type != ")" {
@@ -259,6 +444,24 @@ func parseFunction(tkn *token.Token, ctx *ParseContext) ast.Expression {
 		symbol = it.Next().Value
 	}
 
+	// a method is named after the type it belongs to e.g. Point.len
+	var receiver string
+	if peek = it.Peek(); symbol != "" && peek != nil && peek.Type == "operation" && peek.Value == ast.DOT {
+		it.Next()
+		receiver = symbol
+		if nxt = it.Next(); nxt == nil || nxt.Type != "symbol" {
+			it.SkipTo(token.Token{Type: "{", Value: "{"})
+			it.SkipToClosingBracket()
+			return ctx.CurrentErrorHandler().Add(nxt, "expected method name")
+		}
+		symbol = nxt.Value
+		if !ctx.isRecordType(receiver) {
+			it.SkipTo(token.Token{Type: "{", Value: "{"})
+			it.SkipToClosingBracket()
+			return ctx.CurrentErrorHandler().Add(nxt, fmt.Sprintf("cannot declare method '%s' on '%s' which is not a declared type", symbol, receiver))
+		}
+	}
+
 	// expect ( parameter, parameter, ... )
 	params := make([]*ast.VariableDecleration, 0)
 	if nxt = it.Next(); nxt == nil || nxt.Type != "(" {
@@ -275,13 +478,13 @@ func parseFunction(tkn *token.Token, ctx *ParseContext) ast.Expression {
 			nxt = it.Next()
 		}
 		// first expect data type
-		if !ast.Symbol(nxt.Value).IsDataType() {
+		dataType, ok := parseDataType(nxt, ctx)
+		if !ok {
 			// skip to the opening bracket, and then the closing one
 			it.SkipTo(token.Token{Type: "{", Value: "{"})
 			it.SkipToClosingBracket()
 			return ctx.CurrentErrorHandler().Add(nxt, "expected data type for parameter")
 		}
-		dataType := nxt.Value
 
 		// next expect symbol
 		nxt = it.Next()
@@ -291,30 +494,134 @@ func parseFunction(tkn *token.Token, ctx *ParseContext) ast.Expression {
 			it.SkipToClosingBracket()
 			return ctx.CurrentErrorHandler().Add(nxt, "expected parameter name")
 		}
-		paramName := nxt.Value
-		params = append(params, &ast.VariableDecleration{
-			Symbol:     paramName,
+		param := &ast.VariableDecleration{
+			SourceRef:  ctx.ref(nxt),
+			Symbol:     nxt.Value,
 			SymbolType: dataType,
-		})
+		}
+
+		// a parameter may take the rest of the arguments, or have a default value e.g. num nums... or num step = 1
+		if peek := it.Peek(); peek != nil && peek.Type == "..." {
+			it.Next()
+			param.Variadic = true
+			if peek := it.Peek(); peek == nil || peek.Type != ")" {
+				it.SkipTo(token.Token{Type: "{", Value: "{"})
+				it.SkipToClosingBracket()
+				return ctx.CurrentErrorHandler().Add(nxt, fmt.Sprintf("expected variadic parameter '%s' to be the last parameter", param.Symbol))
+			}
+		} else if peek != nil && peek.Type == "=" {
+			it.Next()
+			param.Value = parseExpression(it.Next(), ctx)
+		} else if len(params) > 0 && params[len(params)-1].Value != nil {
+			it.SkipTo(token.Token{Type: "{", Value: "{"})
+			it.SkipToClosingBracket()
+			return ctx.CurrentErrorHandler().Add(nxt, fmt.Sprintf("expected a default value for '%s' since it follows a parameter with one", param.Symbol))
+		}
+		params = append(params, param)
+	}
+
+	// the first parameter of a method is the record it was called on
+	if receiver != "" && (len(params) == 0 || params[0].SymbolType != receiver) {
+		it.SkipTo(token.Token{Type: "{", Value: "{"})
+		it.SkipToClosingBracket()
+		return ctx.CurrentErrorHandler().Add(tkn, fmt.Sprintf("expected the first parameter of method '%s' to be of type %s", symbol, receiver))
 	}
 
 	// parse the statement that follows
+	// loops outside of the function can't be controlled from inside of it
+	loopDepth, tryDepth, outerReturnType := ctx.loopDepth, ctx.tryDepth, ctx.returnType
+	ctx.loopDepth, ctx.tryDepth, ctx.returnType = 0, 0, returnType
 	body := parseStatement(it.Next(), ctx)
+	ctx.loopDepth, ctx.tryDepth, ctx.returnType = loopDepth, tryDepth, outerReturnType
 	return &ast.FunctionLiteral{
+		SourceRef:  ctx.ref(tkn),
 		Symbol:     symbol,
+		Receiver:   receiver,
 		ReturnType: returnType,
 		Parameters: params,
 		Body:       body,
 	}
 }
 
+// parseTypeDeclaration parses a record type with named, typed fields e.g. type Point { num x, num y }
+func parseTypeDeclaration(tkn *token.Token, ctx *ParseContext) ast.Expression {
+	it := ctx.CurrentIterator()
+	sym := it.Next()
+	if sym == nil || sym.Type != "symbol" {
+		it.SkipTo(token.Token{Type: "{", Value: "{"})
+		it.SkipToClosingBracket()
+		return ctx.CurrentErrorHandler().Add(tkn, "expected type name")
+	}
+	if s := ast.Symbol(sym.Value); s.IsStatementPrefix() || s.IsDataType() || s == ast.VAR || s == ast.TYPE {
+		it.SkipTo(token.Token{Type: "{", Value: "{"})
+		it.SkipToClosingBracket()
+		return ctx.CurrentErrorHandler().Add(sym, fmt.Sprintf("cannot use type name '%s' as it is a reserved word", s))
+	}
+	decl := &ast.TypeDeclaration{SourceRef: ctx.ref(tkn), Symbol: sym.Value, Fields: make([]*ast.VariableDecleration, 0)}
+	// the type is declared before its fields so a field can refer to it e.g. Node? next
+	ctx.declareType(ctx.CurrentFilePath(), decl.Symbol)
+
+	if nxt := it.Next(); nxt == nil || nxt.Type != "{" {
+		it.SkipStatement()
+		return ctx.CurrentErrorHandler().Add(sym, "expected '{' after type name")
+	}
+	// expect { data type symbol, data type symbol, ... } with an optional trailing ','
+	for nxt := it.Next(); nxt == nil || nxt.Type != "}"; nxt = it.Next() {
+		if nxt == nil {
+			return ctx.CurrentErrorHandler().Add(tkn, "unexpected end of file")
+		}
+		dataType, ok := parseDataType(nxt, ctx)
+		if !ok {
+			it.SkipToClosingBracket()
+			return ctx.CurrentErrorHandler().Add(nxt, "expected data type for field")
+		}
+		name := it.Next()
+		if name == nil || name.Type != "symbol" {
+			if name != nil && name.Type != "}" {
+				it.SkipToClosingBracket()
+			}
+			return ctx.CurrentErrorHandler().Add(name, "expected field name")
+		}
+		for _, f := range decl.Fields {
+			if f.Symbol == name.Value {
+				it.SkipToClosingBracket()
+				return ctx.CurrentErrorHandler().Add(name, fmt.Sprintf("field '%s' is already declared", name.Value))
+			}
+		}
+		decl.Fields = append(decl.Fields, &ast.VariableDecleration{
+			SourceRef:  ctx.ref(name),
+			Symbol:     name.Value,
+			SymbolType: dataType,
+		})
+
+		if peek := it.Peek(); peek != nil && peek.Type == "," {
+			it.Next()
+		} else if peek == nil || peek.Type != "}" {
+			it.SkipToClosingBracket()
+			return ctx.CurrentErrorHandler().Add(name, "expected ',' or '}' after field")
+		}
+	}
+	return decl
+}
+
 func parseFunctionCall(exp ast.Expression, ctx *ParseContext) ast.Expression {
 	it := ctx.CurrentIterator()
 	var args []ast.Expression
+	ref := ctx.ref(it.Current())
 	nxt := it.Next()
+	named := false
 	for nxt.Type != ")" {
-		exp := parseExpression(nxt, ctx, nil)
-		args = append(args, exp)
+		// an argument may be passed by the name of its parameter e.g. range(0, end: 10)
+		if peek := it.Peek(); nxt.Type == "symbol" && peek != nil && peek.Type == ":" {
+			it.Next()
+			named = true
+			args = append(args, &ast.NamedArgument{SourceRef: ctx.ref(nxt), Name: nxt.Value, Value: parseExpression(it.Next(), ctx)})
+		} else if named {
+			it.SkipTo(token.Token{Type: ")", Value: ")"})
+			return ctx.CurrentErrorHandler().Add(nxt, "expected named argument since positional arguments must come before named ones")
+		} else {
+			args = append(args, parseExpression(nxt, ctx))
+		}
 		nxt = it.Next()
 		if nxt == nil || nxt.Type != "," && nxt.Type != ")" {
 			it.SkipTo(token.Token{Type: ")", Value: ")"})
@@ -325,18 +632,24 @@ func parseFunctionCall(exp ast.Expression, ctx *ParseContext) ast.Expression {
 		}
 	}
 	return &ast.FunctionCall{
+		SourceRef: ref,
 		Function:  exp,
 		Arguments: args,
 	}
 }
 
 func parseAssignmentExpression(tkn *token.Token, dataType ast.Symbol, ctx *ParseContext) ast.Expression {
-	exp := parseExpression(tkn, ctx, nil)
+	exp := parseExpression(tkn, ctx)
+	// only optional types can be initialized to nil
+	if _, ok := exp.(*ast.NilLiteral); ok && dataType.IsOptional() {
+		return exp
+	}
+	dataType = dataType.BaseType()
 	if dataType == ast.NUM {
 		if _, ok := exp.(*ast.NumberLiteral); ok {
 			return exp
 		} else if intLit, ok := exp.(*ast.IntegerLiteral); ok {
-			return &ast.NumberLiteral{Value: float64(intLit.Value.Int64())}
+			return &ast.NumberLiteral{SourceRef: intLit.SourceRef, Value: float64(intLit.Value.Int64())}
 		}
 	} else if dataType == ast.INT {
 		if _, ok := exp.(*ast.IntegerLiteral); ok {
@@ -346,6 +659,9 @@ func parseAssignmentExpression(tkn *token.Token, dataType ast.Symbol, ctx *Parse
 		if _, ok := exp.(*ast.StringLiteral); ok {
 			return exp
 		}
+		if _, ok := exp.(*ast.InterpolatedString); ok {
+			return exp
+		}
 	} else if dataType == ast.BOOL {
 		if _, ok := exp.(*ast.BooleanLiteral); ok {
 			return exp
@@ -363,15 +679,25 @@ func parseAssignmentExpression(tkn *token.Token, dataType ast.Symbol, ctx *Parse
 			return exp
 		}
 	}
+	// the types of these can only be checked once they're evaluated
+	if _, ok := exp.(*ast.Identifier); ok {
+		return exp
+	}
 	if _, ok := exp.(*ast.OperationExpression); ok {
 		return exp
 	}
+	if _, ok := exp.(*ast.UnaryExpression); ok {
+		return exp
+	}
 	if _, ok := exp.(*ast.FunctionCall); ok {
 		return exp
 	}
 	if _, ok := exp.(*ast.GroupExpression); ok {
 		return exp
 	}
+	if _, ok := exp.(*ast.MatchExpression); ok {
+		return exp
+	}
 	if _, ok := exp.(*ast.FunctionCall); ok {
 		return exp
 	}
@@ -0,0 +1,40 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/mcjcloud/taurine/pkg/ast"
+)
+
+func TestParseEmptyObject(t *testing.T) {
+	tree, handler := parse(t, "var (obj) o = {};\netch {}, {a: {}};")
+	if len(handler.Errors) > 0 {
+		t.Fatalf("unexpected errors: %q", messages(handler.Errors))
+	}
+	decl := tree.Statement.(*ast.BlockStatement).Statements[0].(*ast.ExpressionStatement).Expression.(*ast.VariableDecleration)
+	if obj, ok := decl.Value.(*ast.ObjectLiteral); !ok || len(obj.Value) != 0 {
+		t.Errorf("expected an empty object literal but found %s", decl.Value)
+	}
+}
+
+func TestParseIncompleteExpressions(t *testing.T) {
+	tests := []struct {
+		src string
+		msg string
+	}{
+		{"etch 1 +;", "expected expression but found ';'"},
+		{"var (int) a = 2 * ;\netch a;", "expected expression but found ';'"},
+		{"var (int) a = 1;\na = ;", "expected expression but found ';'"},
+		{"etch 1,\n", "expected expression but found end of file"},
+		{"etch 1 +\n", "expected expression but found end of file"},
+		{"etch [1,\n", "expected expression but found end of file"},
+		{"var (obj) o = {a: 1\n", "expected '}' to end object literal"},
+		{"var (obj) o = {a\n", "expected ':' after identifer"},
+	}
+	for _, test := range tests {
+		_, handler := parse(t, test.src)
+		if msgs := messages(handler.Errors); len(msgs) == 0 || msgs[0] != test.msg {
+			t.Errorf("expected %q for %q but found %q", test.msg, test.src, msgs)
+		}
+	}
+}
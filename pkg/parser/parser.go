@@ -18,13 +18,13 @@ func Parse(ctx *ParseContext) *ast.Ast {
 			block.Statements = append(block.Statements, stmt)
 		} else {
 			// expression
-			exp := parseExpression(tkn, ctx, nil)
+			exp := parseExpression(tkn, ctx)
 			// TODO: should probably expect a semicolon here? do some tests.
-			block.Statements = append(block.Statements, &ast.ExpressionStatement{Expression: exp})
-			// if the expression is not a function, expect an ending semicolon
-			if _, ok := exp.(*ast.FunctionLiteral); !ok {
+			block.Statements = append(block.Statements, &ast.ExpressionStatement{SourceRef: ctx.ref(tkn), Expression: exp})
+			// if the expression doesn't end with a block, expect an ending semicolon
+			if !endsWithBlock(exp) {
 				errTkn := it.Current()
-				if tkn = it.Next(); tkn.Type != ";" {
+				if tkn = it.Next(); tkn == nil || tkn.Type != ";" {
 					handler.Add(errTkn, "expected semicolon to end statement")
 					continue
 				}
@@ -32,9 +32,10 @@ func Parse(ctx *ParseContext) *ast.Ast {
 		}
 		tkn = it.Next()
 	}
+	// bind each identifier to the variable it refers to before anything runs
+	resolveFile(ctx, block)
 	return &ast.Ast{
 		FilePath:  ctx.CurrentFilePath(),
 		Statement: block,
-		Exports:   make(map[string]ast.Expression),
 	}
 }
@@ -0,0 +1,318 @@
+package parser
+
+import (
+	"fmt"
+
+	"github.com/mcjcloud/taurine/pkg/ast"
+	"github.com/mcjcloud/taurine/pkg/token"
+)
+
+// parseMatchExpression parses a match starting with the match keyword
+// e.g. match value { pattern => expression, pattern => { statements } }
+func parseMatchExpression(tkn *token.Token, ctx *ParseContext) ast.Expression {
+	it := ctx.CurrentIterator()
+	match := &ast.MatchExpression{SourceRef: ctx.ref(tkn), Value: parseExpression(it.Next(), ctx), Arms: make([]*ast.MatchArm, 0)}
+	if nxt := it.Next(); nxt == nil || nxt.Type != "{" {
+		return ctx.CurrentErrorHandler().Add(it.Current(), "expected '{' after match value")
+	}
+
+	for nxt := it.Next(); nxt == nil || nxt.Type != "}"; nxt = it.Next() {
+		if nxt == nil {
+			return ctx.CurrentErrorHandler().Add(tkn, "expected '}' to end match but found end of file")
+		}
+		arm := &ast.MatchArm{SourceRef: ctx.ref(nxt)}
+		pattern, ok := parsePattern(nxt, ctx)
+		if !ok {
+			it.SkipToClosingBracket()
+			return pattern.(*ast.ErrorNode)
+		}
+		arm.Pattern = pattern
+		if arrow := it.Next(); arrow == nil || arrow.Type != "=>" {
+			it.SkipToClosingBracket()
+			return ctx.CurrentErrorHandler().Add(arrow, "expected '=>' after pattern")
+		}
+
+		// an arm is either a block or an expression, which must be followed by ',' unless it's the last arm
+		if body := it.Next(); body != nil && body.Type == "{" {
+			arm.Body = parseStatement(body, ctx)
+			if peek := it.Peek(); peek != nil && peek.Type == "," {
+				it.Next()
+			}
+		} else {
+			arm.Value = parseExpression(body, ctx)
+			if peek := it.Peek(); peek != nil && peek.Type == "," {
+				it.Next()
+			} else if peek == nil || peek.Type != "}" {
+				it.SkipToClosingBracket()
+				return ctx.CurrentErrorHandler().Add(it.Current(), "expected ',' or '}' after match arm")
+			}
+		}
+		match.Arms = append(match.Arms, arm)
+	}
+	return match
+}
+
+// parsePattern parses the pattern starting with tkn
+// false is returned along with an *ast.ErrorNode if the pattern couldn't be parsed
+func parsePattern(tkn *token.Token, ctx *ParseContext) (ast.Pattern, bool) {
+	it := ctx.CurrentIterator()
+	handler := ctx.CurrentErrorHandler()
+	if tkn == nil {
+		return handler.Add(it.AtIndex(len(it.Tokens)-1), "expected pattern but found end of file"), false
+	}
+
+	switch tkn.Type {
+	case "symbol":
+		if s := ast.Symbol(tkn.Value); s.IsStatementPrefix() || ctx.isDataType(tkn.Value) {
+			return handler.Add(tkn, fmt.Sprintf("cannot use variable name '%s' as it is a reserved word", s)), false
+		}
+		return &ast.BindingPattern{SourceRef: ctx.ref(tkn), Symbol: tkn.Value}, true
+	case "number", "integer", "string", "bool", "nil", "operation":
+		return parseLiteralPattern(tkn, ctx)
+	case "(":
+		// (type) name
+		t := it.Next()
+		dataType, ok := parseDataType(t, ctx)
+		if !ok {
+			return handler.Add(t, "expected data type in pattern"), false
+		}
+		if nxt := it.Next(); nxt == nil || nxt.Type != ")" {
+			return handler.Add(nxt, "expected ')' after data type"), false
+		}
+		sym := it.Next()
+		if sym == nil || sym.Type != "symbol" {
+			return handler.Add(sym, "expected identifier after data type"), false
+		}
+		return &ast.TypePattern{SourceRef: ctx.ref(tkn), Symbol: sym.Value, SymbolType: dataType}, true
+	case "[":
+		arr := &ast.ArrayPattern{SourceRef: ctx.ref(tkn), Elements: make([]ast.Pattern, 0)}
+		for nxt := it.Next(); nxt == nil || nxt.Type != "]"; nxt = it.Next() {
+			el, ok := parsePattern(nxt, ctx)
+			if !ok {
+				return el, false
+			}
+			if peek := it.Peek(); peek != nil && peek.Type == "..." {
+				if rest, ok := parseRest(el, ctx); !ok {
+					return rest, false
+				}
+				arr.Rest = el
+				continue
+			}
+			arr.Elements = append(arr.Elements, el)
+			if peek := it.Peek(); peek != nil && peek.Type == "," {
+				it.Next()
+			} else if peek == nil || peek.Type != "]" {
+				return handler.Add(it.Current(), "expected ',' or ']' in array pattern"), false
+			}
+		}
+		return arr, true
+	case "{":
+		obj := &ast.ObjectPattern{SourceRef: ctx.ref(tkn), Properties: make([]*ast.PropertyPattern, 0)}
+		for nxt := it.Next(); nxt == nil || nxt.Type != "}"; nxt = it.Next() {
+			if nxt == nil || nxt.Type != "symbol" {
+				return handler.Add(nxt, "key must be an identifier"), false
+			}
+			prop := &ast.PropertyPattern{SourceRef: ctx.ref(nxt), Key: nxt.Value}
+			if peek := it.Peek(); peek != nil && peek.Type == ":" {
+				it.Next()
+				p, ok := parsePattern(it.Next(), ctx)
+				if !ok {
+					return p, false
+				}
+				prop.Pattern = p
+			} else {
+				// {name} is short for {name: name}
+				prop.Pattern = &ast.BindingPattern{SourceRef: ctx.ref(nxt), Symbol: nxt.Value}
+			}
+			obj.Properties = append(obj.Properties, prop)
+			if peek := it.Peek(); peek != nil && peek.Type == "," {
+				it.Next()
+			} else if peek == nil || peek.Type != "}" {
+				return handler.Add(it.Current(), "expected ',' or '}' in object pattern"), false
+			}
+		}
+		return obj, true
+	}
+	return handler.Add(tkn, "expected pattern"), false
+}
+
+// parseLiteralPattern parses a literal, or a range of numbers if the literal is followed by '..'
+func parseLiteralPattern(tkn *token.Token, ctx *ParseContext) (ast.Pattern, bool) {
+	it := ctx.CurrentIterator()
+	start, ok := parsePatternLiteral(tkn, ctx)
+	if !ok {
+		return start.(*ast.ErrorNode), false
+	}
+	if peek := it.Peek(); peek == nil || peek.Type != "operation" || peek.Value != ast.RANGE {
+		return &ast.LiteralPattern{SourceRef: ctx.ref(tkn), Value: start}, true
+	}
+
+	// both ends of a range are numbers
+	opTkn := it.Next()
+	end, ok := parsePatternLiteral(it.Next(), ctx)
+	if !ok {
+		return end.(*ast.ErrorNode), false
+	}
+	if !isNumberLiteral(start) || !isNumberLiteral(end) {
+		return ctx.CurrentErrorHandler().Add(opTkn, "expected a range pattern to be between two numbers"), false
+	}
+	return &ast.RangePattern{SourceRef: ctx.ref(tkn), Start: start, End: end}, true
+}
+
+// parsePatternLiteral parses a literal in a pattern, which may be a negative number
+func parsePatternLiteral(tkn *token.Token, ctx *ParseContext) (ast.Expression, bool) {
+	if tkn == nil {
+		return ctx.CurrentErrorHandler().Add(ctx.CurrentIterator().Current(), "expected literal but found end of file"), false
+	}
+	if tkn.Type == "operation" && tkn.Value == ast.MINUS {
+		num := ctx.CurrentIterator().Next()
+		if num == nil || (num.Type != "number" && num.Type != "integer") {
+			return ctx.CurrentErrorHandler().Add(tkn, "expected number after '-' in pattern"), false
+		}
+		return applyUnary(ctx.ref(tkn), ast.MINUS, parsePrefixExpression(num, ctx)), true
+	}
+	switch tkn.Type {
+	case "number", "integer", "string", "bool", "nil":
+		return parsePrefixExpression(tkn, ctx), true
+	}
+	return ctx.CurrentErrorHandler().Add(tkn, "expected pattern"), false
+}
+
+// isNumberLiteral returns true if exp is a num or int literal
+func isNumberLiteral(exp ast.Expression) bool {
+	switch exp.(type) {
+	case *ast.NumberLiteral, *ast.IntegerLiteral:
+		return true
+	}
+	return false
+}
+
+// parseRest parses the '...' following el, which must be the variable at the end of an array pattern
+// false is returned along with an *ast.ErrorNode if el can't hold the rest of an array
+func parseRest(el ast.Pattern, ctx *ParseContext) (ast.Pattern, bool) {
+	it := ctx.CurrentIterator()
+	dots := it.Next()
+	switch t := el.(type) {
+	case *ast.BindingPattern:
+	case *ast.TypePattern:
+		if ast.Symbol(t.SymbolType).BaseType() != ast.ARR {
+			return ctx.CurrentErrorHandler().Add(dots, fmt.Sprintf("expected the rest of an array to be of type arr but found %s", t.SymbolType)), false
+		}
+	default:
+		return ctx.CurrentErrorHandler().Add(dots, "expected a variable before '...'"), false
+	}
+	if peek := it.Peek(); peek == nil || peek.Type != "]" {
+		return ctx.CurrentErrorHandler().Add(dots, "expected ']' after '...' since it must be the last element of an array pattern"), false
+	}
+	return el, true
+}
+
+// parseDestructuringPattern parses the array or object pattern of a destructuring declaration or for loop
+// e.g. [num a, num b, arr rest...] or {str name, num age, address: {str city}}
+// if typed is true, each variable must be declared with a data type
+func parseDestructuringPattern(tkn *token.Token, ctx *ParseContext, typed bool) (ast.Pattern, bool) {
+	it := ctx.CurrentIterator()
+	handler := ctx.CurrentErrorHandler()
+	if tkn.Type == "[" {
+		arr := &ast.ArrayPattern{SourceRef: ctx.ref(tkn), Elements: make([]ast.Pattern, 0)}
+		for nxt := it.Next(); nxt == nil || nxt.Type != "]"; nxt = it.Next() {
+			el, ok := parseDestructuringElement(nxt, ctx, typed)
+			if !ok {
+				return el, false
+			}
+			if peek := it.Peek(); peek != nil && peek.Type == "..." {
+				if rest, ok := parseRest(el, ctx); !ok {
+					return rest, false
+				}
+				arr.Rest = el
+				continue
+			}
+			arr.Elements = append(arr.Elements, el)
+			if peek := it.Peek(); peek != nil && peek.Type == "," {
+				it.Next()
+			} else if peek == nil || peek.Type != "]" {
+				return handler.Add(it.Current(), "expected ',' or ']' in array pattern"), false
+			}
+		}
+		return arr, true
+	}
+
+	obj := &ast.ObjectPattern{SourceRef: ctx.ref(tkn), Properties: make([]*ast.PropertyPattern, 0)}
+	for nxt := it.Next(); nxt == nil || nxt.Type != "}"; nxt = it.Next() {
+		if nxt == nil || nxt.Type != "symbol" {
+			return handler.Add(nxt, "expected property in object pattern"), false
+		}
+		prop := &ast.PropertyPattern{SourceRef: ctx.ref(nxt)}
+		if peek := it.Peek(); peek != nil && peek.Type == ":" {
+			// key: pattern
+			prop.Key = nxt.Value
+			it.Next()
+			p, ok := parseDestructuringElement(it.Next(), ctx, typed)
+			if !ok {
+				return p, false
+			}
+			prop.Pattern = p
+		} else {
+			// a variable is declared with the name of the property
+			p, ok := parseDestructuringElement(nxt, ctx, typed)
+			if !ok {
+				return p, false
+			}
+			switch t := p.(type) {
+			case *ast.TypePattern:
+				prop.Key = t.Symbol
+			case *ast.BindingPattern:
+				prop.Key = t.Symbol
+			}
+			if prop.Key == ast.WILDCARD {
+				return handler.Add(nxt, "expected property name in object pattern"), false
+			}
+			prop.Pattern = p
+		}
+		obj.Properties = append(obj.Properties, prop)
+		if peek := it.Peek(); peek != nil && peek.Type == "," {
+			it.Next()
+		} else if peek == nil || peek.Type != "}" {
+			return handler.Add(it.Current(), "expected ',' or '}' in object pattern"), false
+		}
+	}
+	return obj, true
+}
+
+// parseDestructuringElement parses a variable or nested pattern in a destructuring pattern
+// a variable is declared with its data type e.g. num a, which may be left off if typed is false
+// _ skips an element without declaring a variable
+func parseDestructuringElement(tkn *token.Token, ctx *ParseContext, typed bool) (ast.Pattern, bool) {
+	handler := ctx.CurrentErrorHandler()
+	if tkn == nil {
+		return handler.Add(ctx.CurrentIterator().Current(), "expected pattern but found end of file"), false
+	}
+	if tkn.Type == "[" || tkn.Type == "{" {
+		return parseDestructuringPattern(tkn, ctx, typed)
+	}
+	if tkn.Type != "symbol" {
+		return handler.Add(tkn, "expected variable in pattern"), false
+	}
+	if tkn.Value == ast.WILDCARD {
+		return &ast.BindingPattern{SourceRef: ctx.ref(tkn), Symbol: tkn.Value}, true
+	}
+
+	dataType, ok := parseDataType(tkn, ctx)
+	if !ok {
+		if typed {
+			return handler.Add(tkn, fmt.Sprintf("expected data type before '%s'", tkn.Value)), false
+		}
+		if ast.Symbol(tkn.Value).IsStatementPrefix() {
+			return handler.Add(tkn, fmt.Sprintf("cannot use variable name '%s' as it is a reserved word", tkn.Value)), false
+		}
+		return &ast.BindingPattern{SourceRef: ctx.ref(tkn), Symbol: tkn.Value}, true
+	}
+	sym := ctx.CurrentIterator().Next()
+	if sym == nil || sym.Type != "symbol" {
+		return handler.Add(sym, "expected identifier"), false
+	}
+	if s := ast.Symbol(sym.Value); s.IsStatementPrefix() || ctx.isDataType(sym.Value) {
+		return handler.Add(sym, fmt.Sprintf("cannot use variable name '%s' as it is a reserved word", s)), false
+	}
+	return &ast.TypePattern{SourceRef: ctx.ref(tkn), Symbol: sym.Value, SymbolType: dataType}, true
+}
@@ -0,0 +1,446 @@
+package parser
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/mcjcloud/taurine/pkg/ast"
+	"github.com/mcjcloud/taurine/pkg/token"
+	"github.com/mcjcloud/taurine/pkg/util"
+)
+
+// scope is one of the scopes the evaluator creates while running a file, whose variables are numbered by slot
+type scope struct {
+	parent     *scope
+	names      map[string]*variable
+	slots      int
+	frame      bool               // true if the scope holds the parameters of a function call
+	declared   int                // for a frame, the number of variables the resolver had declared when the function was declared
+	pending    []*pendingFunction // functions declared in the scope, whose bodies are resolved when it ends
+	unresolved []*reference       // identifiers in or below the scope which were used before a variable of their name was declared
+}
+
+// pendingFunction is a function whose body hasn't been resolved yet
+type pendingFunction struct {
+	fn       *ast.FunctionLiteral
+	declared int // the number of variables the resolver had declared when the function was declared
+}
+
+// variable is a variable declared in a scope
+type variable struct {
+	slot     int
+	order    int  // the number of variables the resolver had declared once it declared this one, or 0 if it was declared outside of the file
+	function bool // true if the variable was declared by a named function, which can be declared again to replace it
+	earlier  bool // true if the variable was declared by an earlier input of the repl, which can declare it again
+}
+
+// reference is an identifier whose variable hasn't been declared yet
+type reference struct {
+	id    *ast.Identifier
+	scope *scope // the scope the identifier is used in
+}
+
+// resolver binds each identifier in a file to a variable by the number of scopes up it was declared and its slot in that scope
+// its scopes match the ones the evaluator creates: the file, each block, each iteration of a for loop, the body of a while loop,
+// a catch clause, each call of a function, and each arm of a match
+type resolver struct {
+	handler     *util.ErrorHandler
+	scope       *scope
+	interactive bool // true if a later input of the repl can declare a variable used by a function in this one
+	declared    int  // the number of variables declared so far
+}
+
+func newScope(parent *scope, frame bool) *scope {
+	return &scope{parent: parent, names: make(map[string]*variable), frame: frame}
+}
+
+// resolveFile resolves the top level statements of the file being parsed, unless it already has syntax errors
+// the top level scope of the main file is kept once it has no errors, so each input of the repl can use the variables of the ones before it
+func resolveFile(ctx *ParseContext, block *ast.BlockStatement) {
+	handler := ctx.CurrentErrorHandler()
+	if len(handler.Errors) > 0 {
+		return
+	}
+	path := ctx.CurrentFilePath()
+	main := path == ctx.MainPath
+	global := newScope(ctx.builtins, false)
+	if main && ctx.globals != nil {
+		global = ctx.globals.clone()
+	}
+
+	r := &resolver{handler: handler, scope: global, interactive: main && ctx.Interactive}
+	for _, stmt := range block.Statements {
+		r.statement(stmt)
+	}
+	r.endFile()
+
+	if !main {
+		return
+	}
+	if ctx.HasErrors() {
+		// the input won't be run, so the functions of earlier inputs go back to using variables which aren't declared yet
+		if ctx.globals != nil {
+			for _, ref := range ctx.globals.unresolved {
+				ref.id.Depth = -1
+			}
+		}
+		return
+	}
+	ctx.globals = global
+}
+
+// clone copies a top level scope, whose variables can be declared again by the next input of the repl
+func (s *scope) clone() *scope {
+	c := newScope(s.parent, false)
+	c.slots = s.slots
+	for name, v := range s.names {
+		c.names[name] = &variable{slot: v.slot, function: v.function, earlier: true}
+	}
+	c.unresolved = append(c.unresolved, s.unresolved...)
+	return c
+}
+
+// endFile resolves the functions declared at the top level of the file, and reports the identifiers which were never declared
+func (r *resolver) endFile() {
+	global := r.scope
+	r.flush(global)
+	var kept []*reference
+	for _, ref := range global.unresolved {
+		if _, deferred := ref.distance(global); r.interactive && deferred {
+			kept = append(kept, ref)
+			continue
+		}
+		r.errorf(ref.id, "'%s' was not declared", ref.id.Name)
+	}
+	global.unresolved = kept
+}
+
+// push starts a scope inside of the current one
+func (r *resolver) push(frame bool) {
+	r.scope = newScope(r.scope, frame)
+}
+
+// pop ends the current scope, resolving the functions declared in it and passing the identifiers it couldn't resolve to its parent
+func (r *resolver) pop() {
+	s := r.scope
+	r.flush(s)
+	r.scope = s.parent
+	r.scope.unresolved = append(r.scope.unresolved, s.unresolved...)
+}
+
+// flush resolves the bodies of the functions declared in s
+// they are resolved after the rest of the scope so they can use variables declared after them
+func (r *resolver) flush(s *scope) {
+	for len(s.pending) > 0 {
+		p := s.pending[0]
+		s.pending = s.pending[1:]
+		r.function(p.fn, p.declared)
+	}
+}
+
+// lookup returns how many scopes up the nearest variable named name is, and its slot
+func (r *resolver) lookup(name string) (ast.Binding, bool) {
+	depth := 0
+	for s := r.scope; s != nil; s = s.parent {
+		if v, ok := s.names[name]; ok {
+			return ast.Binding{Depth: depth, Slot: v.slot}, true
+		}
+		depth++
+	}
+	return ast.Binding{Depth: -1}, false
+}
+
+// use binds id to the nearest variable of its name
+// if there isn't one, it's bound when a variable of its name is declared in a scope around it
+func (r *resolver) use(id *ast.Identifier) {
+	binding, ok := r.lookup(id.Name)
+	id.Binding = binding
+	if !ok {
+		r.scope.unresolved = append(r.scope.unresolved, &reference{id: id, scope: r.scope})
+	}
+}
+
+// declare adds a variable to the current scope and returns its slot
+// kind is what the declaration is called when a name is declared twice in one scope, which is only allowed for a function replacing a function
+func (r *resolver) declare(node ast.Referable, name, kind string) int {
+	if v, ok := r.scope.names[name]; ok {
+		if !v.earlier && !(kind == "function" && v.function) {
+			r.errorf(node, "%s '%s' already exists", kind, name)
+		}
+		v.function, v.earlier = kind == "function", false
+		return v.slot
+	}
+	// a function's body is resolved once the scope it was declared in ends, so a variable declared after the function isn't shadowed
+	before := r.declared
+	for inner, s := r.scope, r.scope.parent; s != nil; inner, s = s, s.parent {
+		if inner.frame {
+			before = inner.declared
+		}
+		if v, ok := s.names[name]; ok {
+			if v.order <= before {
+				r.warnf(node, "'%s' shadows a variable declared in an outer scope", name)
+			}
+			break
+		}
+	}
+	return r.add(name, kind == "function")
+}
+
+// store returns the slot of the variable named name in the current scope, adding one if there isn't one
+// unlike declare, it's the same variable if the name is stored twice e.g. by read
+func (r *resolver) store(name string) int {
+	if v, ok := r.scope.names[name]; ok {
+		return v.slot
+	}
+	return r.add(name, false)
+}
+
+// add adds a variable to the current scope, binding the identifiers which used its name before it was declared
+// using a variable before it's declared is an error, unless it's in a function which could be called after the declaration
+func (r *resolver) add(name string, function bool) int {
+	s := r.scope
+	slot := s.slots
+	s.slots++
+	r.declared++
+	s.names[name] = &variable{slot: slot, function: function, order: r.declared}
+
+	var kept []*reference
+	for _, ref := range s.unresolved {
+		if ref.id.Name != name {
+			kept = append(kept, ref)
+		} else if depth, deferred := ref.distance(s); deferred {
+			ref.id.Binding = ast.Binding{Depth: depth, Slot: slot}
+		} else {
+			r.errorf(ref.id, "'%s' is used before it's declared", name)
+		}
+	}
+	s.unresolved = kept
+	return slot
+}
+
+// distance returns the number of scopes from the one the reference is in up to s,
+// and true if they include the frame of a function, which doesn't run until it's called
+// a reference from an earlier input of the repl is in the copy of the top level scope s was cloned from, which shares its parent
+func (ref *reference) distance(s *scope) (int, bool) {
+	depth, deferred := 0, false
+	for from := ref.scope; from != s && from.parent != s.parent; from = from.parent {
+		deferred = deferred || from.frame
+		depth++
+	}
+	return depth, deferred
+}
+
+// errorf records an error at the position node was parsed from
+func (r *resolver) errorf(node ast.Referable, format string, args ...interface{}) {
+	r.handler.Add(&token.Token{Position: node.Ref().Position}, fmt.Sprintf(format, args...))
+}
+
+// warnf records a warning at the position node was parsed from
+func (r *resolver) warnf(node ast.Referable, format string, args ...interface{}) {
+	r.handler.Warn(&token.Token{Position: node.Ref().Position}, fmt.Sprintf(format, args...))
+}
+
+func (r *resolver) statement(stmt ast.Statement) {
+	switch t := stmt.(type) {
+	case *ast.BlockStatement:
+		r.push(false)
+		for _, s := range t.Statements {
+			r.statement(s)
+		}
+		r.pop()
+	case *ast.ExpressionStatement:
+		r.expression(t.Expression)
+	case *ast.EtchStatement:
+		for _, exp := range t.Expressions {
+			r.expression(exp)
+		}
+	case *ast.ReadStatement:
+		// read stores the input in the nearest variable of its name, or a new one if there isn't one
+		if binding, ok := r.lookup(t.Identifier.Name); ok {
+			t.Identifier.Binding = binding
+		} else {
+			t.Identifier.Binding = ast.Binding{Slot: r.store(t.Identifier.Name)}
+		}
+	case *ast.ReturnStatement:
+		r.expression(t.Value)
+	case *ast.ThrowStatement:
+		r.expression(t.Value)
+	case *ast.IfStatement:
+		r.expression(t.Condition)
+		r.statement(t.Statement)
+		r.statement(t.ElseIf)
+	case *ast.ForLoopStatement:
+		r.expression(t.Iterator)
+		// each iteration declares a new control variable
+		r.push(false)
+		if t.Pattern != nil {
+			r.pattern(t.Pattern)
+		} else {
+			t.Control.Binding = ast.Binding{Slot: r.declare(t.Control, t.Control.Name, "variable")}
+		}
+		r.statement(t.Statement)
+		r.pop()
+	case *ast.WhileLoopStatement:
+		r.expression(t.Condition)
+		r.push(false)
+		r.statement(t.Statement)
+		r.pop()
+	case *ast.TryStatement:
+		r.statement(t.Statement)
+		if t.Catch != nil {
+			r.push(false)
+			t.CatchParameter.Slot = r.declare(t.CatchParameter, t.CatchParameter.Symbol, "variable")
+			r.statement(t.Catch)
+			r.pop()
+		}
+		r.statement(t.Finally)
+	case *ast.ImportStatement:
+		for _, id := range t.Imports {
+			id.Binding = ast.Binding{Slot: r.store(id.Name)}
+		}
+	case *ast.ExportStatement:
+		r.expression(t.Value)
+	}
+}
+
+func (r *resolver) expression(exp ast.Expression) {
+	switch t := exp.(type) {
+	case *ast.Identifier:
+		r.use(t)
+	case *ast.OperationExpression:
+		r.expression(t.LeftExpression)
+		if t.Operator == ast.DOT {
+			r.member(t.RightExpression)
+		} else {
+			r.expression(t.RightExpression)
+		}
+	case *ast.UnaryExpression:
+		r.expression(t.Expression)
+	case *ast.GroupExpression:
+		r.expression(t.Expression)
+	case *ast.VariableDecleration:
+		// the value can't use the variable being declared
+		r.expression(t.Value)
+		t.Slot = r.declare(t, t.Symbol, "variable")
+	case *ast.DestructuringDeclaration:
+		r.expression(t.Value)
+		r.pattern(t.Pattern)
+	case *ast.AssignmentExpression:
+		r.expression(t.Target)
+		r.expression(t.Value)
+	case *ast.FunctionCall:
+		// built-in functions aren't variables
+		if id, ok := t.Function.(*ast.Identifier); !ok || (id.Name != "len" && id.Name != "int" && id.Name != "err") {
+			r.expression(t.Function)
+		}
+		r.arguments(t)
+	case *ast.NamedArgument:
+		r.expression(t.Value)
+	case *ast.ArrayExpression:
+		for _, el := range t.Expressions {
+			r.expression(el)
+		}
+	case *ast.InterpolatedString:
+		for _, part := range t.Parts {
+			r.expression(part)
+		}
+	case *ast.ObjectLiteral:
+		// properties are resolved in a stable order so errors are too
+		keys := make([]string, 0, len(t.Value))
+		for k := range t.Value {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			r.expression(t.Value[k])
+		}
+	case *ast.FunctionLiteral:
+		if t.Receiver != "" {
+			binding, ok := r.lookup(t.Receiver)
+			if !ok {
+				r.errorf(t, "'%s' was not declared", t.Receiver)
+			}
+			t.ReceiverType = binding
+		} else if t.Symbol != "" {
+			t.Slot = r.declare(t, t.Symbol, "function")
+		}
+		r.scope.pending = append(r.scope.pending, &pendingFunction{fn: t, declared: r.declared})
+	case *ast.TypeDeclaration:
+		t.Slot = r.declare(t, t.Symbol, "type")
+	case *ast.MatchExpression:
+		r.expression(t.Value)
+		for _, arm := range t.Arms {
+			// the variables stored by a pattern are only visible in its arm
+			r.push(false)
+			r.pattern(arm.Pattern)
+			r.expression(arm.Value)
+			r.statement(arm.Body)
+			r.pop()
+		}
+	}
+}
+
+// member resolves the right side of a '.', whose name is a property or method rather than a variable
+// a call of a property which an obj doesn't have calls the variable of that name instead, if there is one
+func (r *resolver) member(exp ast.Expression) {
+	call, ok := exp.(*ast.FunctionCall)
+	if !ok {
+		return
+	}
+	if id, ok := call.Function.(*ast.Identifier); ok {
+		id.Binding, _ = r.lookup(id.Name)
+	} else {
+		r.expression(call.Function)
+	}
+	r.arguments(call)
+}
+
+func (r *resolver) arguments(call *ast.FunctionCall) {
+	for _, arg := range call.Arguments {
+		r.expression(arg)
+	}
+}
+
+// function resolves the parameters and body of a function in the frame of a call, whose parent is the scope it was declared in
+// declared is the number of variables declared before the function, which are the only ones its parameters and variables shadow
+func (r *resolver) function(fn *ast.FunctionLiteral, declared int) {
+	r.push(true)
+	r.scope.declared = declared
+	for _, param := range fn.Parameters {
+		// a default value can use the parameters before it
+		r.expression(param.Value)
+		param.Slot = r.declare(param, param.Symbol, "parameter")
+	}
+	r.statement(fn.Body)
+	r.pop()
+}
+
+// pattern declares the variables stored by a pattern, and resolves the values it compares against
+func (r *resolver) pattern(pattern ast.Pattern) {
+	switch p := pattern.(type) {
+	case *ast.LiteralPattern:
+		r.expression(p.Value)
+	case *ast.RangePattern:
+		r.expression(p.Start)
+		r.expression(p.End)
+	case *ast.BindingPattern:
+		if p.Symbol != ast.WILDCARD {
+			p.Slot = r.declare(p, p.Symbol, "variable")
+		}
+	case *ast.TypePattern:
+		if p.Symbol != ast.WILDCARD {
+			p.Slot = r.declare(p, p.Symbol, "variable")
+		}
+	case *ast.ArrayPattern:
+		for _, el := range p.Elements {
+			r.pattern(el)
+		}
+		if p.Rest != nil {
+			r.pattern(p.Rest)
+		}
+	case *ast.ObjectPattern:
+		for _, prop := range p.Properties {
+			r.pattern(prop.Pattern)
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/mcjcloud/taurine/pkg/ast"
+	"github.com/mcjcloud/taurine/pkg/util"
+)
+
+// parse parses src, returning its tree and the errors and warnings found in it
+func parse(t *testing.T, src string) (*ast.Ast, *util.ErrorHandler) {
+	t.Helper()
+	ctx, err := NewParseContextFromSource("/src/main.tc", src)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	tree := Parse(ctx)
+	ctx.PopImportWithTree(tree)
+	return tree, ctx.ErrorHandlers["/src/main.tc"]
+}
+
+func messages(errors []util.ParseError) []string {
+	var msgs []string
+	for _, e := range errors {
+		msgs = append(msgs, e.Message)
+	}
+	return msgs
+}
+
+func TestResolveErrors(t *testing.T) {
+	tests := []struct {
+		src string
+		msg string
+	}{
+		{"etch y;", "'y' was not declared"},
+		{"func (int) f() { return g(); }", "'g' was not declared"},
+		{"etch x;\nvar (int) x = 1;", "'x' is used before it's declared"},
+		{"{\n  etch x;\n}\nvar (int) x = 1;", "'x' is used before it's declared"},
+		{"var (int) x = x;", "'x' is used before it's declared"},
+		{"var (int) a = 1;\nvar (int) a = 2;", "variable 'a' already exists"},
+		{"var (int) a = 1;\nvar [int a] = [2];", "variable 'a' already exists"},
+		{"var [num a, int a] = [1, 2];", "variable 'a' already exists"},
+		{"type Point { num x }\ntype Point { num y }", "type 'Point' already exists"},
+		{"func (void) f(int a, int a) {}", "parameter 'a' already exists"},
+		{"var (int) f = 1;\nfunc (void) f() {}", "function 'f' already exists"},
+	}
+	for _, test := range tests {
+		_, handler := parse(t, test.src)
+		if msgs := messages(handler.Errors); len(msgs) != 1 || msgs[0] != test.msg {
+			t.Errorf("expected %q for %q but found %q", test.msg, test.src, msgs)
+		}
+	}
+}
+
+func TestResolveWithoutErrors(t *testing.T) {
+	tests := []string{
+		// a function can use a variable declared after it, since it only runs once it's called
+		"func (int) count() { return n; }\nvar (int) n = 3;\netch count();",
+		"{\n  func (void) f() { g(); }\n}\nfunc (void) g() {}",
+		"func (void) f() {}\nfunc (void) f() {}",
+		"var (str) s;\nread s, \"> \";\nread t, \"> \";\netch s, t;",
+		"var (obj) o = {a: 1};\no.missing();",
+		"var (int) x = 1;\n{\n  var (int) x = x + 1;\n}",
+	}
+	for _, src := range tests {
+		if _, handler := parse(t, src); len(handler.Errors) > 0 {
+			t.Errorf("unexpected errors for %q: %q", src, messages(handler.Errors))
+		}
+	}
+}
+
+func TestShadowWarnings(t *testing.T) {
+	tests := []struct {
+		src string
+		msg string
+	}{
+		{"var (int) i = 0;\nfor i in [1] {}", "'i' shadows a variable declared in an outer scope"},
+		{"var (int) a = 1;\nfunc (int) f(int a) { return a; }", "'a' shadows a variable declared in an outer scope"},
+		{"var (str) s = \"a\";\netch match 1 { (int) s => s };", "'s' shadows a variable declared in an outer scope"},
+		{"var (int) n = 1;\nfunc (void) f() {\n  func (int) g(int n) { return n; }\n}\nvar (int) m = 2;", "'n' shadows a variable declared in an outer scope"},
+	}
+	for _, test := range tests {
+		_, handler := parse(t, test.src)
+		if len(handler.Errors) > 0 {
+			t.Errorf("unexpected errors for %q: %q", test.src, messages(handler.Errors))
+		}
+		if msgs := messages(handler.Warnings); len(msgs) != 1 || msgs[0] != test.msg {
+			t.Errorf("expected %q for %q but found %q", test.msg, test.src, msgs)
+		}
+	}
+}
+
+func TestShadowWithoutWarnings(t *testing.T) {
+	tests := []string{
+		// a function's body is resolved after the variables declared after it, which it doesn't shadow
+		"type Point { num x, num y }\nfunc (num) Point.len2(Point p) { return p.x * p.x + p.y * p.y; }\nvar (Point) p = Point(1, 2);",
+		"func (int) f(int n) { return n; }\nvar (int) n = f(1);",
+		"func (void) f() {\n  func (int) g(int n) { return n; }\n}\nvar (int) n = 1;",
+		"var (int) a = 1;\n{\n  var (int) b = 2;\n}\nvar (int) b = 3;",
+	}
+	for _, src := range tests {
+		_, handler := parse(t, src)
+		if len(handler.Errors) > 0 {
+			t.Errorf("unexpected errors for %q: %q", src, messages(handler.Errors))
+		}
+		if msgs := messages(handler.Warnings); len(msgs) > 0 {
+			t.Errorf("unexpected warnings for %q: %q", src, msgs)
+		}
+	}
+}
+
+func TestResolveBindings(t *testing.T) {
+	tree, handler := parse(t, "var (int) a = 1;\nvar (int) b = 2;\nfunc (int) f(int c) {\n  etch a, b, c;\n}")
+	if len(handler.Errors) > 0 {
+		t.Fatalf("unexpected errors: %q", messages(handler.Errors))
+	}
+	fn := tree.Statement.(*ast.BlockStatement).Statements[2].(*ast.ExpressionStatement).Expression.(*ast.FunctionLiteral)
+	etch := fn.Body.(*ast.BlockStatement).Statements[0].(*ast.EtchStatement)
+
+	// the body is a block inside the frame of the call, which is inside the top level of the file
+	expected := []ast.Binding{{Depth: 2, Slot: 0}, {Depth: 2, Slot: 1}, {Depth: 1, Slot: 0}}
+	for i, exp := range etch.Expressions {
+		if id := exp.(*ast.Identifier); id.Binding != expected[i] {
+			t.Errorf("expected %s to be bound to %v but found %v", id.Name, expected[i], id.Binding)
+		}
+	}
+	if fn.Slot != 2 {
+		t.Errorf("expected f to be declared in slot 2 but found %d", fn.Slot)
+	}
+}
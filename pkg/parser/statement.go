@@ -11,7 +11,7 @@ import (
 func parseStatement(tkn *token.Token, ctx *ParseContext) ast.Statement {
 	it := ctx.CurrentIterator()
 	if tkn.Type == "{" {
-		block := &ast.BlockStatement{Statements: []ast.Statement{}}
+		block := &ast.BlockStatement{SourceRef: ctx.ref(tkn), Statements: []ast.Statement{}}
 		nxt := it.Next()
 		for nxt.Type != "}" {
 			stmt := parseStatement(nxt, ctx)
@@ -37,21 +37,33 @@ func parseStatement(tkn *token.Token, ctx *ParseContext) ast.Statement {
 			return parseWhileLoop(tkn, ctx)
 		} else if tkn.Value == ast.RETURN {
 			return parseReturnStatement(tkn, ctx)
+		} else if tkn.Value == ast.BREAK {
+			return parseLoopControlStatement(tkn, ctx, &ast.BreakStatement{SourceRef: ctx.ref(tkn)})
+		} else if tkn.Value == ast.CONTINUE {
+			return parseLoopControlStatement(tkn, ctx, &ast.ContinueStatement{SourceRef: ctx.ref(tkn)})
 		} else if tkn.Value == ast.IMPORT {
 			return parseImportStatement(tkn, ctx)
 		} else if tkn.Value == ast.EXPORT {
 			return parseExportStatement(tkn, ctx)
+		} else if tkn.Value == ast.THROW {
+			return parseThrowStatement(tkn, ctx)
+		} else if tkn.Value == ast.TRY {
+			return parseTryStatement(tkn, ctx)
 		}
 	} else {
 		// it's an expression (symbol)
-		exp := parseExpression(tkn, ctx, nil)
+		exp := parseExpression(tkn, ctx)
 		// expect the semicolon if the expression isn't a block
-		if _, ok := exp.(*ast.FunctionLiteral); !ok && it.Next().Type != ";" {
-			return ctx.CurrentErrorHandler().Add(it.Current(), "expected expression statement to end with ';'")
-		} else if ok && it.Peek().Type == ";" {
+		if block := endsWithBlock(exp); !block {
+			if nxt := it.Next(); nxt == nil {
+				return ctx.CurrentErrorHandler().Add(tkn, "expected expression statement to end with ';'")
+			} else if nxt.Type != ";" {
+				return ctx.CurrentErrorHandler().Add(nxt, "expected expression statement to end with ';'")
+			}
+		} else if peek := it.Peek(); peek != nil && peek.Type == ";" {
 			it.Next()
 		}
-		return &ast.ExpressionStatement{Expression: exp}
+		return &ast.ExpressionStatement{SourceRef: ctx.ref(tkn), Expression: exp}
 	}
 	return ctx.CurrentErrorHandler().Add(tkn, "unrecognized statement")
 }
@@ -60,26 +72,28 @@ func parseEtchStatement(tkn *token.Token, ctx *ParseContext) ast.Statement {
 	it := ctx.CurrentIterator()
 	exps := []ast.Expression{}
 	nxt := it.Next()
-	exp := parseExpression(nxt, ctx, nil)
+	exp := parseExpression(nxt, ctx)
 	exps = append(exps, exp)
 	nxt = it.Next()
-	for nxt.Type == "," {
+	for nxt != nil && nxt.Type == "," {
 		nxt = it.Next()
-		exp = parseExpression(nxt, ctx, nil)
+		exp = parseExpression(nxt, ctx)
 		exps = append(exps, exp)
 		nxt = it.Next()
 	}
-	if nxt == nil || nxt.Type != ";" {
+	if nxt == nil {
+		return ctx.CurrentErrorHandler().Add(tkn, "expected semicolon to end statement")
+	} else if nxt.Type != ";" {
 		return ctx.CurrentErrorHandler().Add(nxt, "expected semicolon to end statement")
 	}
-	return &ast.EtchStatement{Expressions: exps}
+	return &ast.EtchStatement{SourceRef: ctx.ref(tkn), Expressions: exps}
 }
 
 func parseReadStatement(tkn *token.Token, ctx *ParseContext) ast.Statement {
 	it := ctx.CurrentIterator()
 	// parse identifier
 	nxt := it.Next()
-	exp := parseExpression(nxt, ctx, nil)
+	exp := parseExpression(nxt, ctx)
 	idExp, ok := exp.(*ast.Identifier)
 	if !ok {
 		return ctx.CurrentErrorHandler().Add(it.Current(), "expected identifier at beginning of 'read' statement")
@@ -89,13 +103,14 @@ func parseReadStatement(tkn *token.Token, ctx *ParseContext) ast.Statement {
 	}
 
 	// parse prompt
-	exp = parseExpression(it.Next(), ctx, nil)
+	exp = parseExpression(it.Next(), ctx)
 	if pmtExp, ok := exp.(*ast.StringLiteral); ok {
 		sc := it.Next()
 		if sc == nil || sc.Type != ";" {
 			return ctx.CurrentErrorHandler().Add(sc, "expected semicolon to end statement")
 		}
 		return &ast.ReadStatement{
+			SourceRef:  ctx.ref(tkn),
 			Identifier: idExp,
 			Prompt:     pmtExp,
 		}
@@ -106,7 +121,7 @@ func parseReadStatement(tkn *token.Token, ctx *ParseContext) ast.Statement {
 func parseIfStatement(tkn *token.Token, ctx *ParseContext) ast.Statement {
 	it := ctx.CurrentIterator()
 
-	exp := parseExpression(it.Next(), ctx, nil)
+	exp := parseExpression(it.Next(), ctx)
 	stmt := parseStatement(it.Next(), ctx)
 
 	// check for an else [if]
@@ -117,6 +132,7 @@ func parseIfStatement(tkn *token.Token, ctx *ParseContext) ast.Statement {
 	}
 
 	return &ast.IfStatement{
+		SourceRef: ctx.ref(tkn),
 		Condition: exp,
 		Statement: stmt,
 		ElseIf:    elif,
@@ -126,13 +142,20 @@ func parseIfStatement(tkn *token.Token, ctx *ParseContext) ast.Statement {
 func parseForLoop(tkn *token.Token, ctx *ParseContext) ast.Statement {
 	it := ctx.CurrentIterator()
 
-	// expect an identifier
+	// expect an identifier, or a pattern which each element is destructured into
 	idStart := it.Next()
-	idExp := parseExpression(idStart, ctx, nil)
 	var id *ast.Identifier
-	if v, ok := idExp.(*ast.Identifier); !ok {
+	var pattern ast.Pattern
+	if idStart != nil && (idStart.Type == "[" || idStart.Type == "{") {
+		p, ok := parseDestructuringPattern(idStart, ctx, false)
+		if !ok {
+			it.SkipTo(token.Token{Type: "{", Value: "{"})
+			return p.(*ast.ErrorNode)
+		}
+		pattern = p
+	} else if v, ok := parseExpression(idStart, ctx).(*ast.Identifier); !ok {
 		it.SkipTo(token.Token{Type: "{", Value: "{"})
-		return ctx.CurrentErrorHandler().Add(idStart, fmt.Sprintf("expected identifier but found %s", idExp))
+		return ctx.CurrentErrorHandler().Add(idStart, fmt.Sprintf("expected identifier but found %s", idStart.Value))
 	} else {
 		id = v
 	}
@@ -144,13 +167,13 @@ func parseForLoop(tkn *token.Token, ctx *ParseContext) ast.Statement {
 	}
 
 	// expect expression this should be an array at runtime
-	arrExp := parseExpression(it.Next(), ctx, nil)
+	arrExp := parseExpression(it.Next(), ctx)
 
 	// optionally expect a ';' and a number (the step)
 	step := 1
 	if peek := it.Peek(); peek.Type == ";" {
 		s := it.Next()
-		numExp := parseExpression(it.Next(), ctx, nil)
+		numExp := parseExpression(it.Next(), ctx)
 		if num, ok := numExp.(*ast.IntegerLiteral); ok {
 			step = int(num.Value.Int64())
 		} else {
@@ -160,10 +183,14 @@ func parseForLoop(tkn *token.Token, ctx *ParseContext) ast.Statement {
 	}
 
 	// read statement
+	ctx.loopDepth += 1
 	stmt := parseStatement(it.Next(), ctx)
+	ctx.loopDepth -= 1
 
 	return &ast.ForLoopStatement{
+		SourceRef: ctx.ref(tkn),
 		Control:   id,
+		Pattern:   pattern,
 		Iterator:  arrExp,
 		Step:      step,
 		Statement: stmt,
@@ -172,10 +199,13 @@ func parseForLoop(tkn *token.Token, ctx *ParseContext) ast.Statement {
 
 func parseWhileLoop(tkn *token.Token, ctx *ParseContext) ast.Statement {
 	it := ctx.CurrentIterator()
-	exp := parseExpression(it.Next(), ctx, nil)
+	exp := parseExpression(it.Next(), ctx)
 
+	ctx.loopDepth += 1
 	stmt := parseStatement(it.Next(), ctx)
+	ctx.loopDepth -= 1
 	return &ast.WhileLoopStatement{
+		SourceRef: ctx.ref(tkn),
 		Condition: exp,
 		Statement: stmt,
 	}
@@ -183,13 +213,125 @@ func parseWhileLoop(tkn *token.Token, ctx *ParseContext) ast.Statement {
 
 func parseReturnStatement(tkn *token.Token, ctx *ParseContext) ast.Statement {
 	it := ctx.CurrentIterator()
-	exp := parseExpression(it.Next(), ctx, nil)
+
+	// a return without a value ends a void function
+	if nxt := it.Peek(); nxt != nil && nxt.Type == ";" {
+		it.Next()
+		if ctx.returnType != "" && ctx.returnType != ast.VOID {
+			return ctx.CurrentErrorHandler().Add(tkn, fmt.Sprintf("expected a value to return from function of type %s", ctx.returnType))
+		}
+		return &ast.ReturnStatement{SourceRef: ctx.ref(tkn)}
+	}
+
+	exp := parseExpression(it.Next(), ctx)
 	// expect a semicolon
 	if nxt := it.Peek(); nxt == nil || nxt.Type != ";" {
 		return ctx.CurrentErrorHandler().Add(tkn, "expected semicolon to end return statement")
 	}
 	it.Next()
-	return &ast.ReturnStatement{Value: exp}
+	if ctx.returnType == ast.VOID {
+		return ctx.CurrentErrorHandler().Add(tkn, "cannot return a value from a void function")
+	}
+	return &ast.ReturnStatement{SourceRef: ctx.ref(tkn), Value: exp, Tail: isTailCall(exp, ctx)}
+}
+
+// isTailCall returns true if returning exp ends the function with a call, and nothing is left to run once the call returns
+// a call in a try block may still be caught, and one in a catch block may still have a finally block to run after it
+func isTailCall(exp ast.Expression, ctx *ParseContext) bool {
+	call, ok := exp.(*ast.FunctionCall)
+	if !ok || ctx.returnType == "" || ctx.tryDepth > 0 {
+		return false
+	}
+	id, ok := call.Function.(*ast.Identifier)
+	return !ok || (id.Name != "len" && id.Name != "int" && id.Name != "err")
+}
+
+func parseThrowStatement(tkn *token.Token, ctx *ParseContext) ast.Statement {
+	it := ctx.CurrentIterator()
+	exp := parseExpression(it.Next(), ctx)
+	// expect a semicolon
+	if nxt := it.Peek(); nxt == nil || nxt.Type != ";" {
+		return ctx.CurrentErrorHandler().Add(tkn, "expected semicolon to end throw statement")
+	}
+	it.Next()
+	return &ast.ThrowStatement{SourceRef: ctx.ref(tkn), Value: exp}
+}
+
+// parseTryStatement parses a try block, followed by a catch block, a finally block, or both
+func parseTryStatement(tkn *token.Token, ctx *ParseContext) ast.Statement {
+	it := ctx.CurrentIterator()
+	stmt := &ast.TryStatement{SourceRef: ctx.ref(tkn)}
+	ctx.tryDepth += 1
+	body, ok := parseBlock(it.Next(), ctx, "try")
+	ctx.tryDepth -= 1
+	if !ok {
+		return body
+	}
+	stmt.Statement = body
+
+	// catch (err e) { ... }
+	if peek := it.Peek(); peek != nil && peek.Value == ast.CATCH {
+		catchTkn := it.Next()
+		if nxt := it.Next(); nxt == nil || nxt.Type != "(" {
+			return ctx.CurrentErrorHandler().Add(nxt, "expected '(' after catch")
+		}
+		typeTkn := it.Next()
+		if dataType, ok := parseDataType(typeTkn, ctx); !ok || dataType != ast.ERR {
+			return ctx.CurrentErrorHandler().Add(typeTkn, "expected caught error to be of type err")
+		}
+		sym := it.Next()
+		if sym == nil || sym.Type != "symbol" {
+			return ctx.CurrentErrorHandler().Add(sym, "expected identifier")
+		}
+		if nxt := it.Next(); nxt == nil || nxt.Type != ")" {
+			return ctx.CurrentErrorHandler().Add(nxt, "expected ')' after caught error")
+		}
+		stmt.CatchParameter = &ast.VariableDecleration{SourceRef: ctx.ref(sym), Symbol: sym.Value, SymbolType: ast.ERR}
+		ctx.tryDepth += 1
+		catch, ok := parseBlock(it.Next(), ctx, catchTkn.Value)
+		ctx.tryDepth -= 1
+		if !ok {
+			return catch
+		}
+		stmt.Catch = catch
+	}
+
+	// finally { ... }
+	if peek := it.Peek(); peek != nil && peek.Value == ast.FINALLY {
+		finallyTkn := it.Next()
+		finally, ok := parseBlock(it.Next(), ctx, finallyTkn.Value)
+		if !ok {
+			return finally
+		}
+		stmt.Finally = finally
+	}
+
+	if stmt.Catch == nil && stmt.Finally == nil {
+		return ctx.CurrentErrorHandler().Add(tkn, "expected catch or finally after try block")
+	}
+	return stmt
+}
+
+// parseBlock parses the block statement which must follow keyword
+func parseBlock(tkn *token.Token, ctx *ParseContext, keyword string) (ast.Statement, bool) {
+	if tkn == nil || tkn.Type != "{" {
+		return ctx.CurrentErrorHandler().Add(tkn, fmt.Sprintf("expected '{' after %s", keyword)), false
+	}
+	return parseStatement(tkn, ctx), true
+}
+
+// parseLoopControlStatement parses a break or continue statement, which must be inside of a loop
+func parseLoopControlStatement(tkn *token.Token, ctx *ParseContext, stmt ast.Statement) ast.Statement {
+	it := ctx.CurrentIterator()
+	// expect a semicolon
+	if nxt := it.Peek(); nxt == nil || nxt.Type != ";" {
+		return ctx.CurrentErrorHandler().Add(tkn, fmt.Sprintf("expected semicolon to end %s statement", tkn.Value))
+	}
+	it.Next()
+	if ctx.loopDepth == 0 {
+		return ctx.CurrentErrorHandler().Add(tkn, fmt.Sprintf("'%s' can only be used inside of a loop", tkn.Value))
+	}
+	return stmt
 }
 
 func parseImportStatement(tkn *token.Token, ctx *ParseContext) ast.Statement {
@@ -197,7 +339,7 @@ func parseImportStatement(tkn *token.Token, ctx *ParseContext) ast.Statement {
 	handler := ctx.CurrentErrorHandler()
 	ids := make([]*ast.Identifier, 0)
 	nxt := it.Next()
-	exp := parseExpression(nxt, ctx, nil)
+	exp := parseExpression(nxt, ctx)
 	if id, ok := exp.(*ast.Identifier); !ok {
 		it.SkipStatement()
 		return handler.Add(nxt, "expected identifier.")
@@ -205,7 +347,7 @@ func parseImportStatement(tkn *token.Token, ctx *ParseContext) ast.Statement {
 		ids = append(ids, id)
 	}
 	for nxt = it.Next(); nxt.Type == ","; nxt = it.Next() {
-		idExp := parseExpression(it.Next(), ctx, nil)
+		idExp := parseExpression(it.Next(), ctx)
 		if id, ok := idExp.(*ast.Identifier); !ok {
 			it.SkipStatement()
 			return handler.Add(nxt, "expected identifier.")
@@ -235,39 +377,60 @@ func parseImportStatement(tkn *token.Token, ctx *ParseContext) ast.Statement {
 	if _, ok := err.(*util.AlreadyParsedError); !ok && err != nil {
 		return handler.Add(nxt, fmt.Sprintf("error finding referenced file: %s", err.Error()))
 	} else if ok {
+		importTypes(ctx, err.(*util.AlreadyParsedError).Path, ids)
 		return &ast.ImportStatement{
-			Source:  source,
-			Imports: ids,
+			SourceRef: ctx.ref(tkn),
+			Source:    source,
+			Imports:   ids,
 		}
 	}
 
 	// run Parse and then return ctx to previous state
+	loopDepth, tryDepth, returnType := ctx.loopDepth, ctx.tryDepth, ctx.returnType
+	ctx.loopDepth, ctx.tryDepth, ctx.returnType = 0, 0, ""
+	importedPath := ctx.CurrentFilePath()
 	refTree := Parse(ctx)
 	ctx.PopImportWithTree(refTree)
+	ctx.loopDepth, ctx.tryDepth, ctx.returnType = loopDepth, tryDepth, returnType
+	importTypes(ctx, importedPath, ids)
 
 	// return the import statement node
 	return &ast.ImportStatement{
-		Source:  source,
-		Imports: ids,
+		SourceRef: ctx.ref(tkn),
+		Source:    source,
+		Imports:   ids,
+	}
+}
+
+// importTypes makes the types among ids, which are imported from the file at path, usable as data types in the current file
+func importTypes(ctx *ParseContext, path string, ids []*ast.Identifier) {
+	for _, id := range ids {
+		if ctx.types[path][id.Name] {
+			ctx.declareType(ctx.CurrentFilePath(), id.Name)
+		}
 	}
 }
 
 func parseExportStatement(tkn *token.Token, ctx *ParseContext) ast.Statement {
 	it := ctx.CurrentIterator()
 	// parse the exported expression
-	exp := parseExpression(it.Next(), ctx, nil)
+	exp := parseExpression(it.Next(), ctx)
 
 	curr := it.Current()
 	var nxt *token.Token
 	if nxt = it.Next(); nxt != nil && nxt.Value == ast.AS {
 		// expect an identifier
-		idExp := parseExpression(it.Next(), ctx, nil)
+		idExp := parseExpression(it.Next(), ctx)
 		if id, ok := idExp.(*ast.Identifier); !ok {
 			e := it.Current()
 			it.SkipStatement()
 			return ctx.CurrentErrorHandler().Add(e, "expected identifier")
+		} else if name := typeName(exp, ctx); name != "" && name != id.Name {
+			// a type is used by its name, so it has to be imported with the name it was declared with
+			return ctx.CurrentErrorHandler().Add(it.Current(), fmt.Sprintf("type '%s' cannot be exported as '%s'", name, id.Name))
 		} else {
 			return &ast.ExportStatement{
+				SourceRef:  ctx.ref(tkn),
 				Identifier: id,
 				Value:      exp,
 			}
@@ -275,7 +438,7 @@ func parseExportStatement(tkn *token.Token, ctx *ParseContext) ast.Statement {
 	}
 
 	// expect semicolon
-	if _, ok := exp.(*ast.FunctionLiteral); !ok && nxt != nil && nxt.Type != ";" {
+	if !endsWithBlock(exp) && nxt != nil && nxt.Type != ";" {
 		return ctx.CurrentErrorHandler().Add(curr, "expected ';' to end export statement")
 	} else if nxt != nil && nxt.Type != ";" {
 		it.Prev()
@@ -292,17 +455,41 @@ func parseExportStatement(tkn *token.Token, ctx *ParseContext) ast.Statement {
 		id = &ast.Identifier{
 			Name: v.Symbol,
 		}
+	} else if t, ok := exp.(*ast.TypeDeclaration); ok {
+		id = &ast.Identifier{
+			Name: t.Symbol,
+		}
 	} else if i, ok := exp.(*ast.Identifier); ok {
 		id = &ast.Identifier{
 			Name: i.Name,
 		}
 	} else {
-		return ctx.CurrentErrorHandler().Add(nxt, "expected variable, function, or identifier")
+		return ctx.CurrentErrorHandler().Add(nxt, "expected variable, function, type, or identifier")
 	}
 
 	// build the export statement
 	return &ast.ExportStatement{
+		SourceRef:  ctx.ref(tkn),
 		Identifier: id,
 		Value:      exp,
 	}
 }
+
+// typeName returns the name of the type exp declares or refers to, or "" if it isn't a type
+func typeName(exp ast.Expression, ctx *ParseContext) string {
+	if t, ok := exp.(*ast.TypeDeclaration); ok {
+		return t.Symbol
+	} else if id, ok := exp.(*ast.Identifier); ok && ctx.isRecordType(id.Name) {
+		return id.Name
+	}
+	return ""
+}
+
+// endsWithBlock returns true if exp ends with a block, so it doesn't need a semicolon to end the statement it's in
+func endsWithBlock(exp ast.Expression) bool {
+	switch exp.(type) {
+	case *ast.FunctionLiteral, *ast.TypeDeclaration, *ast.MatchExpression:
+		return true
+	}
+	return false
+}
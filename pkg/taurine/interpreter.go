@@ -0,0 +1,173 @@
+// Package taurine runs Taurine programs from Go
+// an Interpreter gives a program functions written in Go and global variables, and lets Go call the functions the program exports
+package taurine
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/mcjcloud/taurine/pkg/ast"
+	"github.com/mcjcloud/taurine/pkg/evaluator"
+	"github.com/mcjcloud/taurine/pkg/parser"
+	"github.com/mcjcloud/taurine/pkg/value"
+)
+
+// Interpreter runs programs with the evaluator, along with the functions and variables defined by Define and Set
+// the options set where the program reads and writes, e.g. Options{Stdin: in, Stdout: out}, and the limits it runs with
+// it isn't safe to use from more than one goroutine at once
+type Interpreter struct {
+	opts    evaluator.Options
+	names   []string               // the names of the builtins in the order they were defined, which gives them their slots
+	values  map[string]value.Value // the builtins by name
+	exports map[string]value.Value // the values exported by the main file of the last program run
+	runtime *evaluator.Runtime     // the state of the last program run, which its exported functions run with
+}
+
+// New creates an Interpreter which runs programs with opts
+func New(opts evaluator.Options) *Interpreter {
+	return &Interpreter{opts: opts, values: make(map[string]value.Value)}
+}
+
+// Define adds a function written in Go which programs can call like any other function
+// signature is how the function would be declared in Taurine without its body e.g. "func (int) add(int a, int b)",
+// and its arguments are converted to the types of its parameters before fn is called, and its result to its return type
+// parameters can't have default values, but the last one can be variadic, in which case its argument is an arr
+func (in *Interpreter) Define(signature string, fn func(args []value.Value) (value.Value, error)) error {
+	ctx, err := parser.NewParseContextFromSource("<signature>", signature+" {}")
+	if err != nil {
+		return fmt.Errorf("invalid signature %q: %s", signature, err)
+	}
+	tree := parser.Parse(ctx)
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("invalid signature %q: %s", signature, err)
+	}
+	statements := tree.Statement.(*ast.BlockStatement).Statements
+	if len(statements) != 1 {
+		return fmt.Errorf("invalid signature %q: expected one function", signature)
+	}
+	exp, _ := statements[0].(*ast.ExpressionStatement)
+	if exp == nil {
+		return fmt.Errorf("invalid signature %q: expected a function", signature)
+	}
+	decl, ok := exp.Expression.(*ast.FunctionLiteral)
+	if !ok || decl.Symbol == "" || decl.Receiver != "" {
+		return fmt.Errorf("invalid signature %q: expected a named function", signature)
+	}
+	for _, param := range decl.Parameters {
+		if param.Value != nil {
+			return fmt.Errorf("invalid signature %q: parameter '%s' can't have a default value", signature, param.Symbol)
+		}
+	}
+	in.define(decl.Symbol, &evaluator.HostFunction{Function: decl, Go: fn})
+	return nil
+}
+
+// Set adds a global variable which programs can use, whose value is converted from Go by ValueOf
+// the variable has the type of its value, or no type if it's nil
+func (in *Interpreter) Set(name string, val interface{}) error {
+	converted, err := ValueOf(val)
+	if err != nil {
+		return err
+	}
+	in.define(name, converted)
+	return nil
+}
+
+// define adds a builtin, replacing the one with the same name
+func (in *Interpreter) define(name string, val value.Value) {
+	if _, ok := in.values[name]; !ok {
+		in.names = append(in.names, name)
+	}
+	in.values[name] = val
+}
+
+// Run parses and runs the program whose main file is at path
+func (in *Interpreter) Run(path string) error {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+	ctx, err := parser.NewParseContext(absPath)
+	if err != nil {
+		return err
+	}
+	return in.run(ctx)
+}
+
+// RunSource parses and runs src as the main file of a program, which imports files relative to path
+func (in *Interpreter) RunSource(path, src string) error {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+	ctx, err := parser.NewParseContextFromSource(absPath, src)
+	if err != nil {
+		return err
+	}
+	return in.run(ctx)
+}
+
+// run parses the program of ctx with the builtins declared, then evaluates it
+func (in *Interpreter) run(ctx *parser.ParseContext) error {
+	slots := make([]int, len(in.names))
+	for i, name := range in.names {
+		slots[i] = ctx.Declare(name)
+	}
+	tree := parser.Parse(ctx)
+	ctx.PopImportWithTree(tree)
+	if cycles := ctx.ImportGraph.FindCycles(); len(cycles) > 0 {
+		return fmt.Errorf("import cycle found: %s", strings.Join(cycles, ", "))
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	builtins := evaluator.NewScope(in.opts)
+	for i, name := range in.names {
+		val := in.values[name]
+		if _, ok := val.(*value.Nil); ok {
+			builtins.Set(ast.Binding{Slot: slots[i]}, val)
+		} else if _, err := builtins.Declare(slots[i], name, val.Type(), val); err != nil {
+			return err
+		}
+	}
+	exports, err := evaluator.EvaluateIn(tree, ctx.ImportGraph, builtins)
+	if err != nil {
+		return err
+	}
+	in.exports, in.runtime = exports, builtins.Runtime()
+	return nil
+}
+
+// Call calls the function named name exported by the last program run, with arguments converted from Go by ValueOf
+// each call can take as many steps and write as much output as the options allow, while Context limits them all
+// the arguments are given in the order of the parameters, and the ones after the last parameter that isn't variadic are the elements of the variadic one
+func (in *Interpreter) Call(name string, args ...interface{}) (value.Value, error) {
+	fn, ok := in.exports[name]
+	if !ok {
+		return nil, fmt.Errorf("'%s' is not exported", name)
+	}
+	if _, ok := fn.(value.Func); !ok {
+		return nil, fmt.Errorf("'%s' is not a function", name)
+	}
+	vals := make([]value.Value, len(args))
+	for i, arg := range args {
+		val, err := ValueOf(arg)
+		if err != nil {
+			return nil, err
+		}
+		vals[i] = val
+	}
+	in.runtime.Reset()
+	return evaluator.Call(fn, vals)
+}
+
+// Export returns the value named name exported by the last program run
+func (in *Interpreter) Export(name string) (value.Value, error) {
+	val, ok := in.exports[name]
+	if !ok {
+		return nil, fmt.Errorf("'%s' is not exported", name)
+	}
+	return val, nil
+}
@@ -0,0 +1,275 @@
+package taurine
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mcjcloud/taurine/pkg/evaluator"
+	"github.com/mcjcloud/taurine/pkg/value"
+)
+
+// runSource runs src with in, returning what it printed
+func runSource(t *testing.T, in *Interpreter, src string) (string, error) {
+	t.Helper()
+	var output strings.Builder
+	in.opts.Stdout = &output
+	err := in.RunSource("/src/main.tc", src)
+	return output.String(), err
+}
+
+func TestDefine(t *testing.T) {
+	in := New(evaluator.Options{})
+	err := in.Define("func (int) add(int a, int b)", func(args []value.Value) (value.Value, error) {
+		a, b := args[0].(*value.Int), args[1].(*value.Int)
+		return value.NewInt(a.Value.Int64() + b.Value.Int64()), nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	err = in.Define("func (num) sum(num nums...)", func(args []value.Value) (value.Value, error) {
+		total := 0.0
+		for _, el := range args[0].(*value.Arr).Elements {
+			total += el.(*value.Num).Value
+		}
+		return &value.Num{Value: total}, nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	err = in.Define("func (void) fail(str message)", func(args []value.Value) (value.Value, error) {
+		return nil, errors.New(args[0].(*value.Str).Value)
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	tests := []struct {
+		src    string
+		output string
+	}{
+		{"etch add(1, 2);", "3\n"},
+		{"etch add(b: 1, a: 2);", "3\n"},
+		{"etch sum(1, 2.5, 3);", "6.500000\n"},
+		{"etch sum();", "0.000000\n"},
+		{"etch [1, 2].map(func (int) (int n) { return add(n, n); });", "[2, 4]\n"},
+		{"var (func) f = add;\netch f(4, 5);", "9\n"},
+		{"try {\n  fail(\"no\");\n} catch (err e) {\n  etch e;\n}", "err(\"no\")\n"},
+	}
+	for _, test := range tests {
+		output, err := runSource(t, in, test.src)
+		if err != nil {
+			t.Errorf("unexpected error for %q: %s", test.src, err)
+		} else if output != test.output {
+			t.Errorf("expected %q for %q but found %q", test.output, test.src, output)
+		}
+	}
+
+	errs := []struct {
+		src string
+		msg string
+	}{
+		{"etch add(1, \"2\");", "cannot assign str to 'b' of type int"},
+		{"etch add(1);", "missing argument for 'b' in call to 'add'"},
+		{"fail(\"no\");", "no"},
+	}
+	for _, test := range errs {
+		if _, err := runSource(t, in, test.src); err == nil || !strings.Contains(err.Error(), test.msg) {
+			t.Errorf("expected %q for %q but found %v", test.msg, test.src, err)
+		}
+	}
+}
+
+func TestDefineErrors(t *testing.T) {
+	tests := []string{
+		"func (int) (int a)",
+		"func (int) add(int a = 1)",
+		"var (int) a = 1;",
+		"func (int add(",
+	}
+	fn := func(args []value.Value) (value.Value, error) { return nil, nil }
+	for _, signature := range tests {
+		if err := New(evaluator.Options{}).Define(signature, fn); err == nil {
+			t.Errorf("expected an error for %q", signature)
+		}
+	}
+}
+
+func TestSet(t *testing.T) {
+	in := New(evaluator.Options{})
+	globals := map[string]interface{}{
+		"count": 3,
+		"ratio": 0.5,
+		"name":  "taurine",
+		"ok":    true,
+		"list":  []int{1, 2},
+		"props": map[string]interface{}{"a": 1, "b": []string{"c"}},
+		"none":  nil,
+	}
+	for name, val := range globals {
+		if err := in.Set(name, val); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+	}
+
+	src := "etch count, ratio, name, ok, list, props, none;\ncount += 1;\netch count;"
+	output, err := runSource(t, in, src)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if expected := "3 0.500000 taurine true [1, 2] {a: 1, b: [\"c\"]} nil\n4\n"; output != expected {
+		t.Errorf("expected %q but found %q", expected, output)
+	}
+
+	// a variable keeps the type of the value it was given
+	if _, err := runSource(t, in, "count = \"three\";"); err == nil || !strings.Contains(err.Error(), "cannot assign str to 'count' of type int") {
+		t.Errorf("expected an error assigning to count but found %v", err)
+	}
+	if err := in.Set("ch", make(chan int)); err == nil {
+		t.Error("expected an error setting a chan")
+	}
+	if _, err := runSource(t, in, "etch missing;"); err == nil || !strings.Contains(err.Error(), "'missing' was not declared") {
+		t.Errorf("expected an undeclared variable error but found %v", err)
+	}
+}
+
+func TestCall(t *testing.T) {
+	in := New(evaluator.Options{})
+	src := `func (int) double(int n) {
+  return n * 2;
+}
+func (str) greet(str greeting, str names...) {
+  return greeting + " " + names.join(", ");
+}
+export double;
+export greet;
+export var (int) total = 1;
+`
+	if _, err := runSource(t, in, src); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	tests := []struct {
+		name     string
+		args     []interface{}
+		expected string
+	}{
+		{"double", []interface{}{21}, "42"},
+		{"greet", []interface{}{"hi", "a", "b"}, "\"hi a, b\""},
+		{"greet", []interface{}{"hi"}, "\"hi \""},
+	}
+	for _, test := range tests {
+		val, err := in.Call(test.name, test.args...)
+		if err != nil {
+			t.Errorf("unexpected error calling %s: %s", test.name, err)
+		} else if val.String() != test.expected {
+			t.Errorf("expected %s from %s but found %s", test.expected, test.name, val)
+		}
+	}
+
+	errs := []struct {
+		name string
+		args []interface{}
+		msg  string
+	}{
+		{"missing", nil, "'missing' is not exported"},
+		{"total", nil, "'total' is not a function"},
+		{"double", nil, "missing argument for 'n' in call to 'double'"},
+		{"double", []interface{}{1, 2}, "expected '1' arguments but got '2' for call to 'double'"},
+		{"double", []interface{}{"1"}, "cannot assign str to 'n' of type int"},
+	}
+	for _, test := range errs {
+		if _, err := in.Call(test.name, test.args...); err == nil || !strings.Contains(err.Error(), test.msg) {
+			t.Errorf("expected %q calling %s but found %v", test.msg, test.name, err)
+		}
+	}
+}
+
+func TestCallLimits(t *testing.T) {
+	var output strings.Builder
+	in := New(evaluator.Options{Stdout: &output, MaxSteps: 2000, MaxOutput: 100})
+	src := `func (int) loop(int n) {
+  var (int) total = 0;
+  for i in 0..n {
+    total += i;
+  }
+  etch "looped", n;
+  return total;
+}
+export loop;
+`
+	if err := in.RunSource("/src/main.tc", src); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	// each call can take as many steps and write as much output as the limits allow, however many calls came before it
+	for i := 0; i < 20; i++ {
+		val, err := in.Call("loop", 100)
+		if err != nil {
+			t.Fatalf("unexpected error in call %d: %s", i+1, err)
+		}
+		if val.String() != "4950" {
+			t.Fatalf("expected 4950 from call %d but found %s", i+1, val)
+		}
+	}
+	if expected := strings.Repeat("looped 100\n", 20); output.String() != expected {
+		t.Errorf("expected %q but found %q", expected, output.String())
+	}
+
+	// a call which goes past the limits still fails, without stopping the calls after it
+	var stepErr *evaluator.StepLimitError
+	if _, err := in.Call("loop", 10000); !errors.As(err, &stepErr) {
+		t.Errorf("expected a step limit error but found %v", err)
+	}
+	var outputErr *evaluator.OutputLimitError
+	long := strings.Repeat("a", 200)
+	if err := in.RunSource("/src/main.tc", "func (void) say(str s) {\n  etch s;\n}\nexport say;"); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if _, err := in.Call("say", long); !errors.As(err, &outputErr) {
+		t.Errorf("expected an output limit error but found %v", err)
+	}
+	if _, err := in.Call("say", "short"); err != nil {
+		t.Errorf("unexpected error after a call went past the limit: %s", err)
+	}
+}
+
+func TestIO(t *testing.T) {
+	var output strings.Builder
+	in := New(evaluator.Options{Stdin: strings.NewReader("taurine\ncoffee\n"), Stdout: &output})
+	src := "read a, \"first: \";\nread b, \"second: \";\nread c, \"third: \";\netch a, b, c;"
+	if err := in.RunSource("/src/main.tc", src); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if expected := "first: second: third: taurine coffee nil\n"; output.String() != expected {
+		t.Errorf("expected %q but found %q", expected, output.String())
+	}
+}
+
+func TestImports(t *testing.T) {
+	dir := t.TempDir()
+	lib := "import scale from \"scale.tc\";\nexport var (int) scaled = scale(factor);\n"
+	if err := os.WriteFile(filepath.Join(dir, "lib.tc"), []byte(lib), 0644); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	scale := "func (int) scale(int n) {\n  return n * factor;\n}\nexport scale;\n"
+	if err := os.WriteFile(filepath.Join(dir, "scale.tc"), []byte(scale), 0644); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	// every file can use the builtins
+	in := New(evaluator.Options{})
+	if err := in.Set("factor", 3); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	var output strings.Builder
+	in.opts.Stdout = &output
+	if err := in.RunSource(filepath.Join(dir, "main.tc"), "import scaled from \"lib.tc\";\netch scaled;"); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if output.String() != "9\n" {
+		t.Errorf("expected \"9\\n\" but found %q", output.String())
+	}
+}
@@ -0,0 +1,62 @@
+package taurine
+
+import (
+	"fmt"
+	"math/big"
+	"reflect"
+
+	"github.com/mcjcloud/taurine/pkg/value"
+)
+
+// ValueOf converts a Go value to a Taurine value
+// nil is nil, bools, integers, floats and strings are bool, int, num and str, slices and arrays are arrs, and maps with string keys are objs
+// a value.Value is returned as it is
+func ValueOf(v interface{}) (value.Value, error) {
+	switch t := v.(type) {
+	case nil:
+		return &value.Nil{}, nil
+	case value.Value:
+		return t, nil
+	case *big.Int:
+		return &value.Int{Value: new(big.Int).Set(t)}, nil
+	}
+
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Bool:
+		return &value.Bool{Value: rv.Bool()}, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return value.NewInt(rv.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return &value.Int{Value: new(big.Int).SetUint64(rv.Uint())}, nil
+	case reflect.Float32, reflect.Float64:
+		return &value.Num{Value: rv.Float()}, nil
+	case reflect.String:
+		return &value.Str{Value: rv.String()}, nil
+	case reflect.Slice, reflect.Array:
+		arr := &value.Arr{Elements: make([]value.Value, rv.Len())}
+		for i := range arr.Elements {
+			el, err := ValueOf(rv.Index(i).Interface())
+			if err != nil {
+				return nil, err
+			}
+			arr.Elements[i] = el
+		}
+		return arr, nil
+	case reflect.Map:
+		if rv.Type().Key().Kind() != reflect.String {
+			break
+		}
+		obj := value.NewObj()
+		iter := rv.MapRange()
+		for iter.Next() {
+			prop, err := ValueOf(iter.Value().Interface())
+			if err != nil {
+				return nil, err
+			}
+			obj.Properties[iter.Key().String()] = prop
+		}
+		return obj, nil
+	}
+	return nil, fmt.Errorf("cannot convert %T to a taurine value", v)
+}
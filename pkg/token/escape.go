@@ -0,0 +1,36 @@
+package token
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// Escape converts a string value back into the contents of a string literal, escaping any characters
+// which can't appear in source code as-is
+func Escape(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch r {
+		case '"':
+			b.WriteString(`\"`)
+		case '\\':
+			b.WriteString(`\\`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\t':
+			b.WriteString(`\t`)
+		case '\r':
+			b.WriteString(`\r`)
+		case 0:
+			b.WriteString(`\0`)
+		default:
+			if unicode.IsPrint(r) {
+				b.WriteRune(r)
+			} else {
+				b.WriteString(fmt.Sprintf(`\u{%X}`, r))
+			}
+		}
+	}
+	return b.String()
+}
@@ -67,3 +67,10 @@ func (s *Scanner) Unread() {
   }
 }
 
+// Pos returns the position of the next character to be read
+func (s *Scanner) Pos() Pos {
+  return Pos{
+    Row: s.Row,
+    Col: s.Col,
+  }
+}
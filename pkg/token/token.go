@@ -27,3 +27,12 @@ func NewToken(t, v string, scanner Scanner) *Token {
   }
 }
 
+
+// NewTokenAt creates a token at a known position
+func NewTokenAt(t, v string, pos Pos) *Token {
+  return &Token{
+    Type:     t,
+    Value:    v,
+    Position: pos,
+  }
+}
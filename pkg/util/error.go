@@ -15,7 +15,8 @@ type ParseError struct {
 
 // ErrorHandler keeps track of errors that occur during parsing
 type ErrorHandler struct {
-	Errors []ParseError
+	Errors   []ParseError
+	Warnings []ParseError // problems which don't stop the program from running, like a variable shadowing another
 }
 
 func NewErrorHandler() *ErrorHandler {
@@ -34,6 +35,14 @@ func (h *ErrorHandler) Add(tkn *token.Token, msg string) *ast.ErrorNode {
 	}
 }
 
+// Warn records a warning, which is reported without stopping the program from running
+func (h *ErrorHandler) Warn(tkn *token.Token, msg string) {
+	h.Warnings = append(h.Warnings, ParseError{
+		Message: msg,
+		Token:   tkn,
+	})
+}
+
 // Specific errors that can occur
 type AlreadyParsedError struct {
 	Path string
@@ -140,6 +140,9 @@ func (graph *ImportGraph) FindCycles() []string {
 			g = *vs
 			b = append(b, c)
 		}
+		// n and everything it imports have been searched, so a file importing n isn't part of a cycle through it
+		g = *remove(&g, n)
+		b = append(b, n)
 	}
 	return make([]string, 0)
 }
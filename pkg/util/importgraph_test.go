@@ -47,3 +47,21 @@ func TestImportGraph(t *testing.T) {
 		t.Errorf("matrix entry for 'c' should be [] but was %s", c)
 	}
 }
+
+func TestFindCycles(t *testing.T) {
+	// the nodes are searched in the random order of a map, so each graph is searched a number of times
+	for i := 0; i < 50; i++ {
+		g := NewImportGraph("a")
+		g.Add("a", "b")
+		g.Add("b", "c")
+		g.Add("c", "d")
+		if cycles := g.FindCycles(); len(cycles) > 0 {
+			t.Fatalf("expected no cycles but found %s", cycles)
+		}
+
+		g.Add("d", "b")
+		if cycles := g.FindCycles(); len(cycles) == 0 {
+			t.Fatal("expected a cycle through b, c and d")
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package value
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// Len returns the length of a str or arr, which is what len() returns
+func Len(v Value) (*Int, error) {
+	if str, ok := v.(*Str); ok {
+		return NewInt(int64(len(str.Value))), nil
+	} else if arr, ok := v.(*Arr); ok {
+		return NewInt(int64(len(arr.Elements))), nil
+	}
+	return nil, errors.New("len can only be called on type str or arr")
+}
+
+// ToInt converts a num or str to an int, which is what int() returns
+func ToInt(v Value) (*Int, error) {
+	if num, ok := v.(*Num); ok {
+		if i, ok := FloatToInt(num.Value); ok {
+			return &Int{Value: i}, nil
+		}
+		return nil, fmt.Errorf("cannot convert %s to int", num)
+	} else if str, ok := v.(*Str); ok {
+		if i, ok := new(big.Int).SetString(strings.TrimSpace(str.Value), 10); ok {
+			return &Int{Value: i}, nil
+		}
+		return nil, fmt.Errorf("cannot convert %s to int", str)
+	}
+	return nil, errors.New("int() can only be called on type num or str")
+}
+
+// NewErr creates an err whose message is a str, which is what err() returns
+func NewErr(v Value) (*Err, error) {
+	if str, ok := v.(*Str); ok {
+		return &Err{Message: str.Value}, nil
+	}
+	return nil, errors.New("err() can only be called on type str")
+}
@@ -0,0 +1,91 @@
+package value
+
+import (
+	"fmt"
+	"hash/fnv"
+	"math"
+	"math/big"
+	"strconv"
+)
+
+// Comparable returns true if a and b can be compared with '==', which requires them to have the same type
+// except that a num can be compared with an int, and anything can be compared with nil
+func Comparable(a, b Value) bool {
+	_, aNum := ToFloat(a)
+	_, bNum := ToFloat(b)
+	_, aNil := a.(*Nil)
+	_, bNil := b.(*Nil)
+	return a.Type() == b.Type() || (aNum && bNum) || aNil || bNil
+}
+
+// Equal returns true if a and b are the same value
+// numbers are equal if they have the same numeric value, even if one is a num and the other is an int
+// arrays, objects, and functions are only equal to themselves, since they are references
+func Equal(a, b Value) bool {
+	switch x := a.(type) {
+	case *Int:
+		if y, ok := b.(*Int); ok {
+			return x.Value.Cmp(y.Value) == 0
+		}
+		if y, ok := b.(*Num); ok {
+			return intEqualsFloat(x.Value, y.Value)
+		}
+	case *Num:
+		if y, ok := b.(*Num); ok {
+			return x.Value == y.Value
+		}
+		if y, ok := b.(*Int); ok {
+			return intEqualsFloat(y.Value, x.Value)
+		}
+	case *Str:
+		if y, ok := b.(*Str); ok {
+			return x.Value == y.Value
+		}
+	case *Bool:
+		if y, ok := b.(*Bool); ok {
+			return x.Value == y.Value
+		}
+	case *Nil:
+		_, ok := b.(*Nil)
+		return ok
+	default:
+		return a == b
+	}
+	return false
+}
+
+// intEqualsFloat compares exactly, so large integers aren't rounded to a float which they would equal
+func intEqualsFloat(i *big.Int, f float64) bool {
+	if math.IsInf(f, 0) || math.IsNaN(f) {
+		return false
+	}
+	return new(big.Float).SetInt(i).Cmp(big.NewFloat(f)) == 0
+}
+
+// Hash returns a hash of v such that values which are Equal have the same hash
+// arrays, objects, and functions can't be hashed since they can be changed after they're hashed
+func Hash(v Value) (uint64, error) {
+	var key string
+	switch t := v.(type) {
+	case *Int:
+		key = "n" + t.Value.String()
+	case *Num:
+		// a whole number has the same hash as the int it's equal to
+		if i, ok := FloatToInt(t.Value); ok && t.Value == math.Trunc(t.Value) {
+			key = "n" + i.String()
+		} else {
+			key = "n" + strconv.FormatFloat(t.Value, 'g', -1, 64)
+		}
+	case *Str:
+		key = "s" + t.Value
+	case *Bool:
+		key = "b" + strconv.FormatBool(t.Value)
+	case *Nil:
+		key = "nil"
+	default:
+		return 0, fmt.Errorf("cannot hash a value of type %s", v.Type())
+	}
+	h := fnv.New64a()
+	h.Write([]byte(key))
+	return h.Sum64(), nil
+}
@@ -0,0 +1,106 @@
+package value
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+
+	"github.com/mcjcloud/taurine/pkg/ast"
+)
+
+// Convert converts v to the data type t, which only changes an int to a num
+// an error is returned if v can't be stored as type t, which includes nil unless t is optional
+func Convert(v Value, t ast.Symbol) (Value, error) {
+	if t.IsOptional() {
+		if _, ok := v.(*Nil); ok {
+			return v, nil
+		}
+		t = t.BaseType()
+	}
+	if v.Type() == t {
+		return v, nil
+	}
+	if i, ok := v.(*Int); ok && t == ast.NUM {
+		return &Num{Value: IntToFloat(i.Value)}, nil
+	}
+	return nil, fmt.Errorf("%s is not of type %s", v.Type(), t)
+}
+
+// ConformVariable converts the value being stored in the variable named name to the variable's declared type dType
+// a variable stored without a declaration e.g. by read has no type, and can hold anything
+func ConformVariable(name string, dType ast.Symbol, v Value) (Value, error) {
+	if dType == "" {
+		return v, nil
+	}
+	conformed, err := Convert(v, dType)
+	if err != nil {
+		return nil, fmt.Errorf("cannot assign %s to '%s' of type %s", v.Type(), name, dType)
+	}
+	return conformed, nil
+}
+
+// ConformParameter converts the argument of a parameter to the parameter's type
+// each element of a variadic parameter is converted to the parameter's type
+func ConformParameter(param *ast.VariableDecleration, arg Value) (Value, error) {
+	dType := ast.Symbol(param.SymbolType)
+	if rest, ok := arg.(*Arr); ok && param.Variadic {
+		for i, el := range rest.Elements {
+			v, err := ConformVariable(param.Symbol, dType, el)
+			if err != nil {
+				return nil, err
+			}
+			rest.Elements[i] = v
+		}
+		dType = ast.ARR
+	}
+	return ConformVariable(param.Symbol, dType, arg)
+}
+
+// ConformField converts v to the type of a record's field, returning an error if it can't be stored in the field
+func ConformField(field Field, v Value) (Value, error) {
+	conformed, err := Convert(v, field.Type)
+	if err != nil {
+		return nil, fmt.Errorf("cannot assign %s to field '%s' of type %s", v.Type(), field.Name, field.Type)
+	}
+	return conformed, nil
+}
+
+// ConformReturn converts the value returned from the function declared by decl to its return type
+// the value of a void function, or of the top level of a file where decl is nil, isn't converted
+func ConformReturn(decl *ast.FunctionLiteral, v Value) (Value, error) {
+	if decl == nil || decl.ReturnType == ast.VOID {
+		return v, nil
+	}
+	converted, err := Convert(v, ast.Symbol(decl.ReturnType))
+	if err != nil {
+		return nil, fmt.Errorf("cannot return %s from function of type %s", v.Type(), decl.ReturnType)
+	}
+	return converted, nil
+}
+
+// ToFloat returns the value of a num or int as a float64, and false if v isn't a number
+func ToFloat(v Value) (float64, bool) {
+	switch t := v.(type) {
+	case *Num:
+		return t.Value, true
+	case *Int:
+		return IntToFloat(t.Value), true
+	}
+	return 0, false
+}
+
+// IntToFloat converts an integer to the nearest float64
+func IntToFloat(i *big.Int) float64 {
+	f, _ := new(big.Float).SetInt(i).Float64()
+	return f
+}
+
+// FloatToInt converts a float64 to an integer, dropping anything after the decimal point
+// false is returned if f is infinite or not a number
+func FloatToInt(f float64) (*big.Int, bool) {
+	if math.IsInf(f, 0) || math.IsNaN(f) {
+		return nil, false
+	}
+	i, _ := big.NewFloat(f).Int(nil)
+	return i, true
+}
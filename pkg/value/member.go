@@ -0,0 +1,199 @@
+package value
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/mcjcloud/taurine/pkg/ast"
+)
+
+// Caller calls a function with already evaluated arguments, which is how methods like map call back into taurine
+type Caller func(fn Func, args []Value) (Value, error)
+
+// Member returns a property of a str, arr, or err e.g. arr.length
+func Member(v Value, name string) (Value, error) {
+	switch t := v.(type) {
+	case *Str:
+		if name == "length" {
+			return &Num{Value: float64(len(t.Value))}, nil
+		}
+		return nil, fmt.Errorf("error resolving property '%s'", name)
+	case *Arr:
+		if name == "length" {
+			return &Num{Value: float64(len(t.Elements))}, nil
+		}
+		return nil, fmt.Errorf("error resolving proprty '%s'", name)
+	case *Err:
+		if name == "message" {
+			return &Str{Value: t.Message}, nil
+		}
+		return nil, fmt.Errorf("error resolving property '%s'", name)
+	}
+	return nil, fmt.Errorf("'.' cannot be applied to %s", v.Type())
+}
+
+// CallMember calls a method of a str or arr with already evaluated arguments e.g. str.substr(1, 3)
+// methods which take a function, like map, use call to call it
+func CallMember(v Value, name string, args []Value, call Caller) (Value, error) {
+	switch t := v.(type) {
+	case *Str:
+		return callStr(t, name, args)
+	case *Arr:
+		return callArr(t, name, args, call)
+	case *Err:
+		return nil, fmt.Errorf("error resolving property '%s'", name)
+	}
+	return nil, fmt.Errorf("'.' cannot be applied to %s", v.Type())
+}
+
+func callStr(str *Str, name string, args []Value) (Value, error) {
+	switch name {
+	case "toUpperCase":
+		return &Str{Value: strings.ToUpper(str.Value)}, nil
+	case "toLowerCase":
+		return &Str{Value: strings.ToLower(str.Value)}, nil
+	case "toArray":
+		// convert the string to an array of its characters
+		res := make([]Value, 0)
+		for _, c := range str.Value {
+			res = append(res, &Str{Value: string(c)})
+		}
+		return &Arr{Elements: res}, nil
+	case "substr":
+		start, end, err := sliceBounds("substr", args, len(str.Value))
+		if err != nil {
+			return nil, err
+		}
+		return &Str{Value: str.Value[start:end]}, nil
+	}
+	return nil, fmt.Errorf("error resolving property '%s'", name)
+}
+
+func callArr(arr *Arr, name string, args []Value, call Caller) (Value, error) {
+	switch name {
+	case "slice":
+		start, end, err := sliceBounds("slice", args, len(arr.Elements))
+		if err != nil {
+			return nil, err
+		}
+		return &Arr{Elements: arr.Elements[start:end]}, nil
+	case "map":
+		return arrMap(arr, args, call)
+	case "forEach":
+		_, err := arrMap(arr, args, call)
+		return nil, err
+	case "join":
+		return arrJoin(arr, args)
+	case "push":
+		// push elements to the end of the array
+		arr.Elements = append(arr.Elements, args...)
+		return nil, nil
+	case "pop":
+		// pop the last element from the array and return it
+		arr.Elements = arr.Elements[:len(arr.Elements)-1]
+		return arr.Elements[len(arr.Elements)-1], nil
+	}
+	return nil, fmt.Errorf("error resolving function '%s'", name)
+}
+
+// sliceBounds returns the start and end indexes given to slice or substr, where the end defaults to length
+func sliceBounds(method string, args []Value, length int) (int, int, error) {
+	if len(args) < 1 || len(args) > 2 {
+		return 0, 0, fmt.Errorf("expected 1-2 argument but found %d", len(args))
+	}
+	start, ok := toIndex(args[0])
+	if !ok {
+		return 0, 0, fmt.Errorf("expected integer for first argument to %s but found %v", method, args[0])
+	}
+	end := length
+	if len(args) == 2 {
+		if end, ok = toIndex(args[1]); !ok {
+			return 0, 0, fmt.Errorf("expected integer for second argument to %s but found %v", method, args[1])
+		}
+	}
+
+	// check out of range
+	if start < 0 || start > end {
+		return 0, 0, fmt.Errorf("start index is outside of range 0-%d", end)
+	}
+	if end > length {
+		return 0, 0, fmt.Errorf("end index is outside of range %d-%d", start, length)
+	}
+	return start, end, nil
+}
+
+// toIndex returns the value of an int, or a num with no fractional part, for use as an index
+func toIndex(v Value) (int, bool) {
+	switch t := v.(type) {
+	case *Int:
+		return int(t.Value.Int64()), t.Value.IsInt64()
+	case *Num:
+		return int(t.Value), t.Value == float64(int(t.Value))
+	}
+	return 0, false
+}
+
+// arrMap calls a function with each element of an array, along with its index and the length of the array
+func arrMap(arr *Arr, args []Value, call Caller) (*Arr, error) {
+	if len(args) != 1 {
+		return nil, fmt.Errorf("expected 1 argument in map but found %d", len(args))
+	}
+	fn, ok := args[0].(Func)
+	if !ok {
+		return nil, fmt.Errorf("expected function argument to map")
+	}
+
+	// check parameters
+	decl := fn.Declaration()
+	if len(decl.Parameters) == 2 && decl.Parameters[1].SymbolType != ast.NUM {
+		return nil, errors.New("expected num for second argument type")
+	}
+	if len(decl.Parameters) == 3 && decl.Parameters[2].SymbolType != ast.NUM {
+		return nil, errors.New("expected num for third argument type")
+	}
+
+	// check return type
+	if decl.ReturnType == ast.VOID {
+		return nil, errors.New("map function must have return type")
+	}
+
+	// loop over array expressions
+	newArr := make([]Value, 0)
+	for i, exp := range arr.Elements {
+		// build args, passing only as many as the function accepts
+		fnArgs := []Value{
+			exp,
+			&Num{Value: float64(i)},
+			&Num{Value: float64(len(arr.Elements))},
+		}
+		if len(decl.Parameters) < len(fnArgs) {
+			fnArgs = fnArgs[:len(decl.Parameters)]
+		}
+
+		val, err := call(fn, fnArgs)
+		if err != nil {
+			return nil, err
+		}
+		newArr = append(newArr, val)
+	}
+	return &Arr{Elements: newArr}, nil
+}
+
+// arrJoin joins the elements of an array into a string, separated by the given string
+func arrJoin(arr *Arr, args []Value) (*Str, error) {
+	if len(args) < 1 {
+		return nil, fmt.Errorf("expected 1 argument to join")
+	}
+	if str, ok := args[0].(*Str); ok {
+		var result string
+		for i, exp := range arr.Elements {
+			result += Stringify(exp)
+			if i < len(arr.Elements)-1 {
+				result += str.Value
+			}
+		}
+		return &Str{Value: result}, nil
+	}
+	return nil, fmt.Errorf("expected string for argument to join but found %s", args[0])
+}
@@ -0,0 +1,249 @@
+package value
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/mcjcloud/taurine/pkg/ast"
+)
+
+// BinaryOperation applies an operator to two evaluated operands
+func BinaryOperation(op ast.Operator, left, right Value) (Value, error) {
+	switch op {
+	case ast.PLUS:
+		return add(left, right)
+	case ast.MINUS:
+		return minus(left, right)
+	case ast.MULTIPLY:
+		return multiply(left, right)
+	case ast.DIVIDE:
+		return divide(left, right)
+	case ast.MODULO:
+		return modulo(left, right)
+	case ast.EQUAL_EQUAL:
+		return equalEqual(left, right)
+	case ast.NOT_EQUAL:
+		return notEqual(left, right)
+	case ast.LESS_THAN:
+		return lessThan(left, right)
+	case ast.LESS_EQUAL:
+		return lessEqual(left, right)
+	case ast.GREATER_THAN:
+		return greaterThan(left, right)
+	case ast.GREATER_EQUAL:
+		return greaterEqual(left, right)
+	case ast.AT:
+		return arrayIndex(left, right)
+	case ast.RANGE:
+		return createRange(left, right)
+	default:
+		return nil, fmt.Errorf("unrecognized operator '%s'", op)
+	}
+}
+
+func add(left, right Value) (Value, error) {
+	if leftNum, ok := left.(*Num); ok {
+
+		// add either num, int, or string
+		if rightNum, ok := right.(*Num); ok {
+			return &Num{Value: leftNum.Value + rightNum.Value}, nil
+		} else if rightInt, ok := right.(*Int); ok {
+			return &Num{Value: leftNum.Value + IntToFloat(rightInt.Value)}, nil
+		} else if rightStr, ok := right.(*Str); ok {
+			return &Str{Value: fmt.Sprintf("%f%s", leftNum.Value, rightStr.Value)}, nil
+		}
+	} else if leftInt, ok := left.(*Int); ok {
+
+		// add either num, int, or string
+		if rightNum, ok := right.(*Num); ok {
+			return &Num{Value: IntToFloat(leftInt.Value) + rightNum.Value}, nil
+		} else if rightInt, ok := right.(*Int); ok {
+			return &Int{Value: new(big.Int).Add(leftInt.Value, rightInt.Value)}, nil
+		} else if rightStr, ok := right.(*Str); ok {
+			return &Str{Value: fmt.Sprintf("%s%s", leftInt.Value, rightStr.Value)}, nil
+		}
+	} else if leftStr, ok := left.(*Str); ok {
+
+		// add stringified version of whatever is on right side
+		return &Str{Value: leftStr.Value + Stringify(right)}, nil
+	}
+	return nil, fmt.Errorf("'+' operator is not applicable to arguments %s and %s", left.Type(), right.Type())
+}
+
+// Negate applies the unary '-' operator to a number
+func Negate(v Value) (Value, error) {
+	if num, ok := v.(*Num); ok {
+		return &Num{Value: -num.Value}, nil
+	} else if i, ok := v.(*Int); ok {
+		return &Int{Value: new(big.Int).Neg(i.Value)}, nil
+	}
+	return nil, errors.New("unary '-' operator only applies to type num")
+}
+
+func minus(left, right Value) (Value, error) {
+	if leftInt, ok := left.(*Int); ok {
+		if rightInt, ok := right.(*Int); ok {
+			return &Int{Value: new(big.Int).Sub(leftInt.Value, rightInt.Value)}, nil
+		}
+	}
+	if l, r, ok := toFloats(left, right); ok {
+		return &Num{Value: l - r}, nil
+	}
+	return nil, errors.New("'-' operator only applies to type num")
+}
+
+func multiply(left, right Value) (Value, error) {
+	if leftInt, ok := left.(*Int); ok {
+		if rightInt, ok := right.(*Int); ok {
+			return &Int{Value: new(big.Int).Mul(leftInt.Value, rightInt.Value)}, nil
+		}
+	}
+	if l, r, ok := toFloats(left, right); ok {
+		return &Num{Value: l * r}, nil
+	}
+	return nil, errors.New("'*' operator only applies to type num")
+}
+
+func divide(left, right Value) (Value, error) {
+	if leftInt, ok := left.(*Int); ok {
+		if rightInt, ok := right.(*Int); ok {
+			if rightInt.Value.Sign() == 0 {
+				return nil, errors.New("divide by 0 error")
+			}
+			return &Int{Value: new(big.Int).Div(leftInt.Value, rightInt.Value)}, nil
+		}
+	}
+	if l, r, ok := toFloats(left, right); ok {
+		if r == 0 {
+			return nil, errors.New("divide by 0 error")
+		}
+		return &Num{Value: l / r}, nil
+	}
+	return nil, errors.New("'/' operator only applies to type num")
+}
+
+func modulo(left, right Value) (Value, error) {
+	if leftInt, ok := left.(*Int); ok {
+		if rightInt, ok := right.(*Int); ok {
+			if rightInt.Value.Sign() == 0 {
+				return nil, errors.New("divide by 0 error")
+			}
+			return &Int{Value: new(big.Int).Mod(leftInt.Value, rightInt.Value)}, nil
+		}
+	}
+	return nil, errors.New("'%' operator only applies to integers")
+}
+
+// toFloats returns both operands as float64s, and false if either of them isn't a number
+func toFloats(left, right Value) (float64, float64, bool) {
+	l, lok := ToFloat(left)
+	r, rok := ToFloat(right)
+	return l, r, lok && rok
+}
+
+func equalEqual(left, right Value) (Value, error) {
+	if !Comparable(left, right) {
+		return nil, fmt.Errorf("'==' cannot be applied to %s and %s", left.Type(), right.Type())
+	}
+	return &Bool{Value: Equal(left, right)}, nil
+}
+
+func notEqual(left, right Value) (Value, error) {
+	if !Comparable(left, right) {
+		return nil, fmt.Errorf("'!=' cannot be applied to %s and %s", left.Type(), right.Type())
+	}
+	return &Bool{Value: !Equal(left, right)}, nil
+}
+
+// compare returns -1, 0, or 1 if left is less than, equal to, or greater than right
+// false is returned if the operands aren't both numbers
+func compare(left, right Value) (int, bool) {
+	if leftInt, ok := left.(*Int); ok {
+		if rightInt, ok := right.(*Int); ok {
+			return leftInt.Value.Cmp(rightInt.Value), true
+		}
+	}
+	l, r, ok := toFloats(left, right)
+	if !ok {
+		return 0, false
+	}
+	if l < r {
+		return -1, true
+	} else if l > r {
+		return 1, true
+	}
+	return 0, true
+}
+
+func lessThan(left, right Value) (Value, error) {
+	if c, ok := compare(left, right); ok {
+		return &Bool{Value: c < 0}, nil
+	}
+	return nil, fmt.Errorf("'<' cannot be applied to %s and %s", left.Type(), right.Type())
+}
+
+func lessEqual(left, right Value) (Value, error) {
+	if c, ok := compare(left, right); ok {
+		return &Bool{Value: c <= 0}, nil
+	}
+	return nil, fmt.Errorf("'<=' cannot be applied to %s and %s", left.Type(), right.Type())
+}
+
+func greaterThan(left, right Value) (Value, error) {
+	if c, ok := compare(left, right); ok {
+		return &Bool{Value: c > 0}, nil
+	}
+	return nil, fmt.Errorf("'>' cannot be applied to %s and %s", left.Type(), right.Type())
+}
+
+func greaterEqual(left, right Value) (Value, error) {
+	if c, ok := compare(left, right); ok {
+		return &Bool{Value: c >= 0}, nil
+	}
+	return nil, fmt.Errorf("'>=' cannot be applied to %s and %s", left.Type(), right.Type())
+}
+
+func arrayIndex(left, right Value) (Value, error) {
+	if leftArr, ok := left.(*Arr); ok {
+		if rightNum, ok := right.(*Int); ok {
+			i := int(rightNum.Value.Int64())
+			if i < 0 || i >= len(leftArr.Elements) {
+				return nil, fmt.Errorf("index %d out of range", i)
+			}
+			return leftArr.Elements[i], nil
+		}
+	} else if leftStr, ok := left.(*Str); ok {
+		if rightNum, ok := right.(*Int); ok {
+			i := int(rightNum.Value.Int64())
+			if i < 0 || i >= len(leftStr.Value) {
+				return nil, fmt.Errorf("index %d out of range", i)
+			}
+			return &Str{Value: string([]rune(leftStr.Value)[i])}, nil
+		}
+	}
+	return nil, errors.New("'@' operator must be in form arr@integer")
+}
+
+func createRange(left, right Value) (Value, error) {
+	// make sure each left and right operator are integers
+	if leftNum, ok := left.(*Int); ok {
+		if rightNum, ok := right.(*Int); ok {
+			var direction int
+			if leftNum.Value.Cmp(rightNum.Value) < 0 {
+				direction = 1
+			} else if leftNum.Value.Cmp(rightNum.Value) > 0 {
+				direction = -1
+			} else {
+				return &Arr{Elements: []Value{leftNum}}, nil
+			}
+			// use direction to iterate and populate array
+			arr := make([]Value, 0)
+			for i := int(leftNum.Value.Int64()); i != int(rightNum.Value.Int64()); i += direction {
+				arr = append(arr, NewInt(int64(i)))
+			}
+			return &Arr{Elements: arr}, nil
+		}
+	}
+	return nil, errors.New("'..' must have operands of type integer")
+}
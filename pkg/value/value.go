@@ -0,0 +1,211 @@
+package value
+
+import (
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+
+	"github.com/mcjcloud/taurine/pkg/ast"
+	"github.com/mcjcloud/taurine/pkg/token"
+)
+
+// Value is the result of evaluating an expression
+type Value interface {
+	// Type returns the data type of the value e.g. num
+	Type() ast.Symbol
+	// String returns the value the way it's printed inside of an array or object
+	String() string
+}
+
+// Num is a floating point number
+type Num struct {
+	Value float64
+}
+
+func (n *Num) Type() ast.Symbol { return ast.NUM }
+func (n *Num) String() string {
+	return fmt.Sprintf("%f", n.Value)
+}
+
+// Int is an integer of any size
+// the big.Int is never modified, so it can be shared between values
+type Int struct {
+	Value *big.Int
+}
+
+// NewInt creates an Int from an int64
+func NewInt(i int64) *Int {
+	return &Int{Value: big.NewInt(i)}
+}
+
+func (i *Int) Type() ast.Symbol { return ast.INT }
+func (i *Int) String() string {
+	return i.Value.String()
+}
+
+// Str is a string
+type Str struct {
+	Value string
+}
+
+func (s *Str) Type() ast.Symbol { return ast.STR }
+func (s *Str) String() string {
+	return fmt.Sprintf("\"%s\"", token.Escape(s.Value))
+}
+
+// Bool is a boolean
+type Bool struct {
+	Value bool
+}
+
+func (b *Bool) Type() ast.Symbol { return ast.BOOL }
+func (b *Bool) String() string {
+	return fmt.Sprintf("%v", b.Value)
+}
+
+// Arr is an array; it is shared by every variable it is assigned to
+type Arr struct {
+	Elements []Value
+}
+
+func (a *Arr) Type() ast.Symbol { return ast.ARR }
+func (a *Arr) String() string {
+	elements := make([]string, len(a.Elements))
+	for i, el := range a.Elements {
+		elements[i] = el.String()
+	}
+	return "[" + strings.Join(elements, ", ") + "]"
+}
+
+// Obj is an object; it is shared by every variable it is assigned to
+type Obj struct {
+	Properties map[string]Value
+}
+
+// NewObj creates an object with no properties
+func NewObj() *Obj {
+	return &Obj{Properties: make(map[string]Value)}
+}
+
+func (o *Obj) Type() ast.Symbol { return ast.OBJ }
+func (o *Obj) String() string {
+	// properties are printed in order of their names so the output is always the same
+	keys := make([]string, 0, len(o.Properties))
+	for k := range o.Properties {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	props := make([]string, len(keys))
+	for i, k := range keys {
+		props[i] = fmt.Sprintf("%s: %s", k, o.Properties[k])
+	}
+	return "{" + strings.Join(props, ", ") + "}"
+}
+
+// Nil is the absence of a value
+// it's the value of a variable which hasn't been given one, a missing property, and the result of calling a void function
+type Nil struct{}
+
+func (n *Nil) Type() ast.Symbol { return ast.NIL }
+func (n *Nil) String() string {
+	return "nil"
+}
+
+// Err is an error, which is created by err() or by catching an error
+type Err struct {
+	Message string
+}
+
+func (e *Err) Type() ast.Symbol { return ast.ERR }
+func (e *Err) String() string {
+	return fmt.Sprintf("err(\"%s\")", token.Escape(e.Message))
+}
+
+// Field is a named, typed field of a record type
+type Field struct {
+	Name string
+	Type ast.Symbol
+}
+
+// RecordType is a type declared with the type keyword
+// calling it creates a record, and its methods are called with the record as their first argument
+type RecordType struct {
+	Name    string
+	Fields  []Field
+	Methods map[string]Func
+}
+
+// NewRecordType creates a record type with no methods
+func NewRecordType(name string, fields []Field) *RecordType {
+	return &RecordType{Name: name, Fields: fields, Methods: make(map[string]Func)}
+}
+
+func (t *RecordType) Type() ast.Symbol { return ast.TYPE }
+func (t *RecordType) String() string {
+	return fmt.Sprintf("type %s", t.Name)
+}
+
+// Field returns the field named name, and false if the type doesn't have one
+func (t *RecordType) Field(name string) (Field, bool) {
+	for _, f := range t.Fields {
+		if f.Name == name {
+			return f, true
+		}
+	}
+	return Field{}, false
+}
+
+// DeclareMethod adds fn, a method declared by decl, to t, the type it belongs to
+func DeclareMethod(t Value, decl *ast.FunctionLiteral, fn Func) error {
+	recordType, ok := t.(*RecordType)
+	if !ok {
+		return fmt.Errorf("'%s' is not a type", decl.Receiver)
+	}
+	if _, ok := recordType.Methods[decl.Symbol]; ok {
+		return fmt.Errorf("method '%s' is already declared on %s", decl.Symbol, recordType.Name)
+	}
+	if _, ok := recordType.Field(decl.Symbol); ok {
+		return fmt.Errorf("%s already has a field named '%s'", recordType.Name, decl.Symbol)
+	}
+	recordType.Methods[decl.Symbol] = fn
+	return nil
+}
+
+// Record is a value of a record type; it is shared by every variable it is assigned to
+type Record struct {
+	RecordType *RecordType
+	Fields     map[string]Value
+}
+
+func (r *Record) Type() ast.Symbol { return ast.Symbol(r.RecordType.Name) }
+func (r *Record) String() string {
+	// fields are printed in the order they were declared
+	fields := make([]string, len(r.RecordType.Fields))
+	for i, f := range r.RecordType.Fields {
+		fields[i] = fmt.Sprintf("%s: %s", f.Name, r.Fields[f.Name])
+	}
+	return r.RecordType.Name + "{" + strings.Join(fields, ", ") + "}"
+}
+
+// Func is a value which can be called
+// functions declared in taurine are implemented by the evaluator, since calling one depends on the scope it was declared in
+type Func interface {
+	Value
+	// Name returns the name the function was declared with, or "" if it is anonymous
+	Name() string
+	// Declaration returns the function literal the function was created from
+	Declaration() *ast.FunctionLiteral
+}
+
+// Stringify returns the text etch prints for v
+// it's the same as v.String(), except that strings are printed as their value rather than quoted
+func Stringify(v Value) string {
+	if str, ok := v.(*Str); ok {
+		return str.Value
+	}
+	if v == nil {
+		return "nil"
+	}
+	return v.String()
+}
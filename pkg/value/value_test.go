@@ -0,0 +1,132 @@
+package value
+
+import (
+	"math"
+	"math/big"
+	"testing"
+
+	"github.com/mcjcloud/taurine/pkg/ast"
+)
+
+func TestString(t *testing.T) {
+	obj := NewObj()
+	obj.Properties["b"] = &Str{Value: "x\n"}
+	obj.Properties["a"] = &Arr{Elements: []Value{NewInt(1), &Num{Value: 2.5}}}
+	point := NewRecordType("Point", []Field{{Name: "y", Type: ast.NUM}, {Name: "x", Type: ast.STR}})
+	rec := &Record{RecordType: point, Fields: map[string]Value{"x": &Str{Value: "a"}, "y": &Num{Value: 1}}}
+
+	tests := []struct {
+		val       Value
+		str       string
+		stringify string
+	}{
+		{&Num{Value: 1.5}, "1.500000", "1.500000"},
+		{NewInt(-3), "-3", "-3"},
+		{&Str{Value: "hi\t"}, "\"hi\\t\"", "hi\t"},
+		{&Bool{Value: true}, "true", "true"},
+		{&Nil{}, "nil", "nil"},
+		{&Err{Message: "bad \"x\""}, "err(\"bad \\\"x\\\"\")", "err(\"bad \\\"x\\\"\")"},
+		{obj, "{a: [1, 2.500000], b: \"x\\n\"}", "{a: [1, 2.500000], b: \"x\\n\"}"},
+		{rec, "Point{y: 1.000000, x: \"a\"}", "Point{y: 1.000000, x: \"a\"}"},
+		{point, "type Point", "type Point"},
+	}
+	for _, test := range tests {
+		if s := test.val.String(); s != test.str {
+			t.Errorf("expected String() to be %q but found %q", test.str, s)
+		}
+		if s := Stringify(test.val); s != test.stringify {
+			t.Errorf("expected Stringify() to be %q but found %q", test.stringify, s)
+		}
+	}
+}
+
+func TestEqual(t *testing.T) {
+	arr := &Arr{}
+	huge, _ := new(big.Int).SetString("9007199254740993", 10)
+	tests := []struct {
+		a, b  Value
+		equal bool
+	}{
+		{NewInt(2), NewInt(2), true},
+		{NewInt(2), &Num{Value: 2}, true},
+		{&Num{Value: 2.5}, NewInt(2), false},
+		// 2^53 + 1 rounds to 2^53 as a float, but they aren't equal
+		{&Int{Value: huge}, &Num{Value: 9007199254740992}, false},
+		{&Str{Value: "a"}, &Str{Value: "a"}, true},
+		{&Bool{Value: true}, &Bool{Value: false}, false},
+		{&Nil{}, &Nil{}, true},
+		{arr, arr, true},
+		{arr, &Arr{}, false},
+		{NewObj(), NewObj(), false},
+	}
+	for _, test := range tests {
+		if eq := Equal(test.a, test.b); eq != test.equal {
+			t.Errorf("expected Equal(%s, %s) to be %v", test.a, test.b, test.equal)
+		}
+	}
+
+	if Comparable(&Str{Value: "1"}, NewInt(1)) {
+		t.Error("expected str and int not to be comparable")
+	}
+	if !Comparable(&Num{Value: 1}, NewInt(1)) {
+		t.Error("expected num and int to be comparable")
+	}
+}
+
+func TestHash(t *testing.T) {
+	equal := [][2]Value{
+		{NewInt(3), &Num{Value: 3}},
+		{&Str{Value: "a"}, &Str{Value: "a"}},
+		{&Nil{}, &Nil{}},
+	}
+	for _, pair := range equal {
+		a, err := Hash(pair[0])
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		b, err := Hash(pair[1])
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if a != b {
+			t.Errorf("expected %s and %s to have the same hash", pair[0], pair[1])
+		}
+	}
+
+	a, _ := Hash(&Str{Value: "1"})
+	b, _ := Hash(NewInt(1))
+	if a == b {
+		t.Error("expected \"1\" and 1 to have different hashes")
+	}
+	if _, err := Hash(&Num{Value: math.Inf(1)}); err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+	if _, err := Hash(&Arr{}); err == nil || err.Error() != "cannot hash a value of type arr" {
+		t.Errorf("expected error hashing arr but found %v", err)
+	}
+}
+
+func TestConvert(t *testing.T) {
+	val, err := Convert(NewInt(4), ast.NUM)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if num, ok := val.(*Num); !ok || num.Value != 4 {
+		t.Errorf("expected 4.000000 but found %s", val)
+	}
+
+	str := &Str{Value: "a"}
+	if val, err := Convert(str, ast.STR); err != nil || val != str {
+		t.Errorf("expected the same str to be returned but found %v, %v", val, err)
+	}
+	if _, err := Convert(&Num{Value: 1}, ast.INT); err == nil || err.Error() != "num is not of type int" {
+		t.Errorf("expected error converting num to int but found %v", err)
+	}
+
+	if _, ok := FloatToInt(math.NaN()); ok {
+		t.Error("expected NaN not to convert to an int")
+	}
+	if i, ok := FloatToInt(-2.7); !ok || i.Int64() != -2 {
+		t.Errorf("expected -2 but found %v", i)
+	}
+}
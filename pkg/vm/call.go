@@ -0,0 +1,164 @@
+package vm
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/mcjcloud/taurine/pkg/ast"
+	"github.com/mcjcloud/taurine/pkg/evaluator"
+	"github.com/mcjcloud/taurine/pkg/value"
+)
+
+// callValue calls fn with the values of the arguments of call, in the order they were passed
+// if a method is being called, receiver is the record it was called on, which is passed before the other arguments
+func (m *machine) callValue(fn value.Value, call *ast.FunctionCall, receiver value.Value, vals []value.Value) (value.Value, error) {
+	// calling a type creates a record of that type
+	if recordType, ok := fn.(*value.RecordType); ok {
+		return evaluator.Construct(recordType, call, vals)
+	}
+	closure, ok := fn.(*Closure)
+	if !ok {
+		return nil, errors.New("called expression did not evaluate to function")
+	}
+
+	args, err := bind(closure, call, receiver, vals)
+	if err != nil {
+		return nil, err
+	}
+	val, err := m.call(closure, args)
+	if err != nil {
+		return nil, evaluator.WithFrame(err, &evaluator.CallFrame{Name: evaluator.FunctionName(call, closure.Function.Declaration), Ref: call.Ref()})
+	}
+	return val, nil
+}
+
+// bind returns the values of the arguments of call in the order of the parameters of closure
+// if a method is being called, receiver is the record it was called on, which is passed before the other arguments
+func bind(closure *Closure, call *ast.FunctionCall, receiver value.Value, vals []value.Value) ([]value.Value, error) {
+	params := closure.Function.Declaration.Parameters
+	args := make([]value.Value, len(params))
+	offset := 0
+	if receiver != nil {
+		args[0] = receiver
+		offset = 1
+	}
+	bound, err := ast.BindArguments(call.Function.String(), params[offset:], call.Arguments)
+	if err != nil {
+		return nil, err
+	}
+	for i, indexes := range bound {
+		if params[offset+i].Variadic {
+			rest := &value.Arr{Elements: make([]value.Value, len(indexes))}
+			for j, index := range indexes {
+				rest.Elements[j] = vals[index]
+			}
+			args[offset+i] = rest
+		} else if len(indexes) > 0 {
+			args[offset+i] = vals[indexes[0]]
+		}
+	}
+	return args, nil
+}
+
+// call executes a closure with already evaluated arguments
+// a closure which returns a tail call is replaced by the closure it calls, without adding to the depth of the stack
+func (m *machine) call(closure *Closure, args []value.Value) (value.Value, error) {
+	if err := m.runtime.Call(closure.Function.Name); err != nil {
+		return nil, err
+	}
+	defer m.runtime.Return()
+
+	c, err := m.runFunction(closure, args)
+	for err == nil && c.tail != nil {
+		tail := c.tail
+		// the frame of the closure making the tail call is gone, so the call is the only one left to report
+		if c, err = m.runFunction(tail.closure, tail.args); err != nil {
+			return nil, evaluator.WithFrame(err, &evaluator.CallFrame{Name: evaluator.FunctionName(tail.call, tail.closure.Function.Declaration), Ref: tail.call.Ref()})
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return c.value, nil
+}
+
+// runFunction runs the code of a closure in a new frame
+// a parameter whose argument is nil or missing is given its default value by the code of the function
+func (m *machine) runFunction(closure *Closure, args []value.Value) (completion, error) {
+	f := newFrame(closure.Function, closure.cells)
+	for i, param := range closure.Function.Declaration.Parameters {
+		var arg value.Value
+		if i < len(args) {
+			arg = args[i]
+		}
+		if arg == nil && param.Variadic {
+			arg = &value.Arr{Elements: make([]value.Value, 0)}
+		} else if arg == nil {
+			continue
+		}
+		val, err := value.ConformParameter(param, arg)
+		if err != nil {
+			return completion{}, err
+		}
+		f.store(i, val)
+	}
+	return m.run(f, 0, len(f.fn.Code))
+}
+
+// callInternal calls a function passed to a method like map, with arguments given in the order of its parameters
+func (m *machine) callInternal(fn value.Func, args []value.Value) (value.Value, error) {
+	closure, ok := fn.(*Closure)
+	if !ok {
+		return nil, fmt.Errorf("cannot call %s", fn.Type())
+	}
+	return m.call(closure, args)
+}
+
+// invoke calls the method of call on target
+// an obj which doesn't have a property of the same name as the method calls fallback, the variable of that name, instead
+func (m *machine) invoke(target, fallback value.Value, call *ast.FunctionCall, args []value.Value) (value.Value, error) {
+	name := call.Function.(*ast.Identifier).Name
+	switch t := target.(type) {
+	case *value.Obj:
+		fn, ok := t.Properties[name]
+		if !ok && fallback == nil {
+			return nil, fmt.Errorf("'%s' was not declared", name)
+		} else if !ok {
+			fn = fallback
+		}
+		return m.callValue(fn, call, nil, args)
+	case *value.Record:
+		if method, ok := t.RecordType.Methods[name]; ok {
+			return m.callValue(method, call, t, args)
+		}
+		// a field holding a function is called without the record
+		if val, ok := t.Fields[name]; ok {
+			return m.callValue(val, call, nil, args)
+		}
+		return nil, fmt.Errorf("%s has no method '%s'", t.Type(), name)
+	}
+	if err := m.runtime.CheckMember(target, name, args); err != nil {
+		return nil, err
+	}
+	val, err := value.CallMember(target, name, args, m.callInternal)
+	if err != nil {
+		return nil, err
+	}
+	// a method like push grows the arr it's called on
+	if err := m.runtime.CheckSize(target); err != nil {
+		return nil, err
+	}
+	return val, m.runtime.CheckSize(val)
+}
+
+// builtin calls one of the functions built into the language with its argument
+func builtin(call *ast.FunctionCall, arg value.Value) (value.Value, error) {
+	switch call.Function.(*ast.Identifier).Name {
+	case "len":
+		return value.Len(arg)
+	case "int":
+		return value.ToInt(arg)
+	default:
+		return value.NewErr(arg)
+	}
+}
@@ -0,0 +1,164 @@
+package vm
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/mcjcloud/taurine/pkg/ast"
+	"github.com/mcjcloud/taurine/pkg/value"
+)
+
+// checkVariable returns the value of a variable, which is nil if it hasn't been declared yet
+// a variable whose type isn't optional can only be nil if it was declared without a value, which is an error to use
+func checkVariable(name string, dType ast.Symbol, val value.Value) (value.Value, error) {
+	if val == nil {
+		return nil, fmt.Errorf("'%s' was not declared", name)
+	}
+	if dType != "" && !dType.IsOptional() {
+		if _, isNil := val.(*value.Nil); isNil {
+			return nil, fmt.Errorf("'%s' of type %s is nil", name, dType)
+		}
+	}
+	return val, nil
+}
+
+// orNil returns val, or nil for a variable which hasn't been declared
+func orNil(val value.Value) value.Value {
+	if val == nil {
+		return &value.Nil{}
+	}
+	return val
+}
+
+// assignVariable converts the value assigned to a variable, whose current value is current, to the variable's type
+func assignVariable(name string, dType ast.Symbol, current, val value.Value) (value.Value, error) {
+	if current == nil {
+		return nil, fmt.Errorf("'%s' was not declared", name)
+	}
+	return value.ConformVariable(name, dType, val)
+}
+
+// boolOperand returns val if it's a bool operand of op
+func boolOperand(op ast.Operator, val value.Value) (*value.Bool, error) {
+	if b, ok := val.(*value.Bool); ok {
+		return b, nil
+	}
+	return nil, fmt.Errorf("'%s' cannot be applied to %s", op, val.Type())
+}
+
+// member returns the property of an obj, the field of a record, or a property built into another type
+func member(val value.Value, name string) (value.Value, error) {
+	switch t := val.(type) {
+	case *value.Obj:
+		// a property which doesn't exist is nil
+		if prop, ok := t.Properties[name]; ok {
+			return prop, nil
+		}
+		return &value.Nil{}, nil
+	case *value.Record:
+		if field, ok := t.Fields[name]; ok {
+			return field, nil
+		}
+		return nil, fmt.Errorf("%s has no field '%s'", t.Type(), name)
+	}
+	return value.Member(val, name)
+}
+
+// elementReference returns a reference to the element of left at the index right, so it can be assigned
+func elementReference(left, right value.Value) (lvalue, error) {
+	index, ok := right.(*value.Int)
+	if !ok {
+		return nil, errors.New("'@' operator must be in form arr@integer")
+	}
+	i := int(index.Value.Int64())
+
+	arr, ok := left.(*value.Arr)
+	if !ok {
+		return nil, fmt.Errorf("cannot assign to an element of %s", left.Type())
+	}
+	if i < 0 || i >= len(arr.Elements) {
+		return nil, fmt.Errorf("index %d out of range", i)
+	}
+	return &elementRef{arr: arr, index: i}, nil
+}
+
+// propertyReference returns a reference to the property or field of left named name, so it can be assigned
+func propertyReference(left value.Value, name string) (lvalue, error) {
+	if rec, ok := left.(*value.Record); ok {
+		field, ok := rec.RecordType.Field(name)
+		if !ok {
+			return nil, fmt.Errorf("%s has no field '%s'", rec.Type(), name)
+		}
+		return &fieldRef{rec: rec, field: field}, nil
+	}
+	obj, ok := left.(*value.Obj)
+	if !ok {
+		return nil, fmt.Errorf("cannot assign to a property of %s", left.Type())
+	}
+	return &propertyRef{obj: obj, name: name}, nil
+}
+
+// iterate returns an iterator over the elements of an arr or the characters of a str
+// objects and arrays in the array are shared with the control variable
+func iterate(val value.Value, step int) (*iterator, error) {
+	if a, ok := val.(*value.Arr); ok {
+		return &iterator{elements: a.Elements, step: step}, nil
+	} else if s, ok := val.(*value.Str); ok {
+		var elements []value.Value
+		for _, c := range s.Value {
+			elements = append(elements, &value.Str{Value: string(c)})
+		}
+		return &iterator{elements: elements, step: step}, nil
+	}
+	return nil, fmt.Errorf("expected array or string iterator but found %s", val.Type())
+}
+
+// inRange returns true if val is one of the numbers the range would contain, from its start up to but not including its end
+func inRange(start, end, val value.Value) bool {
+	x, ok := value.ToFloat(val)
+	if !ok {
+		return false
+	}
+	s, _ := value.ToFloat(start)
+	e, _ := value.ToFloat(end)
+	if s <= e {
+		return s <= x && (x < e || x == s)
+	}
+	// a range from a larger number counts down
+	return e < x && x <= s
+}
+
+// property returns the value of a property of an object or a field of a record, and false if it doesn't have one
+func property(val value.Value, key string) (value.Value, bool) {
+	switch t := val.(type) {
+	case *value.Obj:
+		prop, ok := t.Properties[key]
+		return prop, ok
+	case *value.Record:
+		field, ok := t.Fields[key]
+		return field, ok
+	}
+	return nil, false
+}
+
+// rest returns a new array of the elements of arr from index start
+func rest(arr *value.Arr, start int) *value.Arr {
+	elements := make([]value.Value, len(arr.Elements)-start)
+	copy(elements, arr.Elements[start:])
+	return &value.Arr{Elements: elements}
+}
+
+// destructureArray returns an error unless val is an arr with n elements, or at least n elements if the pattern has a rest
+func destructureArray(val value.Value, n int, hasRest bool) error {
+	arr, ok := val.(*value.Arr)
+	if !ok {
+		return fmt.Errorf("cannot destructure %s as an array", val.Type())
+	}
+	if !hasRest && len(arr.Elements) != n {
+		return fmt.Errorf("expected an array of %d elements but found %d", n, len(arr.Elements))
+	}
+	if len(arr.Elements) < n {
+		return fmt.Errorf("expected an array of at least %d elements but found %d", n, len(arr.Elements))
+	}
+	return nil
+}
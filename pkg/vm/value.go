@@ -0,0 +1,117 @@
+package vm
+
+import (
+	"fmt"
+
+	"github.com/mcjcloud/taurine/pkg/ast"
+	"github.com/mcjcloud/taurine/pkg/compiler"
+	"github.com/mcjcloud/taurine/pkg/value"
+)
+
+// Closure is a function value along with the variables it captured from the functions it was declared in
+type Closure struct {
+	Function *compiler.Function
+	cells    []*cell
+}
+
+func (c *Closure) Type() ast.Symbol                  { return ast.FUNC }
+func (c *Closure) Name() string                      { return c.Function.Declaration.Symbol }
+func (c *Closure) Declaration() *ast.FunctionLiteral { return c.Function.Declaration }
+func (c *Closure) String() string {
+	if c.Function.Declaration.Symbol == "" {
+		return fmt.Sprintf("func (%s)", c.Function.Declaration.ReturnType)
+	}
+	return fmt.Sprintf("func (%s) %s", c.Function.Declaration.ReturnType, c.Function.Declaration.Symbol)
+}
+
+// cell holds a variable which is captured by a closure, so the closure and the frame it was declared in share it
+// a cell whose value is nil is a variable which hasn't been declared yet
+type cell struct {
+	value value.Value
+}
+
+// the values below are only ever on the stack or in a slot the compiler added, so the program never sees them
+
+// iterator is the state of a for loop
+type iterator struct {
+	elements []value.Value
+	index    int
+	step     int
+}
+
+func (i *iterator) Type() ast.Symbol { return "iterator" }
+func (i *iterator) String() string   { return "iterator" }
+
+// mark is the size of the stack at the start of a loop
+type mark int
+
+func (m mark) Type() ast.Symbol { return "mark" }
+func (m mark) String() string   { return "mark" }
+
+// lvalue is a location a value can be assigned to
+type lvalue interface {
+	value.Value
+	get() (value.Value, error)
+	set(val value.Value) (value.Value, error)
+}
+
+// elementRef is an element of an array e.g. arr@i
+type elementRef struct {
+	arr   *value.Arr
+	index int
+}
+
+func (r *elementRef) Type() ast.Symbol { return "ref" }
+func (r *elementRef) String() string   { return "ref" }
+
+func (r *elementRef) get() (value.Value, error) {
+	return r.arr.Elements[r.index], nil
+}
+
+func (r *elementRef) set(val value.Value) (value.Value, error) {
+	r.arr.Elements[r.index] = val
+	return val, nil
+}
+
+// propertyRef is a property of an object e.g. obj.prop, which is created when it is first assigned
+type propertyRef struct {
+	obj  *value.Obj
+	name string
+}
+
+func (r *propertyRef) Type() ast.Symbol { return "ref" }
+func (r *propertyRef) String() string   { return "ref" }
+
+func (r *propertyRef) get() (value.Value, error) {
+	if val, ok := r.obj.Properties[r.name]; ok {
+		return val, nil
+	}
+	return nil, fmt.Errorf("property '%s' does not exist", r.name)
+}
+
+func (r *propertyRef) set(val value.Value) (value.Value, error) {
+	r.obj.Properties[r.name] = val
+	return val, nil
+}
+
+// fieldRef is a field of a record e.g. point.x, which can only hold values of the field's type
+type fieldRef struct {
+	rec   *value.Record
+	field value.Field
+}
+
+func (r *fieldRef) Type() ast.Symbol { return "ref" }
+func (r *fieldRef) String() string   { return "ref" }
+
+func (r *fieldRef) get() (value.Value, error) {
+	return r.rec.Fields[r.field.Name], nil
+}
+
+func (r *fieldRef) set(val value.Value) (value.Value, error) {
+	conformed, err := value.ConformField(r.field, val)
+	if err != nil {
+		return nil, err
+	}
+	r.rec.Fields[r.field.Name] = conformed
+	return conformed, nil
+}
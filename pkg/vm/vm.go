@@ -0,0 +1,604 @@
+package vm
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/mcjcloud/taurine/pkg/ast"
+	"github.com/mcjcloud/taurine/pkg/compiler"
+	"github.com/mcjcloud/taurine/pkg/evaluator"
+	"github.com/mcjcloud/taurine/pkg/value"
+)
+
+// machine is the state of a program while it runs
+type machine struct {
+	modules map[string]*module // every file that has been imported, by path
+	runtime *evaluator.Runtime // the state shared by the whole program: limits used, input and output
+}
+
+// module is the state of a file whose top level is being run
+type module struct {
+	exports map[string]value.Value // the values the file has exported so far
+}
+
+// frame is a call of a function, or the top level of a file
+type frame struct {
+	fn       *compiler.Function
+	slots    []value.Value
+	cells    []*cell // the cells of the slots which are captured, and nil for the others
+	captures []*cell
+	stack    []value.Value
+	module   *module // the file being run, if the frame is the top level of a file
+}
+
+// completionKind is how a range of code ended
+type completionKind int
+
+const (
+	// completed means the code reached the end of the range
+	completed completionKind = iota
+	// jumped means a break or continue jumped outside of the range
+	jumped
+	// returned means a function returned
+	returned
+)
+
+// completion is how a range of code ended
+type completion struct {
+	kind   completionKind
+	target int         // the instruction jumped to
+	value  value.Value // the value returned
+	tail   *tailCall   // the call the function returned, which is made once its frame has ended
+}
+
+// tailCall is a call made in place of the function which returned it
+type tailCall struct {
+	closure *Closure
+	call    *ast.FunctionCall
+	args    []value.Value
+}
+
+// Run executes a compiled program, with the same behavior as evaluating it
+// errors are returned as an *evaluator.RuntimeError when they have a location in the source code
+func Run(program *compiler.Program, opts evaluator.Options) error {
+	m := &machine{modules: make(map[string]*module), runtime: evaluator.NewRuntime(opts)}
+	_, err := m.runModule(program.Main)
+	return err
+}
+
+// runModule runs the top level of a file, and returns the state of the file once it's done
+func (m *machine) runModule(fn *compiler.Function) (*module, error) {
+	f := newFrame(fn, nil)
+	f.module = &module{exports: make(map[string]value.Value)}
+	if _, err := m.run(f, 0, len(fn.Code)); err != nil {
+		return nil, err
+	}
+	return f.module, nil
+}
+
+func newFrame(fn *compiler.Function, captures []*cell) *frame {
+	f := &frame{
+		fn:       fn,
+		slots:    make([]value.Value, len(fn.Slots)),
+		cells:    make([]*cell, len(fn.Slots)),
+		captures: captures,
+		stack:    make([]value.Value, 0, 8),
+	}
+	if len(fn.Scopes) > 0 {
+		f.enterScope(0)
+	}
+	return f
+}
+
+// enterScope clears the slots of a scope, giving each captured slot a new cell
+func (f *frame) enterScope(index int) {
+	for _, slot := range f.fn.Scopes[index] {
+		f.slots[slot] = nil
+		if f.fn.Slots[slot].Captured {
+			f.cells[slot] = &cell{}
+		}
+	}
+}
+
+func (f *frame) load(slot int) value.Value {
+	if c := f.cells[slot]; c != nil {
+		return c.value
+	}
+	return f.slots[slot]
+}
+
+func (f *frame) store(slot int, val value.Value) {
+	if c := f.cells[slot]; c != nil {
+		c.value = val
+	} else {
+		f.slots[slot] = val
+	}
+}
+
+func (f *frame) push(val value.Value) {
+	f.stack = append(f.stack, val)
+}
+
+func (f *frame) pop() value.Value {
+	val := f.stack[len(f.stack)-1]
+	f.stack = f.stack[:len(f.stack)-1]
+	return val
+}
+
+func (f *frame) peek() value.Value {
+	return f.stack[len(f.stack)-1]
+}
+
+// popN pops n values, returning them in the order they were pushed
+func (f *frame) popN(n int) []value.Value {
+	vals := make([]value.Value, n)
+	copy(vals, f.stack[len(f.stack)-n:])
+	f.stack = f.stack[:len(f.stack)-n]
+	return vals
+}
+
+// fail converts err to a RuntimeError located at the instruction ip
+// errors which are already RuntimeErrors, and instructions without a location, are left alone
+func (f *frame) fail(ip int, err error) error {
+	if _, ok := err.(*evaluator.RuntimeError); ok {
+		return err
+	}
+	ref := f.fn.Refs[ip]
+	if ref == nil {
+		return err
+	}
+	return &evaluator.RuntimeError{Message: err.Error(), Ref: ref, Err: err}
+}
+
+// run executes the instructions of f from start until it reaches end
+// a jump outside of the range ends it, so a try statement can run its finally block before the jump is taken
+func (m *machine) run(f *frame, start, end int) (completion, error) {
+	code := f.fn.Code
+	pc := start
+	for pc < end {
+		ip := pc
+		in := code[ip]
+		pc++
+
+		jump := -1
+		err := m.runtime.Step()
+		if err != nil {
+			return completion{}, f.fail(ip, err)
+		}
+		switch in.Op {
+		case compiler.OpConstant:
+			f.push(f.fn.Constants[in.A])
+		case compiler.OpNil:
+			f.push(&value.Nil{})
+		case compiler.OpUndefined:
+			f.push(nil)
+		case compiler.OpPop:
+			f.pop()
+		case compiler.OpError:
+			err = errors.New(f.fn.Strings[in.A])
+
+		case compiler.OpEnterScope:
+			f.enterScope(in.A)
+		case compiler.OpGetLocal:
+			slot := f.fn.Slots[in.A]
+			var val value.Value
+			val, err = checkVariable(slot.Name, slot.Type, f.load(in.A))
+			if err == nil {
+				f.push(val)
+			}
+		case compiler.OpLoadLocal:
+			f.push(orNil(f.load(in.A)))
+		case compiler.OpDeclareLocal:
+			slot := f.fn.Slots[in.A]
+			if in.B == 1 {
+				f.store(in.A, &value.Nil{})
+				f.push(&value.Nil{})
+				break
+			}
+			var val value.Value
+			if val, err = value.ConformVariable(slot.Name, slot.Type, f.pop()); err == nil {
+				f.store(in.A, val)
+				f.push(val)
+			}
+		case compiler.OpAssignLocal:
+			slot := f.fn.Slots[in.A]
+			var val value.Value
+			if val, err = assignVariable(slot.Name, slot.Type, f.load(in.A), f.pop()); err == nil {
+				f.store(in.A, val)
+				f.push(val)
+			}
+		case compiler.OpStoreLocal:
+			f.store(in.A, f.peek())
+		case compiler.OpGetCapture:
+			capture := f.fn.Captures[in.A]
+			var val value.Value
+			val, err = checkVariable(capture.Name, capture.Type, f.captures[in.A].value)
+			if err == nil {
+				f.push(val)
+			}
+		case compiler.OpLoadCapture:
+			f.push(orNil(f.captures[in.A].value))
+		case compiler.OpAssignCapture:
+			capture := f.fn.Captures[in.A]
+			c := f.captures[in.A]
+			var val value.Value
+			if val, err = assignVariable(capture.Name, capture.Type, c.value, f.pop()); err == nil {
+				c.value = val
+				f.push(val)
+			}
+		case compiler.OpStoreCapture:
+			f.captures[in.A].value = f.peek()
+		case compiler.OpMark:
+			f.store(in.A, mark(len(f.stack)))
+		case compiler.OpUnwind:
+			f.stack = f.stack[:f.load(in.A).(mark)]
+		case compiler.OpSkipIfBound:
+			if f.load(in.B) != nil {
+				jump = in.A
+			}
+
+		case compiler.OpJump:
+			jump = in.A
+		case compiler.OpJumpIfFalse, compiler.OpJumpIfTrue:
+			b, ok := f.pop().(*value.Bool)
+			if !ok {
+				err = errors.New(f.fn.Strings[in.B])
+			} else if b.Value == (in.Op == compiler.OpJumpIfTrue) {
+				jump = in.A
+			}
+		case compiler.OpAnd, compiler.OpOr:
+			op := ast.Operator(ast.AND)
+			if in.Op == compiler.OpOr {
+				op = ast.OR
+			}
+			// the right side is only evaluated if the left side doesn't decide the result
+			var b *value.Bool
+			if b, err = boolOperand(op, f.peek()); err == nil {
+				if b.Value == (in.Op == compiler.OpOr) {
+					jump = in.A
+				} else {
+					f.pop()
+				}
+			}
+		case compiler.OpBool:
+			_, err = boolOperand(ast.Operator(f.fn.Strings[in.A]), f.peek())
+		case compiler.OpCoalesce:
+			if _, isNil := f.peek().(*value.Nil); !isNil {
+				jump = in.A
+			} else {
+				f.pop()
+			}
+
+		case compiler.OpBinary:
+			right, left := f.pop(), f.pop()
+			op := ast.Operator(f.fn.Strings[in.A])
+			if err = m.runtime.CheckOperation(op, left, right); err != nil {
+				break
+			}
+			var val value.Value
+			if val, err = value.BinaryOperation(op, left, right); err == nil {
+				err = m.runtime.CheckSize(val)
+				f.push(val)
+			}
+		case compiler.OpNegate:
+			var val value.Value
+			if val, err = value.Negate(f.pop()); err == nil {
+				f.push(val)
+			}
+		case compiler.OpNot:
+			var b *value.Bool
+			if b, err = boolOperand(ast.NOT, f.pop()); err == nil {
+				f.push(&value.Bool{Value: !b.Value})
+			}
+		case compiler.OpArray:
+			arr := &value.Arr{Elements: f.popN(in.A)}
+			err = m.runtime.CheckSize(arr)
+			f.push(arr)
+		case compiler.OpObject:
+			keys := f.fn.Objects[in.A]
+			vals := f.popN(len(keys))
+			obj := &value.Obj{Properties: make(map[string]value.Value, len(keys))}
+			for i, k := range keys {
+				obj.Properties[k] = vals[i]
+			}
+			f.push(obj)
+		case compiler.OpStringify:
+			f.push(&value.Str{Value: value.Stringify(f.pop())})
+		case compiler.OpInterpolate:
+			var str strings.Builder
+			for _, part := range f.popN(in.A) {
+				str.WriteString(part.(*value.Str).Value)
+			}
+			interpolated := &value.Str{Value: str.String()}
+			err = m.runtime.CheckSize(interpolated)
+			f.push(interpolated)
+		case compiler.OpClosure:
+			f.push(f.closure(f.fn.Functions[in.A]))
+		case compiler.OpMethod:
+			closure := f.pop().(*Closure)
+			if err = value.DeclareMethod(f.pop(), closure.Function.Declaration, closure); err == nil {
+				f.push(closure)
+			}
+		case compiler.OpType:
+			decl := f.fn.Types[in.A]
+			fields := make([]value.Field, len(decl.Fields))
+			for i, field := range decl.Fields {
+				fields[i] = value.Field{Name: field.Symbol, Type: ast.Symbol(field.SymbolType)}
+			}
+			f.push(value.NewRecordType(decl.Symbol, fields))
+
+		case compiler.OpProperty:
+			var val value.Value
+			if val, err = member(f.pop(), f.fn.Strings[in.A]); err == nil {
+				f.push(val)
+			}
+		case compiler.OpCall:
+			call := f.fn.Calls[in.A]
+			args := f.popN(len(call.Arguments))
+			var val value.Value
+			if val, err = m.callValue(f.pop(), call, nil, args); err == nil {
+				f.push(val)
+			}
+		case compiler.OpInvoke:
+			call := f.fn.Calls[in.A]
+			args := f.popN(len(call.Arguments))
+			fallback := f.pop()
+			var val value.Value
+			if val, err = m.invoke(f.pop(), fallback, call, args); err == nil {
+				f.push(val)
+			}
+		case compiler.OpTailCall:
+			call := f.fn.Calls[in.A]
+			args := f.popN(len(call.Arguments))
+			fn := f.pop()
+			// the function is only replaced by one with the same return type, so the value it returns doesn't need to be converted
+			closure, ok := fn.(*Closure)
+			if decl := f.fn.Declaration; ok && decl != nil && decl.ReturnType == closure.Function.Declaration.ReturnType {
+				var bound []value.Value
+				if bound, err = bind(closure, call, nil, args); err == nil {
+					return completion{kind: returned, tail: &tailCall{closure: closure, call: call, args: bound}}, nil
+				}
+				break
+			}
+			var val value.Value
+			if val, err = m.callValue(fn, call, nil, args); err == nil {
+				f.push(val)
+			}
+		case compiler.OpBuiltin:
+			var val value.Value
+			if val, err = builtin(f.fn.Calls[in.A], f.pop()); err == nil {
+				f.push(val)
+			}
+		case compiler.OpReturn:
+			var val value.Value
+			if val, err = value.ConformReturn(f.fn.Declaration, f.pop()); err == nil {
+				return completion{kind: returned, value: val}, nil
+			}
+		case compiler.OpEnd:
+			// only void functions can end without returning
+			if decl := f.fn.Declaration; decl != nil && decl.ReturnType != ast.VOID {
+				err = fmt.Errorf("function of type %s ended without returning a value", decl.ReturnType)
+			} else {
+				return completion{kind: returned, value: &value.Nil{}}, nil
+			}
+
+		case compiler.OpElementRef:
+			right, left := f.pop(), f.pop()
+			var ref lvalue
+			if ref, err = elementReference(left, right); err == nil {
+				f.push(ref)
+			}
+		case compiler.OpPropertyRef:
+			var ref lvalue
+			if ref, err = propertyReference(f.pop(), f.fn.Strings[in.A]); err == nil {
+				f.push(ref)
+			}
+		case compiler.OpRefGet:
+			var val value.Value
+			if val, err = f.peek().(lvalue).get(); err == nil {
+				f.push(val)
+			}
+		case compiler.OpRefSet:
+			val := f.pop()
+			if val, err = f.pop().(lvalue).set(val); err == nil {
+				f.push(val)
+			}
+
+		case compiler.OpIter:
+			var it *iterator
+			if it, err = iterate(f.pop(), in.A); err == nil {
+				f.push(it)
+			}
+		case compiler.OpNext:
+			it := f.load(in.B).(*iterator)
+			if it.index >= len(it.elements) {
+				jump = in.A
+			} else {
+				f.push(it.elements[it.index])
+				it.index += it.step
+			}
+
+		case compiler.OpMatchLiteral:
+			lit, val := f.pop(), f.pop()
+			f.push(&value.Bool{Value: value.Comparable(lit, val) && value.Equal(lit, val)})
+		case compiler.OpMatchRange:
+			end, start, val := f.pop(), f.pop(), f.pop()
+			f.push(&value.Bool{Value: inRange(start, end, val)})
+		case compiler.OpMatchType:
+			val := f.pop()
+			dType := ast.Symbol(f.fn.Strings[in.A])
+			_, isNil := val.(*value.Nil)
+			f.push(&value.Bool{Value: val.Type() == dType.BaseType() || (isNil && dType.IsOptional())})
+		case compiler.OpMatchArray:
+			arr, ok := f.pop().(*value.Arr)
+			matched := ok && len(arr.Elements) >= in.A && (in.B == 1 || len(arr.Elements) == in.A)
+			f.push(&value.Bool{Value: matched})
+		case compiler.OpMatchObject:
+			val := f.pop()
+			_, isObj := val.(*value.Obj)
+			_, isRecord := val.(*value.Record)
+			matched := isObj || isRecord
+			for _, key := range f.fn.Objects[in.A] {
+				if _, ok := property(val, key); !ok {
+					matched = false
+				}
+			}
+			f.push(&value.Bool{Value: matched})
+		case compiler.OpElement:
+			f.push(f.pop().(*value.Arr).Elements[in.A])
+		case compiler.OpRest:
+			f.push(rest(f.pop().(*value.Arr), in.A))
+		case compiler.OpField:
+			val, _ := property(f.pop(), f.fn.Strings[in.A])
+			f.push(val)
+		case compiler.OpDestructureArray:
+			err = destructureArray(f.pop(), in.A, in.B == 1)
+		case compiler.OpDestructureObject:
+			val := f.pop()
+			_, isObj := val.(*value.Obj)
+			_, isRecord := val.(*value.Record)
+			if !isObj && !isRecord {
+				err = fmt.Errorf("cannot destructure %s as an object", val.Type())
+			}
+		case compiler.OpDestructureField:
+			val := f.pop()
+			key := f.fn.Strings[in.A]
+			if prop, ok := property(val, key); ok {
+				f.push(prop)
+			} else {
+				err = fmt.Errorf("cannot destructure missing property '%s' of %s", key, val.Type())
+			}
+
+		case compiler.OpEtch:
+			vals := f.popN(in.A)
+			toEtch := make([]string, len(vals))
+			for i, val := range vals {
+				toEtch[i] = val.(*value.Str).Value
+			}
+			err = m.runtime.Etch(strings.Join(toEtch, " "))
+		case compiler.OpRead:
+			prompt := ""
+			if in.A != -1 {
+				prompt = f.fn.Strings[in.A]
+			}
+			var val value.Value
+			if val, err = m.runtime.Read(prompt); err == nil {
+				f.push(val)
+			}
+		case compiler.OpThrow:
+			err = evaluator.Throw(f.pop(), f.fn.Refs[ip])
+		case compiler.OpTry:
+			c, tryErr := m.try(f, f.fn.Tries[in.A])
+			if tryErr != nil {
+				return completion{}, tryErr
+			}
+			switch c.kind {
+			case completed:
+				jump = f.fn.Tries[in.A].End
+			case jumped:
+				jump = c.target
+			case returned:
+				return c, nil
+			}
+		case compiler.OpImport:
+			var vals []value.Value
+			if vals, err = m.importValues(f.fn.Imports[in.A]); err == nil {
+				f.stack = append(f.stack, vals...)
+			}
+		case compiler.OpExport:
+			if f.module == nil {
+				err = errors.New("only the top level of a file can export values")
+			} else {
+				f.module.exports[f.fn.Strings[in.A]] = f.pop()
+			}
+		default:
+			err = fmt.Errorf("unknown instruction %s", in.Op)
+		}
+
+		if err != nil {
+			return completion{}, f.fail(ip, err)
+		}
+		if jump != -1 {
+			if jump < start || jump > end {
+				return completion{kind: jumped, target: jump}, nil
+			}
+			pc = jump
+		}
+	}
+	return completion{kind: completed}, nil
+}
+
+// try runs the blocks of a try statement
+// the finally block runs even if the try or catch block returned, or ended with an error
+// a return, break, or continue in the finally block replaces whichever one came before it
+func (m *machine) try(f *frame, t *compiler.Try) (completion, error) {
+	depth := len(f.stack)
+	c, err := m.run(f, t.Body[0], t.Body[1])
+	if evaluator.IsLimit(err) {
+		return completion{}, err
+	}
+	if err != nil && t.Statement.Catch != nil {
+		f.stack = f.stack[:depth]
+		f.enterScope(t.ParamScope)
+		f.store(t.ParamSlot, evaluator.ErrorValue(err))
+		c, err = m.run(f, t.Catch[0], t.Catch[1])
+		if evaluator.IsLimit(err) {
+			return completion{}, err
+		}
+	}
+	if t.Statement.Finally == nil {
+		return c, err
+	}
+
+	if err != nil {
+		f.stack = f.stack[:depth]
+	}
+	finally, finallyErr := m.run(f, t.Finally[0], t.Finally[1])
+	if finallyErr != nil {
+		return completion{}, finallyErr
+	}
+	if finally.kind != completed {
+		return finally, nil
+	}
+	return c, err
+}
+
+// importValues runs an imported file the first time it's imported, and returns the values being imported from it
+func (m *machine) importValues(imp *compiler.Import) ([]value.Value, error) {
+	imported, ok := m.modules[imp.Path]
+	if !ok {
+		if imp.Module == nil {
+			return nil, fmt.Errorf("could not find referenced file %s", imp.Path)
+		}
+		var err error
+		imported, err = m.runModule(imp.Module)
+		if err != nil {
+			return nil, evaluator.WithFrame(err, &evaluator.CallFrame{Name: imp.Path, Import: true, Ref: imp.Statement.Ref()})
+		}
+		m.modules[imp.Path] = imported
+	}
+
+	vals := make([]value.Value, len(imp.Statement.Imports))
+	for i, id := range imp.Statement.Imports {
+		val, ok := imported.exports[id.Name]
+		if !ok {
+			return nil, fmt.Errorf("symbol '%s' is not exported from %s", id.Name, imp.Path)
+		}
+		vals[i] = val
+	}
+	return vals, nil
+}
+
+// closure creates a function of fn which shares the cells of the variables it captures with f
+func (f *frame) closure(fn *compiler.Function) *Closure {
+	cells := make([]*cell, len(fn.Captures))
+	for i, c := range fn.Captures {
+		if c.Local {
+			cells[i] = f.cells[c.Index]
+		} else {
+			cells[i] = f.captures[c.Index]
+		}
+	}
+	return &Closure{Function: fn, cells: cells}
+}
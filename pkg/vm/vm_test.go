@@ -0,0 +1,195 @@
+package vm
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/mcjcloud/taurine/pkg/compiler"
+	"github.com/mcjcloud/taurine/pkg/evaluator"
+	"github.com/mcjcloud/taurine/pkg/parser"
+)
+
+// result is what running a program printed, and the error it ended with
+type result struct {
+	output string
+	err    error
+}
+
+// runSource parses src and runs it with both the evaluator and the virtual machine, failing the test if there are parse errors
+func runSource(t *testing.T, src string) (result, result) {
+	t.Helper()
+	return runWithOptions(t, src, evaluator.Options{})
+}
+
+// runWithOptions runs src with both the evaluator and the virtual machine with the given options
+func runWithOptions(t *testing.T, src string, opts evaluator.Options) (result, result) {
+	t.Helper()
+	ctx, err := parser.NewParseContextFromSource("/src/main.tc", src)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	tree := parser.Parse(ctx)
+	ctx.PopImportWithTree(tree)
+	if ctx.HasErrors() {
+		t.Fatalf("unexpected parse errors in %q", src)
+	}
+
+	evaluated := capture(opts, func(opts evaluator.Options) error { return evaluator.Evaluate(tree, ctx.ImportGraph, opts) })
+	ran := capture(opts, func(opts evaluator.Options) error {
+		program, err := compiler.Compile(tree, ctx.ImportGraph)
+		if err != nil {
+			return err
+		}
+		return Run(program, opts)
+	})
+	return evaluated, ran
+}
+
+func TestTailCalls(t *testing.T) {
+	src := "func (int) count(int n, int total) {\n  if n == 0 {\n    return total;\n  }\n  return count(n - 1, total + 1);\n}\netch count(100000, 0);"
+	evaluated, ran := runSource(t, src)
+	for _, r := range []result{evaluated, ran} {
+		if r.err != nil || r.output != "100000\n" {
+			t.Errorf("expected output %q but found %q with error %v", "100000\n", r.output, r.err)
+		}
+	}
+}
+
+func TestDeepRecursion(t *testing.T) {
+	// each level of the second function makes two calls, through a method and inside a try and a match
+	deep := "func (int) deep(int n) {\n  if n == 0 {\n    return 0;\n  }\n  return 1 + deep(n - 1);\n}\n"
+	deeper := "func (int) deep(int n) {\n  if n == 0 {\n    return 0;\n  }\n  var (arr) r = [n - 1].map(func (int) (int m) {\n    try {\n      return match m { (int) k => 1 + deep(k) };\n    } catch (err e) {\n      throw e;\n    }\n  });\n  return r@0;\n}\n"
+	tests := []struct {
+		src    string
+		output string
+	}{
+		{deep + fmt.Sprintf("etch deep(%d);", evaluator.MaxDepthLimit-1), fmt.Sprintf("%d\n", evaluator.MaxDepthLimit-1)},
+		{deeper + fmt.Sprintf("etch deep(%d);", evaluator.MaxDepthLimit/2-1), fmt.Sprintf("%d\n", evaluator.MaxDepthLimit/2-1)},
+		// a larger limit is lowered, so going past it raises an error rather than running out of Go stack
+		{deep + "try {\n  deep(1000000);\n} catch (err e) {\n  etch e;\n}", "err(\"stack overflow in call to 'deep'\")\n"},
+	}
+	for _, test := range tests {
+		evaluated, ran := runWithOptions(t, test.src, evaluator.Options{MaxDepth: 100000000})
+		for _, r := range []result{evaluated, ran} {
+			if r.err != nil || r.output != test.output {
+				t.Errorf("expected output %q for %q but found %q with error %v", test.output, test.src, r.output, r.err)
+			}
+		}
+	}
+}
+
+func TestLimits(t *testing.T) {
+	loop := "var (int) i = 0;\nwhile true {\n  i += 1;\n}"
+	tests := []struct {
+		src    string
+		opts   evaluator.Options
+		target interface{}
+	}{
+		{loop, evaluator.Options{MaxSteps: 1000}, new(*evaluator.StepLimitError)},
+		{"var (arr) a = [];\nwhile true {\n  a.push(1);\n}", evaluator.Options{MaxSize: 100}, new(*evaluator.SizeLimitError)},
+		{"etch \"\\(0..1000000000)\";", evaluator.Options{MaxSize: 100}, new(*evaluator.SizeLimitError)},
+		{"var (int) i = 0;\nwhile true {\n  etch \"line\", i;\n  i += 1;\n}", evaluator.Options{MaxOutput: 100}, new(*evaluator.OutputLimitError)},
+		{"var (str?) s;\nwhile true {\n  read s, \"" + strings.Repeat("?", 40) + "\";\n}", evaluator.Options{MaxOutput: 100, Stdin: strings.NewReader("")}, new(*evaluator.OutputLimitError)},
+		{"try {\n  " + loop + "\n} catch (err e) {\n  etch \"caught\";\n} finally {\n  etch \"finally\";\n}", evaluator.Options{MaxSteps: 1000}, new(*evaluator.StepLimitError)},
+	}
+	for _, test := range tests {
+		evaluated, ran := runWithOptions(t, test.src, test.opts)
+		if evaluated.output != ran.output {
+			t.Errorf("expected output %q for %q but found %q", evaluated.output, test.src, ran.output)
+		}
+		if !errors.As(evaluated.err, test.target) || !errors.As(ran.err, test.target) {
+			t.Errorf("expected %T for %q but found %v and %v", test.target, test.src, evaluated.err, ran.err)
+		}
+	}
+}
+
+// capture runs a program with opts, recording what it prints
+func capture(opts evaluator.Options, execute func(evaluator.Options) error) result {
+	var output strings.Builder
+	opts.Stdout = &output
+	err := execute(opts)
+	return result{output: output.String(), err: err}
+}
+
+// sameError returns true if a and b are the same error, located at the same place with the same calls leading to it
+func sameError(a, b error) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	if a.Error() != b.Error() {
+		return false
+	}
+	rtA, okA := a.(*evaluator.RuntimeError)
+	rtB, okB := b.(*evaluator.RuntimeError)
+	if !okA || !okB {
+		return okA == okB
+	}
+	if len(rtA.Stack) != len(rtB.Stack) || (rtA.Value == nil) != (rtB.Value == nil) {
+		return false
+	}
+	for i, frame := range rtA.Stack {
+		other := rtB.Stack[i]
+		if frame.Name != other.Name || frame.Import != other.Import || *frame.Ref != *other.Ref {
+			return false
+		}
+	}
+	return true
+}
+
+func TestSameAsEvaluator(t *testing.T) {
+	tests := []string{
+		"func (num) inner(num a) {\n  return a * \"b\";\n}\nfunc (num) outer(num a) {\n  return inner(a);\n}\netch outer(1);",
+		"var (int) x = 1;\nx = 1.5;",
+		"var (int) x = 1;\nx += 0.5;",
+		"func (int) f(int a) { return a; }\nf(\"a\");",
+		"func (int) f() { return 1; }\nf = 2;",
+		"func (num) f() {\n  return \"a\";\n}\nf();",
+		"func (int) f(bool b) {\n  if b {\n    return 1;\n  }\n}\nf(false);",
+		"var (arr) a = [1];\na@1 = 2;",
+		"var (obj) o = {a: 1};\no.b += 1;",
+		"var (str) s;\netch s;",
+		"var (int?) x;\netch x + 1;",
+		"func (int) check(int n) {\n  if n < 0 {\n    throw err(\"negative\");\n  }\n  return n;\n}\ntry {\n  check(-1);\n} finally {\n  etch \"done\";\n}",
+		"throw 1;",
+		"try {\n  etch [1]@2;\n} catch (err e) {\n  throw \"wrapped: \" + e.message;\n}",
+		"type Point { num x, num y }\nPoint(1, \"a\");",
+		"type Point { num x, num y }\nPoint(1, 2).norm();",
+		"type Point { num x }\nfunc (void) Point.f(Point p) {}\nfunc (void) Point.f(Point p) {}",
+		"var [num a, num b] = [1];",
+		"var (obj) o = {a: 1};\nvar {num b} = o;",
+		"for [k, v] in [[1, 2], 3] {}",
+		"func (int) f(int a, int b = 1) { return a + b; }\nf(b: 2);",
+		"func (int) f(int nums...) { return 0; }\nf(1, \"a\");",
+		"func (int) f(int a = \"a\") { return a; }\nf();",
+		"etch len(s: \"a\");",
+		"var (int) i = 0;\nwhile i < 5 {\n  i += 1;\n  try {\n    if i == 2 { continue; }\n    if i == 4 { break; }\n    etch i;\n  } finally {\n    etch \"finally\", i;\n  }\n}",
+		"func (int) f() {\n  try {\n    return 1;\n  } finally {\n    etch \"finally\";\n  }\n}\netch f();",
+		"var (arr) fns = [];\nfor x in [1, 2, 3] {\n  fns.push(func (int) () { return x * 10; });\n}\nfor f in fns { etch f(); }",
+		"func (int) count() { return n; }\nvar (int) n = 3;\netch count();",
+		"for x in [1, 2, 3] {\n  match x { 1 => { etch \"one\"; }, 2 => { continue; }, _ => { etch \"many\"; } };\n}",
+		"var (obj) o = {greet: func (str) (str name) { return \"hi \" + name; }};\netch o.greet(\"bob\");",
+		"func (int) f(int a, int b = a * 2, int rest...) { return a + b + len(rest); }\netch f(1), f(1, 1, 1, 1), f(b: 5, a: 1);",
+		"var (int) i = 5;\nfor i in [1, 2] {}\netch i;",
+		"var (str) s = \"outer\";\n{\n  etch s;\n  var (str) s = \"inner\";\n  etch s;\n}\netch s;",
+		"func (int) deep(int n) {\n  return 1 + deep(n + 1);\n}\ndeep(0);",
+		"func (int) deep(int n) {\n  return 1 + deep(n + 1);\n}\ntry {\n  deep(0);\n} catch (err e) {\n  etch e.message;\n}",
+		"func (int) g() {\n  etch \"g\";\n  return 1;\n}\nfunc (int) f() {\n  try {\n    throw \"x\";\n  } catch (err e) {\n    return g();\n  } finally {\n    etch \"finally\";\n  }\n}\netch f();",
+		"func (num) half(int n) {\n  return n / 2;\n}\nfunc (int) f() {\n  return half(3);\n}\nf();",
+		"func (int) f(int n) {\n  if n == 0 {\n    return [1]@2;\n  }\n  return f(n - 1);\n}\nf(3);",
+		// each value is printed as it was when it was evaluated, even if a later one changes it
+		"var (arr) q = [1, 2];\netch q, q.pop(), q;",
+		"var (arr) q = [1, 2];\netch \"\\(q) \\(q.pop()) \\(q)\";",
+		"var (obj) o = {n: 1};\nfunc (int) bump() {\n  o.n += 1;\n  return o.n;\n}\netch o, bump(), o;",
+	}
+	for _, src := range tests {
+		evaluated, ran := runSource(t, src)
+		if evaluated.output != ran.output {
+			t.Errorf("expected output %q for %q but found %q", evaluated.output, src, ran.output)
+		}
+		if !sameError(evaluated.err, ran.err) {
+			t.Errorf("expected error %v for %q but found %v", evaluated.err, src, ran.err)
+		}
+	}
+}
@@ -8,10 +8,10 @@ import (
 	"path/filepath"
 	"strings"
 
-	"github.com/mcjcloud/taurine/pkg/ast"
+	"github.com/mcjcloud/taurine/pkg/compiler"
 	"github.com/mcjcloud/taurine/pkg/evaluator"
 	"github.com/mcjcloud/taurine/pkg/parser"
-	"github.com/mcjcloud/taurine/pkg/util"
+	"github.com/mcjcloud/taurine/pkg/vm"
 
 	"github.com/kylelemons/godebug/diff"
 )
@@ -70,10 +70,9 @@ func testDirectory(path string) error {
 		return err
 	}
 	tree := parser.Parse(ctx)
-	// print any errors during parsing
-	if ctx.HasErrors() {
-		ctx.PrintErrors()
-		os.Exit(1)
+	// return any errors during parsing, so the other directories are still tested
+	if err := ctx.Err(); err != nil {
+		return err
 	}
 
 	// compare the AST results
@@ -84,45 +83,55 @@ func testDirectory(path string) error {
 		fmt.Printf("done\n")
 	}
 
-	// evaluate test code
+	// evaluate test code with the evaluator, then with the virtual machine
 	fmt.Printf("testing output... ")
-	if err := evaluateTestCode(path, tree, ctx.ImportGraph); err != nil {
+	if err := testOutput(path, expectedOutput, func(opts evaluator.Options) error { return evaluator.Evaluate(tree, ctx.ImportGraph, opts) }); err != nil {
+		return err
+	}
+	fmt.Printf("testing vm output... ")
+	return testOutput(path, expectedOutput, func(opts evaluator.Options) error {
+		program, err := compiler.Compile(tree, ctx.ImportGraph)
+		if err != nil {
+			return err
+		}
+		return vm.Run(program, opts)
+	})
+}
+
+// testOutput runs the test code with execute, and compares what it printed to the expected output
+func testOutput(path, expectedOutput string, execute func(evaluator.Options) error) error {
+	if err := executeTestCode(path, execute); err != nil {
 		return err
 	}
 
 	// check results of execution
 	outBytes, err := os.ReadFile(filepath.Join(path, "output.tmp"))
+	if err != nil {
+		return err
+	}
 	output := string(outBytes)
 	if output != expectedOutput {
 		return errors.New(fmt.Sprintf("expected output does not match actual output\n%s", diff.Diff(expectedOutput, output)))
-	} else {
-		fmt.Printf("done")
 	}
-
+	fmt.Printf("done\n")
 	return nil
 }
 
-func evaluateTestCode(path string, tree *ast.Ast, g *util.ImportGraph) error {
-	// set stdin to input.txt for program execution
+func executeTestCode(path string, execute func(evaluator.Options) error) error {
+	// read input.txt for program execution
 	in, err := os.Open(filepath.Join(path, "input.txt"))
 	if err != nil {
 		return err
 	}
 	defer in.Close()
-	oldStdin := os.Stdin
-	defer func() { os.Stdin = oldStdin }()
-	os.Stdin = in
 
-	// set stdout to output.tmp for later comparison
+	// write to output.tmp for later comparison
 	out, err := os.Create(filepath.Join(path, "output.tmp"))
 	if err != nil {
 		return err
 	}
 	defer out.Close()
-	oldStdout := os.Stdout
-	defer func() { os.Stdout = oldStdout }()
-	os.Stdout = out
 
 	// execute the program
-	return evaluator.Evaluate(tree, g)
+	return execute(evaluator.Options{Stdin: in, Stdout: out})
 }